@@ -0,0 +1,51 @@
+package merkle
+
+const (
+	// arenaChunkNodesMax is the largest number of nodes a nodeArena
+	// allocates at once.
+	arenaChunkNodesMax = 4096
+)
+
+// nodeArena hands out room for nodes carved from larger backing slices, so
+// that building a tree allocates a slice per few thousand nodes instead of
+// one per node. Nodes are never modified in place, so they may share a
+// backing slice; a chunk is freed once none of its nodes is referenced.
+//
+// The first chunk holds the number of nodes the arena is expected to hand
+// out, and every further chunk twice as many as the one before, up to
+// arenaChunkNodesMax, so that a few live nodes never pin much more memory
+// than they take.
+//
+// A nil arena hands out nil, for the trees whose levels copy nodes inline.
+type nodeArena struct {
+	nodeSize   int
+	chunkNodes int
+	buf        []byte
+}
+
+// newNodeArena returns an arena expected to hand out room for the given
+// number of nodes, or for an unknown number if it is 0.
+func (tree *Tree) newNodeArena(nodes int) *nodeArena {
+	if tree.hashSize == inlineNodeSize {
+		return nil
+	}
+	return &nodeArena{
+		nodeSize:   int(tree.hashSize),
+		chunkNodes: min(max(nodes, 1), arenaChunkNodesMax),
+	}
+}
+
+// alloc returns an empty slice with room for exactly one node, to be
+// appended to.
+func (arena *nodeArena) alloc() []byte {
+	if arena == nil {
+		return nil
+	}
+	if len(arena.buf) < arena.nodeSize {
+		arena.buf = make([]byte, arena.nodeSize*arena.chunkNodes)
+		arena.chunkNodes = min(2*arena.chunkNodes, arenaChunkNodesMax)
+	}
+	b := arena.buf[:0:arena.nodeSize]
+	arena.buf = arena.buf[arena.nodeSize:]
+	return b
+}
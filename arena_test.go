@@ -0,0 +1,55 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"testing"
+)
+
+func TestNodeArena(t *testing.T) {
+	arena := &nodeArena{nodeSize: 4, chunkNodes: 1}
+
+	nodes := make([][]byte, arenaChunkNodesMax+1)
+	for i := range nodes {
+		nodes[i] = append(arena.alloc(), byte(i), byte(i), byte(i), byte(i))
+	}
+
+	// appending past the room of a node must not clobber the next one
+	_ = append(nodes[0], 0xff)
+
+	for i, node := range nodes {
+		expected := []byte{byte(i), byte(i), byte(i), byte(i)}
+		if !bytes.Equal(node, expected) {
+			t.Errorf("node %d: expected: %x, actual: %x", i, expected, node)
+		}
+	}
+}
+
+func TestTree_newNodeArena(t *testing.T) {
+	tree, err := NewTree(sha512.New(), 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		nodes      int
+		chunkSizes []int
+	}{
+		{0, []int{1, 2, 4}},
+		{3, []int{3, 6, 12}},
+		{arenaChunkNodesMax + 1, []int{arenaChunkNodesMax, arenaChunkNodesMax}},
+	}
+
+	for _, tc := range testCases {
+		arena := tree.newNodeArena(tc.nodes)
+		for _, chunkSize := range tc.chunkSizes {
+			arena.alloc()
+			if actual := len(arena.buf)/arena.nodeSize + 1; actual != chunkSize {
+				t.Errorf("%d nodes: expected: %d, actual: %d", tc.nodes, chunkSize, actual)
+			}
+			for len(arena.buf) > 0 {
+				arena.alloc()
+			}
+		}
+	}
+}
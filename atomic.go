@@ -0,0 +1,233 @@
+package merkle
+
+import (
+	"errors"
+	"hash"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrReplacementRootMismatch = errors.New("replacement root mismatch")
+	ErrCorruptedReplacement    = errors.New("corrupted replacement")
+)
+
+// AtomicTree applies writes to a working tree and publishes a copy of it on
+// Commit by swapping an atomic pointer, so that Root and CreateMembershipProof
+// read the last committed snapshot without ever waiting for writers. The
+// copy takes time and memory linear in the number of nodes of the tree, as
+// readers may hold on to any snapshot, so commits are meant to batch many
+// writes rather than follow every one of them.
+type AtomicTree struct {
+	newHasher  func() hash.Hash
+	hasherPool *sync.Pool
+	mu         sync.Mutex
+	working    *Tree
+	pending    []PendingWrite
+	pendingSet map[uint64]struct{}
+	hooks      []CommitHook
+	snapshot   atomic.Pointer[Tree]
+}
+
+// PendingWrite is a leaf write made to an AtomicTree since its last commit.
+// Leaf is nil for a deletion.
+type PendingWrite struct {
+	Index uint64
+	Leaf  []byte
+}
+
+// CommitHook inspects the writes about to be committed, in the order they
+// were made, and the root they lead from and to. Returning an error vetoes
+// the commit.
+type CommitHook func(writes []PendingWrite, oldRoot, newRoot Root) error
+
+func NewAtomicTree(newHasher func() hash.Hash, depth uint64, leaves map[uint64][]byte, opts ...Option) (*AtomicTree, error) {
+	working, err := NewTree(newHasher(), depth, leaves, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	atree := &AtomicTree{
+		newHasher:  newHasher,
+		hasherPool: newHasherPool(newHasher),
+		working:    working,
+	}
+	atree.commit()
+
+	return atree, nil
+}
+
+// Update writes leaf at index. With WithDuplicateIndexRejection, writing an
+// index already written since the last commit fails with ErrDuplicateIndex.
+func (atree *AtomicTree) Update(index uint64, leaf []byte) error {
+	atree.mu.Lock()
+	defer atree.mu.Unlock()
+
+	if err := atree.checkPending(index); err != nil {
+		return err
+	}
+	if err := atree.working.Update(index, leaf); err != nil {
+		return err
+	}
+	atree.addPending(PendingWrite{
+		Index: index,
+		Leaf:  atree.working.ingest(leaf),
+	})
+
+	return nil
+}
+
+// Delete deletes the leaf at index, failing like Update on an index already
+// written since the last commit.
+func (atree *AtomicTree) Delete(index uint64) error {
+	atree.mu.Lock()
+	defer atree.mu.Unlock()
+
+	if err := atree.checkPending(index); err != nil {
+		return err
+	}
+	if err := atree.working.Delete(index); err != nil {
+		return err
+	}
+	atree.addPending(PendingWrite{
+		Index: index,
+	})
+
+	return nil
+}
+
+func (atree *AtomicTree) checkPending(index uint64) error {
+	if !atree.working.uniqueWrites {
+		return nil
+	}
+	if _, ok := atree.pendingSet[index]; ok {
+		return ErrDuplicateIndex
+	}
+	return nil
+}
+
+func (atree *AtomicTree) addPending(write PendingWrite) {
+	atree.pending = append(atree.pending, write)
+	if atree.working.uniqueWrites {
+		if atree.pendingSet == nil {
+			atree.pendingSet = map[uint64]struct{}{}
+		}
+		atree.pendingSet[write.Index] = struct{}{}
+	}
+}
+
+// AddCommitHook registers hook to run, after the hooks registered before
+// it, on every Commit.
+func (atree *AtomicTree) AddCommitHook(hook CommitHook) {
+	atree.mu.Lock()
+	defer atree.mu.Unlock()
+
+	atree.hooks = append(atree.hooks, hook)
+}
+
+// Swap replaces the working tree with replacement, a tree built in the
+// background with hashers from newHasher, and publishes it at once, after
+// checking that its nodes are consistent and that its root is expectedRoot.
+// Reads are never blocked; writes wait only for the swap itself, and writes
+// made to the old tree while replacement was being built are discarded.
+// Commit hooks do not run on a swap.
+func (atree *AtomicTree) Swap(newHasher func() hash.Hash, replacement *Tree, expectedRoot Root) error {
+	mismatches, err := replacement.Audit()
+	if err != nil {
+		return err
+	}
+	if len(mismatches) > 0 {
+		return ErrCorruptedReplacement
+	}
+	if !replacement.Root().Equal(expectedRoot) {
+		return ErrReplacementRootMismatch
+	}
+
+	atree.mu.Lock()
+	defer atree.mu.Unlock()
+
+	atree.newHasher = newHasher
+	atree.hasherPool = newHasherPool(newHasher)
+	atree.working = replacement
+	atree.pending, atree.pendingSet = nil, nil
+	atree.commit()
+
+	return nil
+}
+
+// Commit runs the commit hooks and publishes the working tree unless one of
+// them vetoes it, in which case the writes made since the last commit are
+// rolled back and the error of the hook is returned. A journal kept by the
+// working tree records the rolled back writes and then their rollback.
+func (atree *AtomicTree) Commit() error {
+	atree.mu.Lock()
+	defer atree.mu.Unlock()
+
+	oldRoot, newRoot := atree.Snapshot().Root(), atree.working.Root()
+	for _, hook := range atree.hooks {
+		if err := hook(atree.pending, oldRoot, newRoot); err != nil {
+			if rerr := atree.rollback(); rerr != nil {
+				return rerr
+			}
+			return err
+		}
+	}
+
+	atree.pending, atree.pendingSet = nil, nil
+	atree.commit()
+
+	return nil
+}
+
+// rollback restores the leaves written since the last commit to their
+// committed nodes, salts, expiries and metadata.
+func (atree *AtomicTree) rollback() error {
+	snapshot, working := atree.Snapshot(), atree.working
+	for _, write := range atree.pending {
+		node, _ := snapshot.levels[snapshot.depth].get(write.Index)
+		if err := working.writeLeafNode(write.Index, node, snapshot.salts[write.Index]); err != nil {
+			return err
+		}
+		if expiry, ok := snapshot.expiries[write.Index]; ok {
+			if working.expiries == nil {
+				working.expiries = map[uint64]time.Time{}
+			}
+			working.expiries[write.Index] = expiry
+		}
+		if leaf, ok := snapshot.metadata[write.Index]; ok {
+			if working.metadata == nil {
+				working.metadata = map[uint64][]byte{}
+			}
+			working.metadata[write.Index] = leaf
+		}
+	}
+	atree.pending, atree.pendingSet = nil, nil
+	return nil
+}
+
+func (atree *AtomicTree) commit() {
+	snapshot := atree.working.clone(atree.newHasher())
+	snapshot.hasherPool = atree.hasherPool
+	snapshot.frozen = true
+	atree.snapshot.Store(snapshot)
+}
+
+// Snapshot returns the last committed tree. It is read-only, its writes
+// failing with ErrReadOnlyTree, and it hashes with pooled hashers, so
+// VerifyMembershipProof may be called on it concurrently.
+func (atree *AtomicTree) Snapshot() *Tree {
+	return atree.snapshot.Load()
+}
+
+func (atree *AtomicTree) Root() Root {
+	return atree.Snapshot().Root()
+}
+
+func (atree *AtomicTree) CreateMembershipProof(index uint64) ([]byte, error) {
+	return atree.Snapshot().CreateMembershipProof(index)
+}
+
+func (atree *AtomicTree) VerifyMembershipProof(index uint64, proof []byte) (bool, error) {
+	return atree.Snapshot().VerifyMembershipProof(index, proof)
+}
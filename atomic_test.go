@@ -0,0 +1,287 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestAtomicTree(t *testing.T) {
+	atree, err := NewAtomicTree(sha256.New, 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	emptyRoot := atree.Root()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := atree.CreateMembershipProof(3); err != nil {
+					t.Error(err)
+				}
+				atree.Root()
+			}
+		}()
+	}
+
+	if err := atree.Update(0, []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}); err != nil {
+		t.Fatal(err)
+	}
+	if err := atree.Update(3, []byte{0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(atree.Root(), emptyRoot) {
+		t.Errorf("expected: %x, actual: %x", emptyRoot, atree.Root())
+	}
+
+	if err := atree.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	expected := newTestTree(t)
+	if !bytes.Equal(atree.Root(), expected.Root()) {
+		t.Errorf("expected: %x, actual: %x", expected.Root(), atree.Root())
+	}
+
+	snapshot := atree.Snapshot()
+	if err := atree.Delete(3); err != nil {
+		t.Fatal(err)
+	}
+	if err := atree.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(snapshot.Root(), expected.Root()) {
+		t.Errorf("expected: %x, actual: %x", expected.Root(), snapshot.Root())
+	}
+}
+
+func TestAtomicTree_CommitHook(t *testing.T) {
+	atree, err := NewAtomicTree(sha256.New, 3, map[uint64][]byte{
+		0: []byte{0x00},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	committedRoot := atree.Root()
+
+	errVetoed := errors.New("vetoed")
+
+	var calls [][]PendingWrite
+	atree.AddCommitHook(func(writes []PendingWrite, oldRoot, newRoot Root) error {
+		calls = append(calls, writes)
+		if !oldRoot.Equal(committedRoot) {
+			t.Errorf("expected: %x, actual: %x", committedRoot, oldRoot)
+		}
+		for _, write := range writes {
+			if write.Leaf == nil {
+				return errVetoed
+			}
+		}
+		return nil
+	})
+
+	if err := atree.Update(3, []byte{0x03}); err != nil {
+		t.Fatal(err)
+	}
+	if err := atree.Delete(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := atree.Commit(); err != errVetoed {
+		t.Errorf("expected: %v, actual: %v", errVetoed, err)
+	}
+	if !atree.Root().Equal(committedRoot) {
+		t.Errorf("expected: %x, actual: %x", committedRoot, atree.Root())
+	}
+	if len(calls) != 1 || len(calls[0]) != 2 {
+		t.Fatalf("expected: %d calls with %d writes, actual: %v", 1, 2, calls)
+	}
+	if write := calls[0][0]; write.Index != 3 || !bytes.Equal(write.Leaf, []byte{0x03}) {
+		t.Errorf("expected: %v, actual: %v", PendingWrite{3, []byte{0x03}}, write)
+	}
+
+	// the vetoed writes are rolled back, so only the new one is committed
+	if err := atree.Update(3, []byte{0x03}); err != nil {
+		t.Fatal(err)
+	}
+	if err := atree.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := NewTree(sha256.New(), 3, map[uint64][]byte{
+		0: []byte{0x00},
+		3: []byte{0x03},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !atree.Root().Equal(expected.Root()) {
+		t.Errorf("expected: %x, actual: %x", expected.Root(), atree.Root())
+	}
+}
+
+func TestAtomicTree_VerifyMembershipProof(t *testing.T) {
+	atree, err := NewAtomicTree(sha256.New, 3, map[uint64][]byte{
+		0: []byte{0x00},
+		3: []byte{0x03},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proof, err := atree.CreateMembershipProof(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if ok, err := atree.VerifyMembershipProof(3, proof); err != nil || !ok {
+					t.Errorf("expected: %t, actual: %t (%v)", true, ok, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestAtomicTree_Swap(t *testing.T) {
+	atree, err := NewAtomicTree(sha256.New, 3, map[uint64][]byte{
+		0: []byte{0x00},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldRoot := atree.Root()
+
+	replacement, err := NewTree(sha512.New(), 4, map[uint64][]byte{
+		0:  []byte{0x00},
+		15: []byte{0x0f},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := atree.Swap(sha512.New, replacement, oldRoot); err != ErrReplacementRootMismatch {
+		t.Errorf("expected: %v, actual: %v", ErrReplacementRootMismatch, err)
+	}
+	if !atree.Root().Equal(oldRoot) {
+		t.Errorf("expected: %x, actual: %x", oldRoot, atree.Root())
+	}
+
+	if err := atree.Swap(sha512.New, replacement, replacement.Root()); err != nil {
+		t.Fatal(err)
+	}
+	if !atree.Root().Equal(replacement.Root()) {
+		t.Errorf("expected: %x, actual: %x", replacement.Root(), atree.Root())
+	}
+
+	proof, err := atree.CreateMembershipProof(15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := atree.VerifyMembershipProof(15, proof); err != nil || !ok {
+		t.Errorf("expected: %t, actual: %t (%v)", true, ok, err)
+	}
+}
+
+func TestAtomicTree_duplicateIndex(t *testing.T) {
+	atree, err := NewAtomicTree(sha256.New, 3, nil, WithDuplicateIndexRejection())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := atree.Update(3, []byte{0x01}); err != nil {
+		t.Fatal(err)
+	}
+	if err := atree.Update(3, []byte{0x03}); err != ErrDuplicateIndex {
+		t.Errorf("expected: %v, actual: %v", ErrDuplicateIndex, err)
+	}
+	if err := atree.Delete(3); err != ErrDuplicateIndex {
+		t.Errorf("expected: %v, actual: %v", ErrDuplicateIndex, err)
+	}
+	if err := atree.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a new batch starts with the commit
+	if err := atree.Delete(3); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAtomicTree_rollback(t *testing.T) {
+	atree, err := NewAtomicTree(sha256.New, 3, map[uint64][]byte{
+		0: []byte{0x00},
+	}, WithSaltedLeaves())
+	if err != nil {
+		t.Fatal(err)
+	}
+	committedRoot := atree.Root()
+	committedSalt, err := atree.Snapshot().Salt(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errVetoed := errors.New("vetoed")
+	atree.AddCommitHook(func(writes []PendingWrite, oldRoot, newRoot Root) error {
+		return errVetoed
+	})
+
+	if err := atree.Update(0, []byte{0x01}); err != nil {
+		t.Fatal(err)
+	}
+	if err := atree.Update(3, []byte{0x03}); err != nil {
+		t.Fatal(err)
+	}
+	if err := atree.Commit(); err != errVetoed {
+		t.Errorf("expected: %v, actual: %v", errVetoed, err)
+	}
+
+	if !atree.working.Root().Equal(committedRoot) {
+		t.Errorf("expected: %x, actual: %x", committedRoot, atree.working.Root())
+	}
+	salt, err := atree.working.Salt(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(salt, committedSalt) {
+		t.Errorf("expected: %x, actual: %x", committedSalt, salt)
+	}
+	if _, err := atree.working.Salt(3); err != ErrSaltNotFound {
+		t.Errorf("expected: %v, actual: %v", ErrSaltNotFound, err)
+	}
+}
+
+func TestAtomicTree_Snapshot(t *testing.T) {
+	atree, err := NewAtomicTree(sha256.New, 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := atree.Snapshot()
+	if err := snapshot.Update(0, []byte{0x00}); err != ErrReadOnlyTree {
+		t.Errorf("expected: %v, actual: %v", ErrReadOnlyTree, err)
+	}
+	if err := snapshot.Delete(0); err != ErrReadOnlyTree {
+		t.Errorf("expected: %v, actual: %v", ErrReadOnlyTree, err)
+	}
+
+	// the working tree stays writable
+	if err := atree.Update(0, []byte{0x00}); err != nil {
+		t.Fatal(err)
+	}
+	if err := atree.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,65 @@
+package merkle
+
+import (
+	"bytes"
+	"sort"
+)
+
+// NodeMismatch is an internal node whose stored value differs from the one
+// recomputed from its children. A nil Expected means the node should not
+// exist, and a nil Actual that it is missing.
+type NodeMismatch struct {
+	Depth    uint64
+	Index    uint64
+	Expected []byte
+	Actual   []byte
+}
+
+// Audit recomputes every internal node from its children and reports the
+// mismatches, ordered by depth and index.
+func (tree *Tree) Audit() ([]NodeMismatch, error) {
+	var mismatches []NodeMismatch
+
+	for d := tree.depth; d > 0; d-- {
+		level, parentLevel := tree.levels[d], tree.levels[d-1]
+
+		var found []NodeMismatch
+
+		parentIndices := map[uint64]struct{}{}
+		for index := range level.all() {
+			parentIndices[index/2] = struct{}{}
+		}
+
+		for parentIndex := range parentIndices {
+			leftNode, ok := level.get(parentIndex * 2)
+			if !ok {
+				leftNode = tree.defaultNodes[d]
+			}
+			rightNode, ok := level.get(parentIndex*2 + 1)
+			if !ok {
+				rightNode = tree.defaultNodes[d]
+			}
+
+			expected, err := tree.pairHash(tree.depth-d+1, leftNode, rightNode)
+			if err != nil {
+				return nil, err
+			}
+			if actual, _ := parentLevel.get(parentIndex); !bytes.Equal(actual, expected) {
+				found = append(found, NodeMismatch{d - 1, parentIndex, expected, actual})
+			}
+		}
+
+		for parentIndex, actual := range parentLevel.all() {
+			if _, ok := parentIndices[parentIndex]; !ok {
+				found = append(found, NodeMismatch{d - 1, parentIndex, nil, actual})
+			}
+		}
+
+		sort.Slice(found, func(i, j int) bool {
+			return found[i].Index < found[j].Index
+		})
+		mismatches = append(found, mismatches...)
+	}
+
+	return mismatches, nil
+}
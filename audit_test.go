@@ -0,0 +1,83 @@
+package merkle
+
+import (
+	"testing"
+)
+
+func TestTree_Audit(t *testing.T) {
+	tree := newTestTree(t)
+
+	mismatches, err := tree.Audit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mismatches) != 0 {
+		t.Errorf("expected: %d, actual: %d", 0, len(mismatches))
+	}
+
+	corrupted := testNode(tree, 1, 0)
+	tree.levels[1].put(0, testNode(tree, 2, 1))
+	tree.levels[2].put(3, testNode(tree, 2, 1))
+	tree.levels[0].remove(0)
+
+	mismatches, err = tree.Audit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		depth    uint64
+		index    uint64
+		expected bool
+		actual   bool
+	}{
+		{0, 0, true, false},
+		{1, 0, true, true},
+		{1, 1, true, false},
+		{2, 3, false, true},
+	}
+	if len(mismatches) != len(expected) {
+		t.Fatalf("expected: %d, actual: %d", len(expected), len(mismatches))
+	}
+	for i, mismatch := range mismatches {
+		e := expected[i]
+		if mismatch.Depth != e.depth || mismatch.Index != e.index {
+			t.Errorf("expected: (%d, %d), actual: (%d, %d)", e.depth, e.index, mismatch.Depth, mismatch.Index)
+		}
+		if (mismatch.Expected != nil) != e.expected || (mismatch.Actual != nil) != e.actual {
+			t.Errorf("expected: (%t, %t), actual: (%x, %x)", e.expected, e.actual, mismatch.Expected, mismatch.Actual)
+		}
+	}
+	if string(mismatches[1].Expected) != string(corrupted) {
+		t.Errorf("expected: %x, actual: %x", corrupted, mismatches[1].Expected)
+	}
+}
+
+func TestTree_Rebuild(t *testing.T) {
+	tree := newTestTree(t)
+	expected := newTestTree(t)
+
+	tree.levels[1].put(0, testNode(tree, 2, 1))
+	tree.levels[2].put(3, testNode(tree, 2, 1))
+	tree.levels[0].remove(0)
+
+	if err := tree.Rebuild(); err != nil {
+		t.Fatal(err)
+	}
+
+	mismatches, err := tree.Audit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mismatches) != 0 {
+		t.Errorf("expected: %d, actual: %d", 0, len(mismatches))
+	}
+	if string(tree.Root()) != string(expected.Root()) {
+		t.Errorf("expected: %x, actual: %x", expected.Root(), tree.Root())
+	}
+	for d := range tree.levels {
+		if tree.levels[d].len() != expected.levels[d].len() {
+			t.Errorf("expected: %d, actual: %d", expected.levels[d].len(), tree.levels[d].len())
+		}
+	}
+}
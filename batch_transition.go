@@ -0,0 +1,249 @@
+package merkle
+
+import (
+	"bytes"
+	"errors"
+)
+
+var (
+	ErrInconsistentTransitions = errors.New("inconsistent transitions")
+	ErrDuplicateIndex          = errors.New("duplicate index")
+)
+
+type LeafWrite struct {
+	Index uint64
+	Leaf  []byte
+}
+
+type LeafTransition struct {
+	Index   uint64
+	OldLeaf []byte
+	NewLeaf []byte
+}
+
+// ProveBatchTransition applies the writes in order, deleting the leaves of
+// writes with a nil Leaf, and returns a single proof linking the old root to
+// the new one. Siblings shared between the paths of the written leaves, and
+// nodes that are on those paths themselves, are left out of the proof.
+//
+// The proof is a bitmap with one bit per sibling position, in the order
+// the verifier derives them from the indices, followed by the siblings
+// whose bit is set; the others are default nodes.
+//
+// A later write to an index wins over the earlier ones, unless the tree is
+// built with WithDuplicateIndexRejection, in which case a batch writing an
+// index twice fails with ErrDuplicateIndex before any write.
+func (tree *Tree) ProveBatchTransition(writes []LeafWrite) ([]byte, error) {
+	if tree.uniqueWrites {
+		if err := CheckUniqueIndices(writes); err != nil {
+			return nil, err
+		}
+	}
+
+	indices := make([]uint64, 0, len(writes))
+	for _, write := range writes {
+		if write.Index > tree.indexMax {
+			return nil, ErrTooLargeLeafIndex
+		}
+		indices = append(indices, write.Index)
+	}
+
+	positions := tree.batchSiblingPositions(indices)
+
+	proof := make([]byte, (len(positions)+7)/8)
+	for i, pos := range positions {
+		if siblingNode, ok := tree.levels[tree.depth-pos.height].get(pos.index); ok {
+			proof[i/8] |= 1 << uint(i%8)
+			proof = append(proof, siblingNode...)
+		}
+	}
+
+	for _, write := range writes {
+		var err error
+		if write.Leaf == nil {
+			err = tree.Delete(write.Index)
+		} else {
+			err = tree.Update(write.Index, write.Leaf)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return proof, nil
+}
+
+// CheckUniqueIndices returns ErrDuplicateIndex if writes write an index more
+// than once, for batches whose later writes must not silently win over the
+// earlier ones, e.g. before EncodeCommand.
+func CheckUniqueIndices(writes []LeafWrite) error {
+	seen := make(map[uint64]struct{}, len(writes))
+	for _, write := range writes {
+		if _, ok := seen[write.Index]; ok {
+			return ErrDuplicateIndex
+		}
+		seen[write.Index] = struct{}{}
+	}
+	return nil
+}
+
+// VerifyBatchTransitionProof checks that applying the transitions in order
+// turns oldRoot into newRoot. A transition of an index written earlier in
+// the batch must start from the leaf the earlier one ended with.
+func (tree *Tree) VerifyBatchTransitionProof(transitions []LeafTransition, oldRoot, newRoot Root, proof []byte) (bool, error) {
+	oldNodes := map[uint64][]byte{}
+	newNodes := map[uint64][]byte{}
+	newLeaves := map[uint64][]byte{}
+	indices := make([]uint64, 0, len(transitions))
+
+	for _, transition := range transitions {
+		if transition.Index > tree.indexMax {
+			return false, ErrTooLargeLeafIndex
+		}
+
+		if prevLeaf, ok := newLeaves[transition.Index]; ok {
+			if (prevLeaf == nil) != (transition.OldLeaf == nil) || !bytes.Equal(prevLeaf, transition.OldLeaf) {
+				return false, ErrInconsistentTransitions
+			}
+		} else {
+			node, err := tree.leafNode(transition.OldLeaf)
+			if err != nil {
+				return false, err
+			}
+			oldNodes[transition.Index] = node
+		}
+
+		node, err := tree.leafNode(transition.NewLeaf)
+		if err != nil {
+			return false, err
+		}
+		newNodes[transition.Index] = node
+		newLeaves[transition.Index] = transition.NewLeaf
+
+		indices = append(indices, transition.Index)
+	}
+
+	if len(indices) == 0 {
+		return len(proof) == 0 && tree.equalRoots(newRoot, oldRoot), nil
+	}
+
+	positions := tree.batchSiblingPositions(indices)
+
+	bitmapSize := uint64(len(positions)+7) / 8
+	if uint64(len(proof)) < bitmapSize {
+		return false, ErrInvalidProofSize
+	}
+	proofIndex := bitmapSize
+
+	siblings := make([][]byte, len(positions))
+	for i := range positions {
+		if proof[i/8]&(1<<uint(i%8)) == 0 {
+			continue
+		}
+		if proofIndex+tree.hashSize > uint64(len(proof)) {
+			return false, ErrInvalidProofSize
+		}
+		siblings[i] = proof[proofIndex : proofIndex+tree.hashSize]
+		proofIndex += tree.hashSize
+	}
+	if proofIndex != uint64(len(proof)) {
+		return false, ErrInvalidProofSize
+	}
+
+	for _, t := range []struct {
+		nodes map[uint64][]byte
+		root  Root
+	}{
+		{oldNodes, oldRoot},
+		{newNodes, newRoot},
+	} {
+		root, err := tree.computeBatchRoot(t.nodes, siblings)
+		if err != nil {
+			return false, err
+		}
+		if !tree.equalRoots(root, t.root) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+type nodePosition struct {
+	height uint64
+	index  uint64
+}
+
+// batchSiblingPositions returns, from the leaf level up and in index order
+// within a level, the siblings needed to fold the leaves at indices into the
+// root, leaving out the nodes that are on the path of another leaf.
+func (tree *Tree) batchSiblingPositions(indices []uint64) []nodePosition {
+	var positions []nodePosition
+
+	level := uniqueSorted(indices)
+	for h := uint64(0); h < tree.depth; h++ {
+		var nextLevel []uint64
+		for i := 0; i < len(level); i++ {
+			index := level[i]
+			if index%2 == 0 && i+1 < len(level) && level[i+1] == index+1 {
+				i++
+			} else {
+				positions = append(positions, nodePosition{h, index ^ 1})
+			}
+			nextLevel = append(nextLevel, index/2)
+		}
+		level = nextLevel
+	}
+
+	return positions
+}
+
+// computeBatchRoot folds the leaf nodes into the root in the same order as
+// batchSiblingPositions, taking siblings from the list aligned with it.
+func (tree *Tree) computeBatchRoot(leafNodes map[uint64][]byte, siblings [][]byte) ([]byte, error) {
+	nodes := leafNodes
+	level := make([]uint64, 0, len(nodes))
+	for index := range nodes {
+		level = append(level, index)
+	}
+	level = uniqueSorted(level)
+
+	p := 0
+	for h := uint64(0); h < tree.depth; h++ {
+		nextNodes := make(map[uint64][]byte, len(level))
+		var nextLevel []uint64
+
+		for i := 0; i < len(level); i++ {
+			index := level[i]
+
+			var leftNode, rightNode []byte
+			if index%2 == 0 && i+1 < len(level) && level[i+1] == index+1 {
+				leftNode, rightNode = nodes[index], nodes[index+1]
+				i++
+			} else {
+				siblingNode := siblings[p]
+				if siblingNode == nil {
+					siblingNode = tree.defaultNodes[tree.depth-h]
+				}
+				p++
+
+				if index%2 == 0 {
+					leftNode, rightNode = nodes[index], siblingNode
+				} else {
+					leftNode, rightNode = siblingNode, nodes[index]
+				}
+			}
+
+			parentNode, err := tree.pairHash(h+1, leftNode, rightNode)
+			if err != nil {
+				return nil, err
+			}
+			nextNodes[index/2] = parentNode
+			nextLevel = append(nextLevel, index/2)
+		}
+
+		nodes, level = nextNodes, nextLevel
+	}
+
+	return nodes[0], nil
+}
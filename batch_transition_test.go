@@ -0,0 +1,173 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestTree_VerifyBatchTransitionProof(t *testing.T) {
+	tree := newTestTree(t)
+	leaf0 := []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+	leaf3 := []byte{0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03}
+
+	oldRoot := tree.Root()
+	proof, err := tree.ProveBatchTransition([]LeafWrite{
+		{3, []byte{0x04}},
+		{1, []byte{0x01}},
+		{3, nil},
+		{6, []byte{0x06}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	newRoot := tree.Root()
+
+	if !tree.HasLeaf(1) || tree.HasLeaf(3) || !tree.HasLeaf(6) {
+		t.Errorf("expected: writes applied")
+	}
+
+	type output struct {
+		ok  bool
+		err error
+	}
+	testCases := []struct {
+		name        string
+		transitions []LeafTransition
+		out         output
+	}{
+		{
+			"failure: inconsistent transitions",
+			[]LeafTransition{
+				{3, leaf3, []byte{0x04}},
+				{1, nil, []byte{0x01}},
+				{3, leaf3, nil},
+				{6, nil, []byte{0x06}},
+			},
+			output{false, ErrInconsistentTransitions},
+		},
+		{
+			"failure: wrong old leaf",
+			[]LeafTransition{
+				{3, leaf0, []byte{0x04}},
+				{1, nil, []byte{0x01}},
+				{3, []byte{0x04}, nil},
+				{6, nil, []byte{0x06}},
+			},
+			output{false, nil},
+		},
+		{
+			"failure: missing transition",
+			[]LeafTransition{
+				{3, leaf3, []byte{0x04}},
+				{1, nil, []byte{0x01}},
+				{3, []byte{0x04}, nil},
+			},
+			output{false, nil},
+		},
+		{
+			"success",
+			[]LeafTransition{
+				{3, leaf3, []byte{0x04}},
+				{1, nil, []byte{0x01}},
+				{3, []byte{0x04}, nil},
+				{6, nil, []byte{0x06}},
+			},
+			output{true, nil},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := tc.out
+
+			ok, err := tree.VerifyBatchTransitionProof(tc.transitions, oldRoot, newRoot, proof)
+			if err != out.err {
+				t.Errorf("expected: %v, actual: %v", out.err, err)
+			}
+			if ok != out.ok {
+				t.Errorf("expected: %t, actual: %t", out.ok, ok)
+			}
+		})
+	}
+
+	if ok, err := tree.VerifyBatchTransitionProof(nil, newRoot, newRoot, nil); err != nil || !ok {
+		t.Errorf("expected: %t, actual: %t (%v)", true, ok, err)
+	}
+}
+
+func TestTree_ProveBatchTransition_duplicateIndex(t *testing.T) {
+	writes := []LeafWrite{
+		{3, []byte{0x01}},
+		{5, []byte{0x05}},
+		{3, []byte{0x03}},
+	}
+
+	testCases := []struct {
+		name string
+		opts []Option
+		err  error
+	}{
+		{
+			"failure: rejected",
+			[]Option{WithDuplicateIndexRejection()},
+			ErrDuplicateIndex,
+		},
+		{
+			"success: last write wins",
+			nil,
+			nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tree, err := NewTree(sha256.New(), 3, nil, tc.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			emptyRoot := tree.Root()
+
+			if _, err := tree.ProveBatchTransition(writes); err != tc.err {
+				t.Fatalf("expected: %v, actual: %v", tc.err, err)
+			}
+			if tc.err != nil {
+				if !bytes.Equal(tree.Root(), emptyRoot) {
+					t.Errorf("expected: %x, actual: %x", emptyRoot, tree.Root())
+				}
+				return
+			}
+
+			expected, err := NewTree(sha256.New(), 3, map[uint64][]byte{
+				3: []byte{0x03},
+				5: []byte{0x05},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(tree.Root(), expected.Root()) {
+				t.Errorf("expected: %x, actual: %x", expected.Root(), tree.Root())
+			}
+		})
+	}
+}
+
+func TestTree_batchSiblingPositions(t *testing.T) {
+	tree := newTestTree(t)
+
+	positions := tree.batchSiblingPositions([]uint64{3, 1, 3, 6})
+	expected := []nodePosition{
+		{0, 0},
+		{0, 2},
+		{0, 7},
+		{1, 2},
+	}
+	if len(positions) != len(expected) {
+		t.Fatalf("expected: %v, actual: %v", expected, positions)
+	}
+	for i := range expected {
+		if positions[i] != expected[i] {
+			t.Errorf("expected: %v, actual: %v", expected[i], positions[i])
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package merkle
+
+import (
+	"errors"
+)
+
+var (
+	ErrInvalidProof = errors.New("invalid proof")
+)
+
+type ProofItem struct {
+	Index uint64
+	Proof []byte
+}
+
+// VerifyMembershipProofs verifies every item and returns an error per item,
+// nil for the proofs that verify and ErrInvalidProof for those that are well
+// formed but do not. The root is read once for all the items. With
+// WithHasherPool the items are split between up to the parallelism of the
+// tree, each verifying its share with a single hasher from the pool, and
+// they are verified one by one with the hasher of the tree otherwise.
+func (tree *Tree) VerifyMembershipProofs(items []ProofItem) []error {
+	errs := make([]error, len(items))
+	if len(items) == 0 {
+		return errs
+	}
+
+	workers := 1
+	if tree.hasherPool != nil {
+		workers = min(tree.workers(), len(items))
+	}
+	share := (len(items) + workers - 1) / workers
+
+	root := tree.Root()
+	parallelFor(workers, workers, func(w int) {
+		hasher := tree.getHasher()
+		defer tree.putHasher(hasher)
+
+		for i := w * share; i < min((w+1)*share, len(items)); i++ {
+			ok, err := tree.verifyMembershipProof(hasher, root, items[i].Index, items[i].Proof)
+			if err == nil && !ok {
+				err = ErrInvalidProof
+			}
+			errs[i] = err
+		}
+	})
+	return errs
+}
@@ -0,0 +1,61 @@
+package merkle
+
+import (
+	"crypto/sha256"
+	"errors"
+	"testing"
+)
+
+func TestTree_VerifyMembershipProofs(t *testing.T) {
+	parallel, err := NewTree(sha256.New(), 3, map[uint64][]byte{
+		0: []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		3: []byte{0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03},
+	}, WithHasherPool(sha256.New), WithParallelism(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tree := range map[string]*Tree{
+		"sequential": newTestTree(t),
+		"parallel":   parallel,
+	} {
+		t.Run(name, func(t *testing.T) {
+			proof0, err := tree.CreateMembershipProof(0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			proof3, err := tree.CreateMembershipProof(3)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			errs := tree.VerifyMembershipProofs([]ProofItem{
+				{0, proof0},
+				{3, proof0},
+				{3, proof3},
+				{8, proof3},
+				{3, proof3[:len(proof3)-1]},
+			})
+
+			expected := []error{
+				nil,
+				ErrInvalidProof,
+				nil,
+				ErrTooLargeLeafIndex,
+				ErrInvalidProofSize,
+			}
+			if len(errs) != len(expected) {
+				t.Fatalf("expected: %d, actual: %d", len(expected), len(errs))
+			}
+			for i, err := range errs {
+				if !errors.Is(err, expected[i]) {
+					t.Errorf("item %d: expected: %v, actual: %v", i, expected[i], err)
+				}
+			}
+
+			if errs := tree.VerifyMembershipProofs(nil); len(errs) != 0 {
+				t.Errorf("expected: %d, actual: %d", 0, len(errs))
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package merkle
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CachePolicy controls how a CachedStore trades durability for throughput.
+// In write-through mode every write reaches the store before it returns. In
+// write-back mode writes are buffered and flushed once MaxDirtyBytes is
+// exceeded, every FlushInterval if it is set, and on Flush and Close.
+// MaxCachedBytes bounds the values kept from reads and flushed writes.
+type CachePolicy struct {
+	WriteBack      bool
+	MaxDirtyBytes  int
+	FlushInterval  time.Duration
+	MaxCachedBytes int
+}
+
+// CachedStore is a NodeStore decorator caching values in memory according
+// to a CachePolicy.
+type CachedStore struct {
+	store       NodeStore
+	policy      CachePolicy
+	mu          sync.Mutex
+	clean       map[string][]byte
+	cleanBytes  int
+	dirty       map[string]*dirtyValue
+	dirtyBytes  int
+	writes      uint64
+	flushErr    error
+	stopFlusher chan struct{}
+	flusherDone chan struct{}
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+}
+
+type dirtyValue struct {
+	value   []byte
+	deleted bool
+}
+
+func NewCachedStore(store NodeStore, policy CachePolicy) *CachedStore {
+	cstore := &CachedStore{
+		store:  store,
+		policy: policy,
+		clean:  map[string][]byte{},
+		dirty:  map[string]*dirtyValue{},
+	}
+
+	if policy.WriteBack && policy.FlushInterval > 0 {
+		cstore.stopFlusher = make(chan struct{})
+		cstore.flusherDone = make(chan struct{})
+		go cstore.runFlusher()
+	}
+
+	return cstore
+}
+
+// Get returns a copy of the value of key. A value read from the store is
+// cached unless a write reached the store while it was being read, as the
+// value might then be stale.
+func (cstore *CachedStore) Get(key []byte) ([]byte, error) {
+	cstore.mu.Lock()
+	if dv, ok := cstore.dirty[string(key)]; ok {
+		cstore.mu.Unlock()
+		cstore.hits.Add(1)
+		if dv.deleted {
+			return nil, ErrNodeNotFound
+		}
+		return append([]byte(nil), dv.value...), nil
+	}
+	if value, ok := cstore.clean[string(key)]; ok {
+		cstore.mu.Unlock()
+		cstore.hits.Add(1)
+		return append([]byte(nil), value...), nil
+	}
+	writes := cstore.writes
+	cstore.mu.Unlock()
+	cstore.misses.Add(1)
+
+	value, err := cstore.store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	cstore.mu.Lock()
+	if cstore.writes == writes {
+		cstore.cache(string(key), append([]byte(nil), value...))
+	}
+	cstore.mu.Unlock()
+
+	return value, nil
+}
+
+func (cstore *CachedStore) Put(key, value []byte) error {
+	return cstore.write(string(key), &dirtyValue{value: append([]byte(nil), value...)})
+}
+
+func (cstore *CachedStore) Delete(key []byte) error {
+	return cstore.write(string(key), &dirtyValue{deleted: true})
+}
+
+// Iterate flushes the buffered writes and iterates over the store.
+func (cstore *CachedStore) Iterate(f func(key, value []byte) error) error {
+	if err := cstore.Flush(); err != nil {
+		return err
+	}
+	return cstore.store.Iterate(f)
+}
+
+// Flush writes the buffered writes to the store. It also reports, once, the
+// error a periodic flush may have run into.
+func (cstore *CachedStore) Flush() error {
+	cstore.mu.Lock()
+	defer cstore.mu.Unlock()
+
+	if err := cstore.takeFlushErr(); err != nil {
+		return err
+	}
+	return cstore.flush()
+}
+
+// Close stops the periodic flush and flushes the buffered writes.
+func (cstore *CachedStore) Close() error {
+	if cstore.stopFlusher != nil {
+		close(cstore.stopFlusher)
+		<-cstore.flusherDone
+		cstore.stopFlusher = nil
+	}
+	return cstore.Flush()
+}
+
+func (cstore *CachedStore) write(key string, dv *dirtyValue) error {
+	cstore.mu.Lock()
+	defer cstore.mu.Unlock()
+
+	if err := cstore.takeFlushErr(); err != nil {
+		return err
+	}
+
+	cstore.uncache(key)
+
+	if !cstore.policy.WriteBack {
+		if err := cstore.apply(key, dv); err != nil {
+			return err
+		}
+		if !dv.deleted {
+			cstore.cache(key, dv.value)
+		}
+		return nil
+	}
+
+	if prev, ok := cstore.dirty[key]; ok {
+		cstore.dirtyBytes -= len(key) + len(prev.value)
+	}
+	cstore.dirty[key] = dv
+	cstore.dirtyBytes += len(key) + len(dv.value)
+
+	if cstore.dirtyBytes > cstore.policy.MaxDirtyBytes {
+		return cstore.flush()
+	}
+	return nil
+}
+
+func (cstore *CachedStore) flush() error {
+	keys := make([]string, 0, len(cstore.dirty))
+	for key := range cstore.dirty {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		dv := cstore.dirty[key]
+		if err := cstore.apply(key, dv); err != nil {
+			return err
+		}
+
+		delete(cstore.dirty, key)
+		cstore.dirtyBytes -= len(key) + len(dv.value)
+		if !dv.deleted {
+			cstore.cache(key, dv.value)
+		}
+	}
+
+	return nil
+}
+
+func (cstore *CachedStore) takeFlushErr() error {
+	err := cstore.flushErr
+	cstore.flushErr = nil
+	return err
+}
+
+func (cstore *CachedStore) apply(key string, dv *dirtyValue) error {
+	cstore.writes++
+	if dv.deleted {
+		return cstore.store.Delete([]byte(key))
+	}
+	return cstore.store.Put([]byte(key), dv.value)
+}
+
+func (cstore *CachedStore) cache(key string, value []byte) {
+	if len(key)+len(value) > cstore.policy.MaxCachedBytes {
+		return
+	}
+	for k := range cstore.clean {
+		if cstore.cleanBytes+len(key)+len(value) <= cstore.policy.MaxCachedBytes {
+			break
+		}
+		cstore.uncache(k)
+	}
+	cstore.clean[key] = value
+	cstore.cleanBytes += len(key) + len(value)
+}
+
+func (cstore *CachedStore) uncache(key string) {
+	if value, ok := cstore.clean[key]; ok {
+		delete(cstore.clean, key)
+		cstore.cleanBytes -= len(key) + len(value)
+	}
+}
+
+func (cstore *CachedStore) runFlusher() {
+	defer close(cstore.flusherDone)
+
+	ticker := time.NewTicker(cstore.policy.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			cstore.mu.Lock()
+			if err := cstore.flush(); err != nil {
+				cstore.flushErr = err
+			}
+			cstore.mu.Unlock()
+
+		case <-cstore.stopFlusher:
+			return
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package merkle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCachedStore(t *testing.T) {
+	testCases := []struct {
+		name        string
+		policy      CachePolicy
+		flushedSoon bool
+	}{
+		{
+			"write-through",
+			CachePolicy{
+				MaxCachedBytes: 1 << 10,
+			},
+			true,
+		},
+		{
+			"write-back",
+			CachePolicy{
+				WriteBack:      true,
+				MaxDirtyBytes:  1 << 10,
+				MaxCachedBytes: 1 << 10,
+			},
+			false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fileStore, err := NewFileStore(t.TempDir(), false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			store := NewCachedStore(fileStore, tc.policy)
+
+			if err := store.Put(nodeKey(0, 0), []byte{0x00}); err != nil {
+				t.Fatal(err)
+			}
+			if value, err := store.Get(nodeKey(0, 0)); err != nil || value[0] != 0x00 {
+				t.Errorf("expected: %x, actual: %x (%v)", []byte{0x00}, value, err)
+			}
+
+			_, err = fileStore.Get(nodeKey(0, 0))
+			if flushed := err == nil; flushed != tc.flushedSoon {
+				t.Errorf("expected: %t, actual: %t", tc.flushedSoon, flushed)
+			}
+
+			if err := store.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := fileStore.Get(nodeKey(0, 0)); err != nil {
+				t.Errorf("expected: %v, actual: %v", nil, err)
+			}
+		})
+	}
+}
+
+func TestCachedStore_MaxDirtyBytes(t *testing.T) {
+	fileStore, err := NewFileStore(t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewCachedStore(fileStore, CachePolicy{
+		WriteBack:     true,
+		MaxDirtyBytes: 2 * (nodeKeySize + 1),
+	})
+
+	for index := uint64(0); index < 3; index++ {
+		if err := store.Put(nodeKey(0, index), []byte{byte(index)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if store.dirtyBytes != 0 {
+		t.Errorf("expected: %d, actual: %d", 0, store.dirtyBytes)
+	}
+	if _, err := fileStore.Get(nodeKey(0, 2)); err != nil {
+		t.Errorf("expected: %v, actual: %v", nil, err)
+	}
+}
+
+func TestCachedStore_FlushInterval(t *testing.T) {
+	fileStore, err := NewFileStore(t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewCachedStore(fileStore, CachePolicy{
+		WriteBack:     true,
+		MaxDirtyBytes: 1 << 10,
+		FlushInterval: time.Millisecond,
+	})
+	defer store.Close()
+
+	if err := store.Put(nodeKey(0, 0), []byte{0x00}); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := fileStore.Get(nodeKey(0, 0)); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected: flushed within a second")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// interleavedGetStore runs a write to the CachedStore in front of it while a
+// Get is reading from it.
+type interleavedGetStore struct {
+	NodeStore
+	write func()
+}
+
+func (store *interleavedGetStore) Get(key []byte) ([]byte, error) {
+	value, err := store.NodeStore.Get(key)
+	if write := store.write; write != nil {
+		store.write = nil
+		write()
+	}
+	return value, err
+}
+
+func TestCachedStore_Get_interleavedWrite(t *testing.T) {
+	fileStore, err := NewFileStore(t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fileStore.Put(nodeKey(0, 0), []byte{0x00}); err != nil {
+		t.Fatal(err)
+	}
+	backend := &interleavedGetStore{NodeStore: fileStore}
+	store := NewCachedStore(backend, CachePolicy{
+		MaxCachedBytes: 1 << 10,
+	})
+
+	backend.write = func() {
+		if err := store.Put(nodeKey(0, 0), []byte{0x01}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if value, err := store.Get(nodeKey(0, 0)); err != nil || value[0] != 0x00 {
+		t.Errorf("expected: %x, actual: %x (%v)", []byte{0x00}, value, err)
+	}
+
+	// the value read before the write is not cached over the written one
+	value, err := store.Get(nodeKey(0, 0))
+	if err != nil || value[0] != 0x01 {
+		t.Errorf("expected: %x, actual: %x (%v)", []byte{0x01}, value, err)
+	}
+
+	// the cached value cannot be modified through a returned one
+	value[0] = 0x02
+	if value, err := store.Get(nodeKey(0, 0)); err != nil || value[0] != 0x01 {
+		t.Errorf("expected: %x, actual: %x (%v)", []byte{0x01}, value, err)
+	}
+}
@@ -0,0 +1,129 @@
+package merkle
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+const (
+	canonicalFlagSSZ         byte = 0x01
+	canonicalFlagLevelTweak  byte = 0x02
+	canonicalFlagSortedPairs byte = 0x04
+	canonicalFlagSaltedLeaf  byte = 0x08
+)
+
+var (
+	ErrUnencodableState = errors.New("unencodable tree state")
+)
+
+// WriteCanonical writes the state of the tree in a canonical form:
+//
+//	hasher name size (4 bytes) || hasher name || depth (8 bytes) ||
+//	flags (1 byte) || leaf count (8 bytes) ||
+//	{ index (8 bytes) || node size (4 bytes) || node || salt } ||
+//	expiry count (8 bytes) ||
+//	{ index (8 bytes) || unix seconds (8 bytes) || nanoseconds (4 bytes) } ||
+//	metadata count (8 bytes) || { index (8 bytes) || size (4 bytes) || metadata }
+//
+// with everything in index order, so that equal states always serialize to
+// the same bytes. The flags are the hashing modes of the tree: 0x01 for
+// WithSSZ, 0x02 for WithLevelTweak, 0x04 for WithSortedPairs and 0x08 for
+// WithSaltedLeaves, in which case every leaf carries its salt. A salted
+// leaf whose salt is unknown cannot be encoded and fails with
+// ErrUnencodableState.
+//
+// The hasher is written by name, which is empty unless the tree was built
+// by name, e.g. with NewTreeByName.
+func (tree *Tree) WriteCanonical(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	b := binary.BigEndian.AppendUint32(nil, uint32(len(tree.hasherName)))
+	b = append(b, tree.hasherName...)
+	b = binary.BigEndian.AppendUint64(b, tree.depth)
+	b = append(b, tree.canonicalFlags())
+	b = binary.BigEndian.AppendUint64(b, uint64(tree.levels[tree.depth].len()))
+	if _, err := bw.Write(b); err != nil {
+		return err
+	}
+
+	for _, index := range tree.levels[tree.depth].indices() {
+		node, _ := tree.levels[tree.depth].get(index)
+
+		b = binary.BigEndian.AppendUint64(b[:0], index)
+		b = binary.BigEndian.AppendUint32(b, uint32(len(node)))
+		b = append(b, node...)
+		if tree.salts != nil {
+			salt, ok := tree.salts[index]
+			if !ok {
+				return ErrUnencodableState
+			}
+			b = append(b, salt...)
+		}
+		if _, err := bw.Write(b); err != nil {
+			return err
+		}
+	}
+
+	indices := sortedIndices(tree.expiries)
+	b = binary.BigEndian.AppendUint64(b[:0], uint64(len(indices)))
+	for _, index := range indices {
+		expiry := tree.expiries[index]
+		b = binary.BigEndian.AppendUint64(b, index)
+		b = binary.BigEndian.AppendUint64(b, uint64(expiry.Unix()))
+		b = binary.BigEndian.AppendUint32(b, uint32(expiry.Nanosecond()))
+	}
+	if _, err := bw.Write(b); err != nil {
+		return err
+	}
+
+	indices = sortedIndices(tree.metadata)
+	b = binary.BigEndian.AppendUint64(b[:0], uint64(len(indices)))
+	if _, err := bw.Write(b); err != nil {
+		return err
+	}
+	for _, index := range indices {
+		metadata := tree.metadata[index]
+		b = binary.BigEndian.AppendUint64(b[:0], index)
+		b = binary.BigEndian.AppendUint32(b, uint32(len(metadata)))
+		b = append(b, metadata...)
+		if _, err := bw.Write(b); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+func (tree *Tree) canonicalFlags() byte {
+	var flags byte
+	if tree.ssz {
+		flags |= canonicalFlagSSZ
+	}
+	if tree.levelTweak {
+		flags |= canonicalFlagLevelTweak
+	}
+	if tree.sortedPairs {
+		flags |= canonicalFlagSortedPairs
+	}
+	if tree.salts != nil {
+		flags |= canonicalFlagSaltedLeaf
+	}
+	return flags
+}
+
+// StateHash hashes the canonical form of the tree with its hasher. Unlike
+// the root, it also commits to the parameters and hashing modes of the
+// tree, to the salts, expiries and metadata of its leaves, and tells an
+// empty leaf from one whose node happens to be the default.
+func (tree *Tree) StateHash() ([]byte, error) {
+	hasher := tree.getHasher()
+	defer tree.putHasher(hasher)
+
+	hasher.Reset()
+	if err := tree.WriteCanonical(hasher); err != nil {
+		return nil, err
+	}
+	return hasher.Sum(nil), nil
+}
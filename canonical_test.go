@@ -0,0 +1,147 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+)
+
+func TestTree_WriteCanonical(t *testing.T) {
+	tree, err := NewTreeByName("sha256", 3, map[uint64][]byte{
+		3: []byte{0x03},
+		0: []byte{0x00},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := tree.WriteCanonical(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "00000006" + hex.EncodeToString([]byte("sha256")) +
+		"0000000000000003" +
+		"00" +
+		"0000000000000002" +
+		"0000000000000000" + "00000020" + hex.EncodeToString(testNode(tree, 3, 0)) +
+		"0000000000000003" + "00000020" + hex.EncodeToString(testNode(tree, 3, 3)) +
+		"0000000000000000" +
+		"0000000000000000"
+	if actual := hex.EncodeToString(buf.Bytes()); actual != expected {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
+func TestTree_StateHash(t *testing.T) {
+	zeroLeaf := make([]byte, sha256.Size)
+
+	testCases := []struct {
+		name  string
+		tree1 func() (*Tree, error)
+		tree2 func() (*Tree, error)
+		equal bool
+	}{
+		{
+			"same leaves",
+			func() (*Tree, error) { return NewTree(sha256.New(), 3, map[uint64][]byte{1: {0x01}}) },
+			func() (*Tree, error) { return NewTree(sha256.New(), 3, map[uint64][]byte{1: {0x01}}) },
+			true,
+		},
+		{
+			"different depths",
+			func() (*Tree, error) { return NewTree(sha256.New(), 3, nil) },
+			func() (*Tree, error) { return NewTree(sha256.New(), 4, nil) },
+			false,
+		},
+		{
+			"different hashing modes",
+			func() (*Tree, error) { return NewTree(sha256.New(), 3, map[uint64][]byte{1: {0x01}}) },
+			func() (*Tree, error) {
+				return NewTree(sha256.New(), 3, map[uint64][]byte{1: {0x01}}, WithLevelTweak())
+			},
+			false,
+		},
+		{
+			"different expiries",
+			func() (*Tree, error) { return NewTree(sha256.New(), 3, map[uint64][]byte{1: {0x01}}) },
+			func() (*Tree, error) {
+				tree, err := NewTree(sha256.New(), 3, nil)
+				if err != nil {
+					return nil, err
+				}
+				return tree, tree.UpdateWithExpiry(1, []byte{0x01}, time.Unix(1, 0))
+			},
+			false,
+		},
+		{
+			"different metadata",
+			func() (*Tree, error) {
+				tree, err := NewTree(sha256.New(), 3, nil)
+				if err != nil {
+					return nil, err
+				}
+				return tree, tree.UpdateWithMetadata(1, LeafMetadata{Value: []byte{0x01}, Version: 1})
+			},
+			func() (*Tree, error) {
+				tree, err := NewTree(sha256.New(), 3, nil)
+				if err != nil {
+					return nil, err
+				}
+				return tree, tree.UpdateWithMetadata(1, LeafMetadata{Value: []byte{0x01}, Version: 2})
+			},
+			false,
+		},
+		{
+			"default leaf",
+			func() (*Tree, error) { return NewTree(sha256.New(), 3, map[uint64][]byte{1: zeroLeaf}) },
+			func() (*Tree, error) { return NewTree(sha256.New(), 3, nil) },
+			false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tree1, err := tc.tree1()
+			if err != nil {
+				t.Fatal(err)
+			}
+			tree2, err := tc.tree2()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			hash1, err := tree1.StateHash()
+			if err != nil {
+				t.Fatal(err)
+			}
+			hash2, err := tree2.StateHash()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bytes.Equal(hash1, hash2) != tc.equal {
+				t.Errorf("expected: %t, actual: %t", tc.equal, !tc.equal)
+			}
+		})
+	}
+}
+
+func TestTree_StateHash_unencodable(t *testing.T) {
+	tree, err := NewTree(sha256.New(), 3, nil, WithSaltedLeaves())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Update(1, []byte{0x01}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tree.StateHash(); err != nil {
+		t.Fatal(err)
+	}
+
+	delete(tree.salts, 1)
+	if _, err := tree.StateHash(); err != ErrUnencodableState {
+		t.Errorf("expected: %v, actual: %v", ErrUnencodableState, err)
+	}
+}
@@ -0,0 +1,122 @@
+package merkle
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var (
+	ErrChainMismatch     = errors.New("chain mismatch")
+	ErrInvalidChainProof = errors.New("invalid chain proof")
+)
+
+// ChainProof proves a leaf of an inner tree, such as the tree of a shard,
+// whose root is itself a leaf of an outer tree, such as a global tree of
+// shard roots, in one object: a proof of the leaf up to InnerRoot, and a
+// proof of InnerRoot as a leaf of the outer tree.
+type ChainProof struct {
+	InnerIndex uint64
+	InnerProof []byte
+	InnerRoot  Root
+	OuterIndex uint64
+	OuterProof []byte
+}
+
+// CreateChainProof chains the proof of the leaf at innerIndex of inner with
+// the proof of the root of inner, which must be the leaf at outerIndex of
+// the tree.
+func (tree *Tree) CreateChainProof(outerIndex uint64, inner *Tree, innerIndex uint64) (*ChainProof, error) {
+	if outerIndex > tree.indexMax {
+		return nil, ErrTooLargeLeafIndex
+	}
+
+	innerRoot := inner.Root()
+	node, err := tree.hashLeaf(innerRoot)
+	if err != nil {
+		return nil, err
+	}
+	if leafNode, ok := tree.levels[tree.depth].get(outerIndex); !ok || !Root(leafNode).Equal(node) {
+		return nil, ErrChainMismatch
+	}
+
+	innerProof, err := inner.CreateMembershipProof(innerIndex)
+	if err != nil {
+		return nil, err
+	}
+	outerProof, err := tree.CreateMembershipProof(outerIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ChainProof{
+		InnerIndex: innerIndex,
+		InnerProof: innerProof,
+		InnerRoot:  append(Root(nil), innerRoot...),
+		OuterIndex: outerIndex,
+		OuterProof: outerProof,
+	}, nil
+}
+
+// VerifyChainProof checks that leaf is in an inner tree of the parameters of
+// inner whose root is, according to proof, a leaf of the tree. Only the
+// parameters of inner are used, so it may be an empty tree.
+func (tree *Tree) VerifyChainProof(inner *Tree, leaf []byte, proof *ChainProof) (bool, error) {
+	ok, err := inner.VerifyAgainstRoots(proof.InnerIndex, leaf, proof.InnerProof, [][]byte{proof.InnerRoot})
+	if err != nil || !ok {
+		return false, err
+	}
+	return tree.VerifyAgainstRoots(proof.OuterIndex, proof.InnerRoot, proof.OuterProof, [][]byte{tree.Root()})
+}
+
+// MarshalBinary encodes the proof as inner index (8 bytes) || outer index
+// (8 bytes) || inner root size (4 bytes) || inner root || inner proof size
+// (4 bytes) || inner proof || outer proof.
+func (proof *ChainProof) MarshalBinary() ([]byte, error) {
+	b := binary.BigEndian.AppendUint64(nil, proof.InnerIndex)
+	b = binary.BigEndian.AppendUint64(b, proof.OuterIndex)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(proof.InnerRoot)))
+	b = append(b, proof.InnerRoot...)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(proof.InnerProof)))
+	b = append(b, proof.InnerProof...)
+	return append(b, proof.OuterProof...), nil
+}
+
+func (proof *ChainProof) UnmarshalBinary(b []byte) error {
+	if len(b) < 20 {
+		return ErrInvalidChainProof
+	}
+	innerIndex := binary.BigEndian.Uint64(b[0:8])
+	outerIndex := binary.BigEndian.Uint64(b[8:16])
+	b = b[16:]
+
+	innerRoot, b, ok := cutSized(b)
+	if !ok {
+		return ErrInvalidChainProof
+	}
+	innerProof, b, ok := cutSized(b)
+	if !ok {
+		return ErrInvalidChainProof
+	}
+
+	*proof = ChainProof{
+		InnerIndex: innerIndex,
+		InnerProof: append([]byte(nil), innerProof...),
+		InnerRoot:  append(Root(nil), innerRoot...),
+		OuterIndex: outerIndex,
+		OuterProof: append([]byte(nil), b...),
+	}
+	return nil
+}
+
+// cutSized splits a size (4 bytes) prefixed field off the front of b.
+func cutSized(b []byte) ([]byte, []byte, bool) {
+	if len(b) < 4 {
+		return nil, nil, false
+	}
+	size := uint64(binary.BigEndian.Uint32(b[:4]))
+	b = b[4:]
+	if uint64(len(b)) < size {
+		return nil, nil, false
+	}
+	return b[:size], b[size:], true
+}
@@ -0,0 +1,59 @@
+package merkle
+
+import (
+	"crypto/sha256"
+	"testing"
+)
+
+func TestTree_ChainProof(t *testing.T) {
+	inner := newTestTree(t)
+	leaf := []byte{0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03}
+
+	outer, err := NewTree(sha256.New(), 4, map[uint64][]byte{
+		1: []byte{0x01},
+		9: inner.Root(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := outer.CreateChainProof(1, inner, 3); err != ErrChainMismatch {
+		t.Errorf("expected: %v, actual: %v", ErrChainMismatch, err)
+	}
+
+	proof, err := outer.CreateChainProof(9, inner, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := proof.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded ChainProof
+	if err := decoded.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := decoded.UnmarshalBinary(b[:30]); err != ErrInvalidChainProof {
+		t.Errorf("expected: %v, actual: %v", ErrInvalidChainProof, err)
+	}
+
+	// the verifier only needs an empty tree of the parameters of the inner one
+	params, err := NewTree(sha256.New(), 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := outer.VerifyChainProof(params, leaf, &decoded); err != nil || !ok {
+		t.Errorf("expected: %t, actual: %t (%v)", true, ok, err)
+	}
+	if ok, err := outer.VerifyChainProof(params, []byte{0x04}, &decoded); err != nil || ok {
+		t.Errorf("expected: %t, actual: %t (%v)", false, ok, err)
+	}
+
+	if err := outer.Update(9, []byte{0x09}); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := outer.VerifyChainProof(params, leaf, &decoded); err != nil || ok {
+		t.Errorf("expected: %t, actual: %t (%v)", false, ok, err)
+	}
+}
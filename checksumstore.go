@@ -0,0 +1,74 @@
+package merkle
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+)
+
+var (
+	castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+)
+
+var (
+	ErrCorruptedNode = errors.New("corrupted node")
+)
+
+// ChecksumStore is a NodeStore decorator appending the CRC-32 (Castagnoli)
+// of every value and verifying it on read, so that bit rot in the underlying
+// store surfaces as ErrCorruptedNode instead of a wrong root. FileStore
+// checksums its values itself and needs no decorator.
+type ChecksumStore struct {
+	store NodeStore
+}
+
+func NewChecksumStore(store NodeStore) *ChecksumStore {
+	return &ChecksumStore{
+		store: store,
+	}
+}
+
+func (store *ChecksumStore) Get(key []byte) ([]byte, error) {
+	b, err := store.store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return verifyChecksum(b)
+}
+
+func (store *ChecksumStore) Put(key, value []byte) error {
+	return store.store.Put(key, appendChecksum(value))
+}
+
+func (store *ChecksumStore) Delete(key []byte) error {
+	return store.store.Delete(key)
+}
+
+func (store *ChecksumStore) Iterate(f func(key, value []byte) error) error {
+	return store.store.Iterate(func(key, b []byte) error {
+		value, err := verifyChecksum(b)
+		if err != nil {
+			return err
+		}
+		return f(key, value)
+	})
+}
+
+func appendChecksum(value []byte) []byte {
+	b := make([]byte, len(value), len(value)+4)
+	copy(b, value)
+	return binary.BigEndian.AppendUint32(b, crc32.Checksum(value, castagnoliTable))
+}
+
+func verifyChecksum(b []byte) ([]byte, error) {
+	if len(b) < 4 {
+		return nil, ErrCorruptedNode
+	}
+
+	value, sum := b[:len(b)-4], binary.BigEndian.Uint32(b[len(b)-4:])
+	if crc32.Checksum(value, castagnoliTable) != sum {
+		return nil, ErrCorruptedNode
+	}
+
+	return value, nil
+}
@@ -0,0 +1,42 @@
+package merkle
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestChecksumStore(t *testing.T) {
+	fileStore, err := NewFileStore(t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewChecksumStore(fileStore)
+
+	key := nodeKey(3, 0)
+	if err := store.Put(key, []byte{0x00, 0x01}); err != nil {
+		t.Fatal(err)
+	}
+	value, err := store.Get(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(value, []byte{0x00, 0x01}) {
+		t.Errorf("expected: %x, actual: %x", []byte{0x00, 0x01}, value)
+	}
+
+	b, err := fileStore.Get(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b[0] ^= 0x01
+	if err := fileStore.Put(key, b); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Get(key); err != ErrCorruptedNode {
+		t.Errorf("expected: %v, actual: %v", ErrCorruptedNode, err)
+	}
+	if err := store.Iterate(func(key, value []byte) error { return nil }); err != ErrCorruptedNode {
+		t.Errorf("expected: %v, actual: %v", ErrCorruptedNode, err)
+	}
+}
@@ -0,0 +1,84 @@
+//go:build js && wasm
+
+// Command smt-wasm lets browsers verify proofs produced by Go backends. It
+// only depends on the verifier package, and is built with
+//
+//	GOOS=js GOARCH=wasm go build -o smt.wasm ./cmd/smt-wasm
+//
+// Once loaded with the wasm_exec.js of the Go distribution, it defines
+//
+//	verifyProof(depth, root, index, leaf, proof)
+//
+// on the global object, verifying proofs of SHA-256 trees. root, leaf and
+// proof are hex strings, and leaf is null for an empty leaf. index is a
+// decimal string, as indices may exceed Number.MAX_SAFE_INTEGER. It returns
+// {valid, error}, where error is null unless the arguments are invalid.
+//
+// TinyGo is not supported: neither this command nor the packages it
+// depends on are built or tested with it.
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"syscall/js"
+
+	"github.com/m0t0k1ch1/sparse-merkle-tree/verifier"
+)
+
+var (
+	errInvalidArgs = errors.New("invalid arguments")
+)
+
+func main() {
+	js.Global().Set("verifyProof", js.FuncOf(func(this js.Value, args []js.Value) any {
+		valid, err := verifyProof(args)
+		result := map[string]any{
+			"valid": valid,
+			"error": nil,
+		}
+		if err != nil {
+			result["error"] = err.Error()
+		}
+		return result
+	}))
+
+	select {}
+}
+
+func verifyProof(args []js.Value) (bool, error) {
+	if len(args) != 5 {
+		return false, errInvalidArgs
+	}
+
+	depth, err := strconv.ParseUint(args[0].String(), 10, 64)
+	if err != nil {
+		return false, errInvalidArgs
+	}
+	root, err := hex.DecodeString(args[1].String())
+	if err != nil {
+		return false, errInvalidArgs
+	}
+	index, err := strconv.ParseUint(args[2].String(), 10, 64)
+	if err != nil {
+		return false, errInvalidArgs
+	}
+	var leaf []byte
+	if !args[3].IsNull() {
+		if leaf, err = hex.DecodeString(args[3].String()); err != nil {
+			return false, errInvalidArgs
+		}
+	}
+	proof, err := hex.DecodeString(args[4].String())
+	if err != nil {
+		return false, errInvalidArgs
+	}
+
+	v, err := verifier.New(sha256.New(), depth)
+	if err != nil {
+		return false, err
+	}
+	return v.Verify(root, index, leaf, proof)
+}
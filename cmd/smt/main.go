@@ -0,0 +1,80 @@
+// Command smt provides tooling around the sparse Merkle tree package.
+//
+// Usage:
+//
+//	smt testvectors [-hashers sha256] [-depths 8,64] [-leaves 0,1,16] [-seed 1]
+//
+// testvectors prints JSON fixtures for checking other implementations
+// against this one.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	merkle "github.com/m0t0k1ch1/sparse-merkle-tree"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "testvectors":
+		err = testVectors(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: smt testvectors [flags]")
+	os.Exit(2)
+}
+
+func testVectors(args []string) error {
+	fs := flag.NewFlagSet("testvectors", flag.ExitOnError)
+	hashers := fs.String("hashers", "sha256", "comma separated hasher names")
+	depths := fs.String("depths", "8,64", "comma separated tree depths")
+	leafCounts := fs.String("leaves", "0,1,16", "comma separated leaf counts")
+	seed := fs.Int64("seed", 1, "seed of the leaf generator")
+	fs.Parse(args)
+
+	matrix := merkle.TestVectorMatrix{
+		Hashers: strings.Split(*hashers, ","),
+		Seed:    *seed,
+	}
+	for _, s := range strings.Split(*depths, ",") {
+		depth, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		matrix.Depths = append(matrix.Depths, depth)
+	}
+	for _, s := range strings.Split(*leafCounts, ",") {
+		leafCount, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		matrix.LeafCounts = append(matrix.LeafCounts, leafCount)
+	}
+
+	vectors, err := merkle.GenerateTestVectors(matrix)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(vectors)
+}
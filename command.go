@@ -0,0 +1,156 @@
+package merkle
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"log/slog"
+	"slices"
+)
+
+const (
+	CommandVersion1 byte = 0x01
+
+	commandWriteHeadSize = 13
+)
+
+var (
+	ErrInvalidCommand        = errors.New("invalid command")
+	ErrNondeterministicApply = errors.New("nondeterministic apply")
+)
+
+// EncodeCommand returns the canonical encoding of a batch of writes, where
+// a nil Leaf deletes the leaf: version (1 byte) followed by the writes in
+// ascending order of their indices, each encoded like a journal entry as op
+// (1 byte) || index (8 bytes) || leaf size (4 bytes) || leaf. As in
+// ProveBatchTransition, a later write to an index wins over the earlier
+// ones, which are left out; CheckUniqueIndices rejects such a batch
+// instead. As the order is fixed and an index is written only once, a batch
+// has exactly one encoding.
+func EncodeCommand(writes []LeafWrite) ([]byte, error) {
+	sorted := slices.Clone(writes)
+	slices.SortStableFunc(sorted, func(a, b LeafWrite) int {
+		return cmp.Compare(a.Index, b.Index)
+	})
+
+	// keep the last of the writes of an index, compacting in place
+	last := sorted[:0]
+	for i, write := range sorted {
+		if i+1 < len(sorted) && sorted[i+1].Index == write.Index {
+			continue
+		}
+		last = append(last, write)
+	}
+	sorted = last
+
+	size := 1
+	for _, write := range sorted {
+		size += commandWriteHeadSize + len(write.Leaf)
+	}
+
+	cmd := make([]byte, 1, size)
+	cmd[0] = CommandVersion1
+	for _, write := range sorted {
+		op := journalOpUpdate
+		if write.Leaf == nil {
+			op = journalOpDelete
+		}
+		cmd = append(cmd, op)
+		cmd = binary.BigEndian.AppendUint64(cmd, write.Index)
+		cmd = binary.BigEndian.AppendUint32(cmd, uint32(len(write.Leaf)))
+		cmd = append(cmd, write.Leaf...)
+	}
+
+	return cmd, nil
+}
+
+// DecodeCommand decodes a command encoded by EncodeCommand, rejecting any
+// encoding that is not the canonical one.
+func DecodeCommand(cmd []byte) ([]LeafWrite, error) {
+	if len(cmd) == 0 || cmd[0] != CommandVersion1 {
+		return nil, ErrInvalidCommand
+	}
+	cmd = cmd[1:]
+
+	var writes []LeafWrite
+	for len(cmd) > 0 {
+		if len(cmd) < commandWriteHeadSize {
+			return nil, ErrInvalidCommand
+		}
+		op := cmd[0]
+		index := binary.BigEndian.Uint64(cmd[1:9])
+		size := uint64(binary.BigEndian.Uint32(cmd[9:13]))
+		cmd = cmd[commandWriteHeadSize:]
+
+		if len(writes) > 0 && writes[len(writes)-1].Index >= index {
+			return nil, ErrInvalidCommand
+		}
+		if uint64(len(cmd)) < size {
+			return nil, ErrInvalidCommand
+		}
+
+		switch op {
+		case journalOpUpdate:
+			writes = append(writes, LeafWrite{index, append([]byte{}, cmd[:size]...)})
+		case journalOpDelete:
+			if size != 0 {
+				return nil, ErrInvalidCommand
+			}
+			writes = append(writes, LeafWrite{index, nil})
+		default:
+			return nil, ErrInvalidCommand
+		}
+		cmd = cmd[size:]
+	}
+
+	return writes, nil
+}
+
+// Apply decodes cmd and applies its writes, returning the new root, as the
+// entry point of a replicated state machine such as one driven by Raft:
+// replicas applying the same commands in the same order end up with the
+// same roots byte for byte. The command is checked as a whole, its indices
+// and leaves included, before any write, so an invalid one leaves the tree
+// untouched; only a failing node store can interrupt a batch midway. Trees
+// with salted leaves are refused, as their roots depend on random salts.
+func (tree *Tree) Apply(cmd []byte) (root Root, err error) {
+	if tree.tracer != nil {
+		span := tree.tracer.Start("merkle.apply", slog.Int("size", len(cmd)))
+		defer func() {
+			span.End(err)
+		}()
+	}
+
+	if tree.salts != nil {
+		return nil, ErrNondeterministicApply
+	}
+
+	writes, err := DecodeCommand(cmd)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([][]byte, len(writes))
+	for i, write := range writes {
+		if write.Index > tree.indexMax {
+			return nil, ErrTooLargeLeafIndex
+		}
+		if write.Leaf != nil {
+			if nodes[i], err = tree.hashLeaf(write.Leaf); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for i, write := range writes {
+		if write.Leaf == nil {
+			err = tree.Delete(write.Index)
+		} else {
+			err = tree.updateNode(write.Index, write.Leaf, nodes[i])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return append(Root(nil), tree.Root()...), nil
+}
@@ -0,0 +1,171 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestEncodeCommand(t *testing.T) {
+	cmd, err := EncodeCommand([]LeafWrite{
+		{3, []byte{0x03}},
+		{1, nil},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []byte{
+		0x01,
+		0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, 0x03,
+	}
+	if !bytes.Equal(cmd, expected) {
+		t.Errorf("expected: %x, actual: %x", expected, cmd)
+	}
+
+	// the later write to an index wins
+	cmd, err = EncodeCommand([]LeafWrite{
+		{1, []byte{0x01}},
+		{3, []byte{0x03}},
+		{1, nil},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(cmd, expected) {
+		t.Errorf("expected: %x, actual: %x", expected, cmd)
+	}
+}
+
+func TestDecodeCommand(t *testing.T) {
+	testCases := []struct {
+		name string
+		cmd  []byte
+		err  error
+	}{
+		{
+			"failure: empty",
+			nil,
+			ErrInvalidCommand,
+		},
+		{
+			"failure: unknown version",
+			[]byte{0x02},
+			ErrInvalidCommand,
+		},
+		{
+			"failure: truncated head",
+			[]byte{0x01, 0x01, 0x00},
+			ErrInvalidCommand,
+		},
+		{
+			"failure: truncated leaf",
+			[]byte{0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x02, 0x01},
+			ErrInvalidCommand,
+		},
+		{
+			"failure: delete with leaf",
+			[]byte{0x01, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x01},
+			ErrInvalidCommand,
+		},
+		{
+			"failure: unknown op",
+			[]byte{0x01, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00},
+			ErrInvalidCommand,
+		},
+		{
+			"failure: unsorted",
+			[]byte{
+				0x01,
+				0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00,
+				0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+			},
+			ErrInvalidCommand,
+		},
+		{
+			"success: no writes",
+			[]byte{0x01},
+			nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := DecodeCommand(tc.cmd); err != tc.err {
+				t.Errorf("expected: %v, actual: %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestTree_Apply(t *testing.T) {
+	cmd, err := EncodeCommand([]LeafWrite{
+		{0, nil},
+		{5, []byte{0x05}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replicas := []*Tree{newTestTree(t), newTestTree(t)}
+	var roots []Root
+	for _, tree := range replicas {
+		root, err := tree.Apply(cmd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+	}
+	if !roots[0].Equal(roots[1]) {
+		t.Errorf("expected: %x, actual: %x", roots[0], roots[1])
+	}
+
+	expected := newTestTree(t)
+	if err := expected.Delete(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := expected.Update(5, []byte{0x05}); err != nil {
+		t.Fatal(err)
+	}
+	if !roots[0].Equal(expected.Root()) {
+		t.Errorf("expected: %x, actual: %x", expected.Root(), roots[0])
+	}
+
+	// an index out of range rejects the whole command
+	tree := newTestTree(t)
+	root := tree.Root()
+	cmd, err = EncodeCommand([]LeafWrite{{1, []byte{0x01}}, {8, []byte{0x08}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tree.Apply(cmd); err != ErrTooLargeLeafIndex {
+		t.Errorf("expected: %v, actual: %v", ErrTooLargeLeafIndex, err)
+	}
+	if !tree.Root().Equal(root) {
+		t.Errorf("expected: %x, actual: %x", root, tree.Root())
+	}
+
+	// so does a leaf the tree cannot hash, here a short SSZ chunk
+	ssz, err := NewTree(sha256.New(), 3, nil, WithSSZ())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd, err = EncodeCommand([]LeafWrite{{1, make([]byte, sha256.Size)}, {2, []byte{0x02}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ssz.Apply(cmd); err != ErrInvalidChunkSize {
+		t.Errorf("expected: %v, actual: %v", ErrInvalidChunkSize, err)
+	}
+	if ssz.HasLeaf(1) {
+		t.Errorf("expected: %t, actual: %t", false, true)
+	}
+
+	salted, err := NewTree(sha256.New(), 3, nil, WithSaltedLeaves())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := salted.Apply([]byte{0x01}); err != ErrNondeterministicApply {
+		t.Errorf("expected: %v, actual: %v", ErrNondeterministicApply, err)
+	}
+}
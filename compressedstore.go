@@ -0,0 +1,123 @@
+package merkle
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"io"
+	"sync"
+)
+
+const (
+	// CompressedValueSizeMax bounds the size of the values of a
+	// CompressedStore, so that a corrupted or forged value cannot
+	// decompress to an arbitrary size.
+	CompressedValueSizeMax = 1 << 20
+)
+
+const (
+	compressionNone  byte = 0x00
+	compressionFlate byte = 0x01
+)
+
+var (
+	ErrInvalidCompressedValue = errors.New("invalid compressed value")
+	ErrTooLargeValue          = errors.New("too large value")
+)
+
+// CompressedStore is a NodeStore decorator compressing values with DEFLATE.
+// Values that do not shrink, such as bare node hashes, are stored as they
+// are behind a one byte header.
+type CompressedStore struct {
+	store   NodeStore
+	writers sync.Pool
+}
+
+// NewCompressedStore wraps store with a compress/flate level.
+func NewCompressedStore(store NodeStore, level int) (*CompressedStore, error) {
+	if _, err := flate.NewWriter(io.Discard, level); err != nil {
+		return nil, err
+	}
+	return &CompressedStore{
+		store: store,
+		writers: sync.Pool{
+			New: func() any {
+				w, _ := flate.NewWriter(io.Discard, level)
+				return w
+			},
+		},
+	}, nil
+}
+
+func (store *CompressedStore) Get(key []byte) ([]byte, error) {
+	b, err := store.store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return decompressValue(b)
+}
+
+// Put fails with ErrTooLargeValue for a value larger than
+// CompressedValueSizeMax.
+func (store *CompressedStore) Put(key, value []byte) error {
+	if len(value) > CompressedValueSizeMax {
+		return ErrTooLargeValue
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteByte(compressionFlate)
+
+	w := store.writers.Get().(*flate.Writer)
+	defer store.writers.Put(w)
+
+	w.Reset(buf)
+	if _, err := w.Write(value); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	if buf.Len() > len(value) {
+		return store.store.Put(key, append([]byte{compressionNone}, value...))
+	}
+	return store.store.Put(key, buf.Bytes())
+}
+
+func (store *CompressedStore) Delete(key []byte) error {
+	return store.store.Delete(key)
+}
+
+func (store *CompressedStore) Iterate(f func(key, value []byte) error) error {
+	return store.store.Iterate(func(key, b []byte) error {
+		value, err := decompressValue(b)
+		if err != nil {
+			return err
+		}
+		return f(key, value)
+	})
+}
+
+func decompressValue(b []byte) ([]byte, error) {
+	if len(b) == 0 {
+		return nil, ErrInvalidCompressedValue
+	}
+
+	switch b[0] {
+	case compressionNone:
+		return b[1:], nil
+
+	case compressionFlate:
+		r := flate.NewReader(bytes.NewReader(b[1:]))
+		defer r.Close()
+
+		value, err := io.ReadAll(io.LimitReader(r, CompressedValueSizeMax+1))
+		if err != nil || len(value) > CompressedValueSizeMax {
+			return nil, ErrInvalidCompressedValue
+		}
+		return value, nil
+
+	default:
+		return nil, ErrInvalidCompressedValue
+	}
+}
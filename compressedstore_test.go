@@ -0,0 +1,106 @@
+package merkle
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestCompressedStore(t *testing.T) {
+	fileStore, err := NewFileStore(t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewCompressedStore(fileStore, 10); err == nil {
+		t.Errorf("expected: error, actual: %v", err)
+	}
+
+	store, err := NewCompressedStore(fileStore, flate.BestCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type output struct {
+		compression byte
+	}
+	testCases := []struct {
+		name  string
+		value []byte
+		out   output
+	}{
+		{
+			"incompressible",
+			sha256.New().Sum(nil),
+			output{
+				compressionNone,
+			},
+		},
+		{
+			"compressible",
+			bytes.Repeat([]byte{0x01}, 1024),
+			output{
+				compressionFlate,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			key := nodeKey(0, 0)
+
+			if err := store.Put(key, tc.value); err != nil {
+				t.Fatal(err)
+			}
+
+			raw, err := fileStore.Get(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if raw[0] != tc.out.compression {
+				t.Errorf("expected: %d, actual: %d", tc.out.compression, raw[0])
+			}
+
+			value, err := store.Get(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(value, tc.value) {
+				t.Errorf("expected: %x, actual: %x", tc.value, value)
+			}
+		})
+	}
+
+	if err := fileStore.Put(nodeKey(0, 0), []byte{0x02}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(nodeKey(0, 0)); err != ErrInvalidCompressedValue {
+		t.Errorf("expected: %v, actual: %v", ErrInvalidCompressedValue, err)
+	}
+
+	tooLarge := make([]byte, CompressedValueSizeMax+1)
+	if err := store.Put(nodeKey(0, 0), tooLarge); err != ErrTooLargeValue {
+		t.Errorf("expected: %v, actual: %v", ErrTooLargeValue, err)
+	}
+
+	// a value that was not written by Put and decompresses past the limit
+	var bomb bytes.Buffer
+	bomb.WriteByte(compressionFlate)
+	w, err := flate.NewWriter(&bomb, flate.BestCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(tooLarge); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := fileStore.Put(nodeKey(0, 0), bomb.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(nodeKey(0, 0)); err != ErrInvalidCompressedValue {
+		t.Errorf("expected: %v, actual: %v", ErrInvalidCompressedValue, err)
+	}
+}
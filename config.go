@@ -0,0 +1,92 @@
+package merkle
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"strconv"
+)
+
+var (
+	ErrInvalidStoreDSN = errors.New("invalid store dsn")
+)
+
+// Config declares the parameters of a tree. Store is a DSN understood by
+// OpenStore, and the tree is kept in memory only when it is empty.
+type Config struct {
+	Hasher string `json:"hasher"`
+	Depth  uint64 `json:"depth"`
+	Store  string `json:"store,omitempty"`
+}
+
+// LoadConfig reads a JSON encoded Config from path.
+func LoadConfig(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	decoder := json.NewDecoder(f)
+	decoder.DisallowUnknownFields()
+
+	var config Config
+	if err := decoder.Decode(&config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// NewTree builds an empty tree, or restores the tree kept in the configured
+// store.
+func (config *Config) NewTree(opts ...Option) (*Tree, error) {
+	if config.Store == "" {
+		return NewTreeByName(config.Hasher, config.Depth, nil, opts...)
+	}
+
+	newHasher, err := LookupHasher(config.Hasher)
+	if err != nil {
+		return nil, err
+	}
+	store, err := OpenStore(config.Store)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := LoadTree(newHasher(), config.Depth, store, opts...)
+	if err != nil {
+		return nil, err
+	}
+	tree.hasherName = config.Hasher
+
+	return tree, nil
+}
+
+// OpenStore opens the NodeStore described by dsn. The only scheme is
+// "file", as in file:///var/lib/tree?sync=true, which opens a FileStore.
+func OpenStore(dsn string) (NodeStore, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "file":
+		if u.Path == "" {
+			return nil, ErrInvalidStoreDSN
+		}
+
+		sync := false
+		if s := u.Query().Get("sync"); s != "" {
+			if sync, err = strconv.ParseBool(s); err != nil {
+				return nil, ErrInvalidStoreDSN
+			}
+		}
+
+		return NewFileStore(u.Path, sync)
+
+	default:
+		return nil, ErrInvalidStoreDSN
+	}
+}
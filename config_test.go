@@ -0,0 +1,101 @@
+package merkle
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	type output struct {
+		config Config
+		err    bool
+	}
+	testCases := []struct {
+		name string
+		json string
+		out  output
+	}{
+		{
+			"failure: unknown field",
+			`{"hasher": "sha256", "depth": 3, "leaf": "00"}`,
+			output{
+				Config{},
+				true,
+			},
+		},
+		{
+			"success",
+			`{"hasher": "sha256", "depth": 3, "store": "file://` + dir + `/nodes?sync=true"}`,
+			output{
+				Config{
+					Hasher: "sha256",
+					Depth:  3,
+					Store:  "file://" + dir + "/nodes?sync=true",
+				},
+				false,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := tc.out
+
+			path := filepath.Join(dir, "config.json")
+			if err := os.WriteFile(path, []byte(tc.json), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			config, err := LoadConfig(path)
+			if (err != nil) != out.err {
+				t.Errorf("expected: %t, actual: %v", out.err, err)
+			}
+			if err == nil {
+				if *config != out.config {
+					t.Errorf("expected: %+v, actual: %+v", out.config, *config)
+				}
+			}
+		})
+	}
+}
+
+func TestConfig_NewTree(t *testing.T) {
+	config := &Config{
+		Hasher: "sha256",
+		Depth:  3,
+		Store:  "file://" + t.TempDir(),
+	}
+
+	tree, err := config.NewTree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Update(0, []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Update(3, []byte{0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03}); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := config.NewTree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := newTestTree(t)
+	if !bytes.Equal(reopened.Root(), expected.Root()) {
+		t.Errorf("expected: %x, actual: %x", expected.Root(), reopened.Root())
+	}
+	if reopened.HasherName() != "sha256" {
+		t.Errorf("expected: %s, actual: %s", "sha256", reopened.HasherName())
+	}
+
+	for _, dsn := range []string{"mem://", "file://", "file:///tmp?sync=maybe"} {
+		if _, err := OpenStore(dsn); err != ErrInvalidStoreDSN {
+			t.Errorf("%s: expected: %v, actual: %v", dsn, ErrInvalidStoreDSN, err)
+		}
+	}
+}
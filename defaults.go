@@ -0,0 +1,113 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"sync"
+)
+
+// defaultNodeTable holds the default node of every height from the leaf
+// (height 0) up to DepthMax, computed once per process and checked against
+// the known node of height DepthMax, so that a hasher that is not what the
+// table claims it to be leaves the table unused rather than trees wrong.
+type defaultNodeTable struct {
+	once      sync.Once
+	newHasher func() hash.Hash
+	topHex    string
+	nodes     [][]byte
+}
+
+// Keccak-256 is not in the standard library, so it has no table; trees of
+// it compute their default nodes on their own.
+var (
+	sha256DefaultNodes = &defaultNodeTable{
+		newHasher: sha256.New,
+		topHex:    "25441aeb06532079d31e076f0210a8f2d14175fff809058f10f8e40e3bcea40d",
+	}
+)
+
+func (table *defaultNodeTable) get() [][]byte {
+	table.once.Do(func() {
+		hasher := table.newHasher()
+
+		nodes := make([][]byte, DepthMax+1)
+		hasher.Write(make([]byte, hasher.Size()))
+		nodes[0] = hasher.Sum(nil)
+
+		for h := uint64(1); h <= DepthMax; h++ {
+			hasher.Reset()
+			hasher.Write(nodes[h-1])
+			hasher.Write(nodes[h-1])
+			nodes[h] = hasher.Sum(nil)
+		}
+
+		if hex.EncodeToString(nodes[DepthMax]) != table.topHex {
+			return
+		}
+		table.nodes = nodes
+	})
+	return table.nodes
+}
+
+// useDefaultNodeTable fills tree.defaultNodes from a precomputed table when
+// the hasher of the tree produces the same default leaf as the table. The
+// nodes are shared by every tree using the table, so they must never be
+// handed out without being copied.
+func (tree *Tree) useDefaultNodeTable(leafNode []byte) bool {
+	for _, table := range []*defaultNodeTable{sha256DefaultNodes} {
+		nodes := table.get()
+		if nodes == nil || !bytes.Equal(nodes[0], leafNode) {
+			continue
+		}
+		for d := uint64(0); d <= tree.depth; d++ {
+			tree.defaultNodes[d] = nodes[tree.depth-d]
+		}
+		return true
+	}
+	return false
+}
+
+// DefaultNodes returns the default node of every depth of a tree of the
+// given depth, from the root to the leaves, i.e. the nodes of the subtrees
+// with no leaves.
+func DefaultNodes(hasher hash.Hash, depth uint64) ([][]byte, error) {
+	if err := checkTreeParams(hasher, depth); err != nil {
+		return nil, err
+	}
+
+	tree := &Tree{
+		hasher:       hasher,
+		hashSize:     uint64(hasher.Size()),
+		depth:        depth,
+		defaultNodes: make([][]byte, depth+1),
+	}
+	if err := tree.buildDefaultNodes(); err != nil {
+		return nil, err
+	}
+
+	nodes := make([][]byte, len(tree.defaultNodes))
+	for d, node := range tree.defaultNodes {
+		nodes[d] = append([]byte(nil), node...)
+	}
+	return nodes, nil
+}
+
+// EmptyRoot returns the root of a tree of the given depth with no leaves,
+// computing only the default nodes instead of building the tree.
+func EmptyRoot(hasher hash.Hash, depth uint64) (Root, error) {
+	nodes, err := DefaultNodes(hasher, depth)
+	if err != nil {
+		return nil, err
+	}
+	return nodes[0], nil
+}
+
+// DefaultNode returns the node at depth of a subtree with no leaves.
+func (tree *Tree) DefaultNode(depth uint64) ([]byte, error) {
+	if depth > tree.depth {
+		return nil, ErrTooLargeTreeDepth
+	}
+	return append([]byte(nil), tree.defaultNodes[depth]...), nil
+}
@@ -0,0 +1,133 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"testing"
+)
+
+func TestDefaultNodeTable(t *testing.T) {
+	nodes := sha256DefaultNodes.get()
+
+	testCases := []struct {
+		height  uint64
+		nodeHex string
+	}{
+		{0, "66687aadf862bd776c8fc18b8e9f8e20089714856ee233b3902a591d0d5f2925"},
+		{3, "5b82b695a7ac2668e188b75f7d4fa79faa504117d1fdfcbe8a46915c1a8a5191"},
+		{64, "25441aeb06532079d31e076f0210a8f2d14175fff809058f10f8e40e3bcea40d"},
+	}
+
+	for _, tc := range testCases {
+		if nodeHex := hex.EncodeToString(nodes[tc.height]); nodeHex != tc.nodeHex {
+			t.Errorf("height %d: expected: %s, actual: %s", tc.height, tc.nodeHex, nodeHex)
+		}
+	}
+}
+
+func TestTree_buildDefaultNodes(t *testing.T) {
+	for _, newTree := range []func() *Tree{
+		func() *Tree { tree, _ := NewTree(sha256.New(), 3, nil); return tree },
+		func() *Tree { tree, _ := NewTree(sha512.New(), 3, nil); return tree },
+	} {
+		tree := newTree()
+
+		expected := make([][]byte, tree.depth+1)
+		expected[tree.depth], _ = tree.hash(make([]byte, tree.hashSize))
+		for d := tree.depth; d > 0; d-- {
+			expected[d-1], _ = tree.pairHash(tree.depth-d+1, expected[d], expected[d])
+		}
+
+		for d := range expected {
+			if !bytes.Equal(tree.defaultNodes[d], expected[d]) {
+				t.Errorf("depth %d: expected: %x, actual: %x", d, expected[d], tree.defaultNodes[d])
+			}
+		}
+	}
+}
+
+func TestEmptyRoot(t *testing.T) {
+	if _, err := EmptyRoot(sha256.New(), 65); err != ErrTooLargeTreeDepth {
+		t.Errorf("expected: %v, actual: %v", ErrTooLargeTreeDepth, err)
+	}
+
+	root, err := EmptyRoot(sha256.New(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rootHex := root.Hex(); rootHex != "5b82b695a7ac2668e188b75f7d4fa79faa504117d1fdfcbe8a46915c1a8a5191" {
+		t.Errorf("expected: %s, actual: %s", "5b82b695a7ac2668e188b75f7d4fa79faa504117d1fdfcbe8a46915c1a8a5191", rootHex)
+	}
+
+	tree, err := NewTree(sha512.New(), 5, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err = EmptyRoot(sha512.New(), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !root.Equal(tree.Root()) {
+		t.Errorf("expected: %x, actual: %x", tree.Root(), root)
+	}
+}
+
+func TestDefaultNodes(t *testing.T) {
+	tree, err := NewTree(sha256.New(), 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes, err := DefaultNodes(sha256.New(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 4 {
+		t.Fatalf("expected: %d, actual: %d", 4, len(nodes))
+	}
+	for d, node := range nodes {
+		expected, err := tree.DefaultNode(uint64(d))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(node, expected) {
+			t.Errorf("depth %d: expected: %x, actual: %x", d, expected, node)
+		}
+	}
+
+	if _, err := tree.DefaultNode(4); err != ErrTooLargeTreeDepth {
+		t.Errorf("expected: %v, actual: %v", ErrTooLargeTreeDepth, err)
+	}
+
+	// the returned nodes must not alias the shared default node table
+	nodes[0][0] ^= 0xff
+	if root, _ := EmptyRoot(sha256.New(), 3); !root.Equal(tree.Root()) {
+		t.Errorf("expected: %x, actual: %x", tree.Root(), root)
+	}
+}
+
+func TestTree_Root_sharedDefaultNodes(t *testing.T) {
+	a, err := NewTree(sha256.New(), 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewTree(sha256.New(), 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a.Root()[0] ^= 0xff
+
+	if rootHex := b.Root().Hex(); rootHex != "5b82b695a7ac2668e188b75f7d4fa79faa504117d1fdfcbe8a46915c1a8a5191" {
+		t.Errorf("expected: %s, actual: %s", "5b82b695a7ac2668e188b75f7d4fa79faa504117d1fdfcbe8a46915c1a8a5191", rootHex)
+	}
+	root, err := EmptyRoot(sha256.New(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !root.Equal(a.Root()) {
+		t.Errorf("expected: %x, actual: %x", root, a.Root())
+	}
+}
@@ -0,0 +1,168 @@
+// Package deposit reproduces the incremental Merkle tree of the Ethereum
+// deposit contract, so that staking tooling can compute deposit roots and
+// create and verify the deposit proofs of the consensus specs.
+//
+// The tree is a SHA-256 SSZ tree of depth 32 whose leaves are the roots of
+// the deposit data, with the zero hashes of the contract as its empty
+// subtrees. The deposit root is that root mixed in with the deposit count
+// as get_deposit_root of the contract does, and a proof is the branch of a
+// deposit followed by the count, 33 nodes checked by is_valid_merkle_branch.
+package deposit
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+
+	merkle "github.com/m0t0k1ch1/sparse-merkle-tree"
+)
+
+const (
+	// Depth is DEPOSIT_CONTRACT_TREE_DEPTH of the contract.
+	Depth = 32
+
+	// ProofLength is the number of nodes of a deposit proof, the branch and
+	// the deposit count.
+	ProofLength = Depth + 1
+
+	PubkeySize                = 48
+	WithdrawalCredentialsSize = 32
+	SignatureSize             = 96
+)
+
+var (
+	ErrTreeFull        = errors.New("deposit tree full")
+	ErrUnknownDeposit  = errors.New("unknown deposit")
+	ErrInvalidDeposit  = errors.New("invalid deposit data")
+	ErrInvalidProofLen = errors.New("invalid deposit proof length")
+)
+
+// Data is the DepositData of the consensus specs, as logged by the
+// contract on every deposit.
+type Data struct {
+	Pubkey                []byte
+	WithdrawalCredentials []byte
+	// Amount is in Gwei.
+	Amount    uint64
+	Signature []byte
+}
+
+// Root returns the hash tree root of the deposit data, computed the way the
+// contract computes the node it inserts.
+func (data Data) Root() ([32]byte, error) {
+	if len(data.Pubkey) != PubkeySize ||
+		len(data.WithdrawalCredentials) != WithdrawalCredentialsSize ||
+		len(data.Signature) != SignatureSize {
+		return [32]byte{}, ErrInvalidDeposit
+	}
+
+	pubkeyRoot := sha256.Sum256(append(append([]byte(nil), data.Pubkey...), make([]byte, 16)...))
+	signatureRoot := hashPair(
+		sha256.Sum256(data.Signature[:64]),
+		sha256.Sum256(append(append([]byte(nil), data.Signature[64:]...), make([]byte, 32)...)),
+	)
+
+	var amountChunk [32]byte
+	binary.LittleEndian.PutUint64(amountChunk[:], data.Amount)
+
+	return hashPair(
+		hashPair(pubkeyRoot, [32]byte(data.WithdrawalCredentials)),
+		hashPair(amountChunk, signatureRoot),
+	), nil
+}
+
+// Tree is the deposit tree. Unlike the contract, which keeps only the
+// right frontier, it holds every deposit so that any of them can be proven.
+type Tree struct {
+	tree  *merkle.Tree
+	count uint64
+}
+
+func New() (*Tree, error) {
+	tree, err := merkle.NewTree(sha256.New(), Depth, nil, merkle.WithSSZ())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tree{
+		tree: tree,
+	}, nil
+}
+
+func (t *Tree) Count() uint64 {
+	return t.count
+}
+
+// Push inserts the root of the next deposit data, as deposit of the
+// contract does.
+func (t *Tree) Push(dataRoot [32]byte) error {
+	if t.count == 1<<Depth-1 {
+		// the contract keeps the last leaf unused
+		return ErrTreeFull
+	}
+
+	if err := t.tree.Update(t.count, dataRoot[:]); err != nil {
+		return err
+	}
+	t.count++
+
+	return nil
+}
+
+// Root returns the deposit root, the same as get_deposit_root of the
+// contract after the same deposits.
+func (t *Tree) Root() ([32]byte, error) {
+	root, err := t.tree.MixInLength(t.count)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return [32]byte(root), nil
+}
+
+// Proof returns the proof of the deposit at index against the current
+// deposit root.
+func (t *Tree) Proof(index uint64) ([][32]byte, error) {
+	if index >= t.count {
+		return nil, ErrUnknownDeposit
+	}
+
+	branch, err := t.tree.SSZBranch(index)
+	if err != nil {
+		return nil, err
+	}
+
+	proof := make([][32]byte, 0, ProofLength)
+	for _, node := range branch {
+		proof = append(proof, [32]byte(node))
+	}
+
+	return append(proof, countChunk(t.count)), nil
+}
+
+// Verify reports whether proof links the root of the deposit data at index
+// to the deposit root, as process_deposit of the consensus specs checks.
+func Verify(dataRoot [32]byte, proof [][32]byte, index uint64, root [32]byte) (bool, error) {
+	if len(proof) != ProofLength {
+		return false, ErrInvalidProofLen
+	}
+	if index >= 1<<Depth {
+		return false, nil
+	}
+
+	branch := make([][]byte, len(proof))
+	for i := range proof {
+		branch[i] = proof[i][:]
+	}
+
+	return merkle.VerifySSZBranch(sha256.New(), dataRoot[:], branch, index, root[:])
+}
+
+func countChunk(count uint64) [32]byte {
+	var chunk [32]byte
+	binary.LittleEndian.PutUint64(chunk[:], count)
+	return chunk
+}
+
+func hashPair(left, right [32]byte) [32]byte {
+	return sha256.Sum256(append(left[:], right[:]...))
+}
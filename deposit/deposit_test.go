@@ -0,0 +1,132 @@
+package deposit
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+)
+
+// contract mirrors the deposit and get_deposit_root functions of the
+// deposit contract.
+type contract struct {
+	branch     [Depth][32]byte
+	zeroHashes [Depth][32]byte
+	count      uint64
+}
+
+func newContract() *contract {
+	c := &contract{}
+	for h := 0; h < Depth-1; h++ {
+		c.zeroHashes[h+1] = hashPair(c.zeroHashes[h], c.zeroHashes[h])
+	}
+	return c
+}
+
+func (c *contract) deposit(node [32]byte) {
+	c.count++
+	size := c.count
+	for h := 0; h < Depth; h++ {
+		if size&1 == 1 {
+			c.branch[h] = node
+			return
+		}
+		node = hashPair(c.branch[h], node)
+		size /= 2
+	}
+}
+
+func (c *contract) root() [32]byte {
+	var node [32]byte
+	size := c.count
+	for h := 0; h < Depth; h++ {
+		if size&1 == 1 {
+			node = hashPair(c.branch[h], node)
+		} else {
+			node = hashPair(node, c.zeroHashes[h])
+		}
+		size /= 2
+	}
+	return hashPair(node, countChunk(c.count))
+}
+
+func TestTree(t *testing.T) {
+	tree, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the deposit root of the contract before any deposit
+	root, err := tree.Root()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rootHex := hex.EncodeToString(root[:]); rootHex != "d70a234731285c6804c2a4f56711ddb8c82c99740f207854891028af34e27e5e" {
+		t.Errorf("expected: %s, actual: %s", "d70a234731285c6804c2a4f56711ddb8c82c99740f207854891028af34e27e5e", rootHex)
+	}
+
+	if _, err := tree.Proof(0); err != ErrUnknownDeposit {
+		t.Errorf("expected: %v, actual: %v", ErrUnknownDeposit, err)
+	}
+
+	c := newContract()
+	var dataRoots [][32]byte
+	for i := uint64(0); i < 6; i++ {
+		data := Data{
+			Pubkey:                make([]byte, PubkeySize),
+			WithdrawalCredentials: make([]byte, WithdrawalCredentialsSize),
+			Amount:                32_000_000_000,
+			Signature:             make([]byte, SignatureSize),
+		}
+		binary.BigEndian.PutUint64(data.Pubkey, i)
+
+		dataRoot, err := data.Root()
+		if err != nil {
+			t.Fatal(err)
+		}
+		dataRoots = append(dataRoots, dataRoot)
+
+		if err := tree.Push(dataRoot); err != nil {
+			t.Fatal(err)
+		}
+		c.deposit(dataRoot)
+
+		root, err := tree.Root()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := c.root(); root != expected {
+			t.Errorf("expected: %x, actual: %x", expected, root)
+		}
+	}
+	if tree.Count() != 6 {
+		t.Errorf("expected: %d, actual: %d", 6, tree.Count())
+	}
+
+	root, err = tree.Root()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, dataRoot := range dataRoots {
+		proof, err := tree.Proof(uint64(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(proof) != ProofLength {
+			t.Fatalf("expected: %d, actual: %d", ProofLength, len(proof))
+		}
+
+		if ok, err := Verify(dataRoot, proof, uint64(i), root); err != nil || !ok {
+			t.Errorf("expected: %t, actual: %t (%v)", true, ok, err)
+		}
+		if ok, err := Verify(dataRoot, proof, uint64(i)^1, root); err != nil || ok {
+			t.Errorf("expected: %t, actual: %t (%v)", false, ok, err)
+		}
+	}
+
+	if _, err := Verify(dataRoots[0], nil, 0, root); err != ErrInvalidProofLen {
+		t.Errorf("expected: %v, actual: %v", ErrInvalidProofLen, err)
+	}
+	if _, err := (Data{}).Root(); err != ErrInvalidDeposit {
+		t.Errorf("expected: %v, actual: %v", ErrInvalidDeposit, err)
+	}
+}
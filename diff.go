@@ -0,0 +1,71 @@
+package merkle
+
+import (
+	"bytes"
+	"context"
+)
+
+type LeafChange struct {
+	Index   uint64
+	OldNode []byte
+	NewNode []byte
+	Err     error
+}
+
+// DiffStream walks the subtrees whose nodes differ from other and sends
+// the differing leaves in index order. The channel is closed after the last
+// change or after a change carrying Err, and must be drained by the caller
+// unless ctx is canceled, which stops the walk and closes the channel with
+// a change carrying the error of ctx if it can still be received. The walk
+// reads the tree and other as it goes, so neither may be written to until
+// the channel is closed.
+func (tree *Tree) DiffStream(ctx context.Context, other SyncTransport) <-chan LeafChange {
+	changes := make(chan LeafChange)
+
+	go func() {
+		defer close(changes)
+
+		if err := tree.diff(ctx, other, 0, 0, changes); err != nil {
+			select {
+			case changes <- LeafChange{Err: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	return changes
+}
+
+func (tree *Tree) diff(ctx context.Context, other SyncTransport, depth, index uint64, changes chan<- LeafChange) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	otherNode, err := other.Node(depth, index)
+	if err != nil {
+		return err
+	}
+
+	node, ok := tree.levels[depth].get(index)
+	if ok == (otherNode != nil) && bytes.Equal(node, otherNode) {
+		return nil
+	}
+
+	if depth == tree.depth {
+		select {
+		case changes <- LeafChange{
+			Index:   index,
+			OldNode: node,
+			NewNode: otherNode,
+		}:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if err := tree.diff(ctx, other, depth+1, index*2, changes); err != nil {
+		return err
+	}
+	return tree.diff(ctx, other, depth+1, index*2+1, changes)
+}
@@ -0,0 +1,80 @@
+package merkle
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestTree_DiffStream(t *testing.T) {
+	tree := newTestTree(t)
+
+	other, err := NewTree(sha256.New(), 3, map[uint64][]byte{
+		0: []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		2: []byte{0x02, 0x02, 0x02, 0x02, 0x02, 0x02, 0x02, 0x02},
+		3: []byte{0x04, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var changes []LeafChange
+	for change := range tree.DiffStream(context.Background(), other) {
+		if change.Err != nil {
+			t.Fatal(change.Err)
+		}
+		changes = append(changes, change)
+	}
+
+	expected := []LeafChange{
+		{
+			Index:   2,
+			OldNode: nil,
+			NewNode: testNode(other, 3, 2),
+		},
+		{
+			Index:   3,
+			OldNode: testNode(tree, 3, 3),
+			NewNode: testNode(other, 3, 3),
+		},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected: %d, actual: %d", len(expected), len(changes))
+	}
+	for i, change := range changes {
+		if change.Index != expected[i].Index {
+			t.Errorf("expected: %d, actual: %d", expected[i].Index, change.Index)
+		}
+		if !bytes.Equal(change.OldNode, expected[i].OldNode) {
+			t.Errorf("expected: %x, actual: %x", expected[i].OldNode, change.OldNode)
+		}
+		if !bytes.Equal(change.NewNode, expected[i].NewNode) {
+			t.Errorf("expected: %x, actual: %x", expected[i].NewNode, change.NewNode)
+		}
+	}
+}
+
+func TestTree_DiffStream_canceled(t *testing.T) {
+	tree := newTestTree(t)
+
+	other, err := NewTree(sha256.New(), 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := tree.DiffStream(ctx, other)
+	if change := <-changes; change.Err != nil || change.Index != 0 {
+		t.Fatalf("expected: %d, actual: %d (%v)", 0, change.Index, change.Err)
+	}
+	cancel()
+
+	// the walk stops and closes the channel, whether or not the remaining
+	// change got through
+	for change := range changes {
+		if change.Err != nil && change.Err != context.Canceled {
+			t.Errorf("expected: %v, actual: %v", context.Canceled, change.Err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package merkle
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+)
+
+var (
+	ErrInvalidCiphertext = errors.New("invalid ciphertext")
+)
+
+// EncryptedStore is a NodeStore decorator sealing values with AES-GCM. The
+// key of each value is authenticated along with it, so that values cannot be
+// moved between keys unnoticed.
+type EncryptedStore struct {
+	store NodeStore
+	aead  cipher.AEAD
+}
+
+// NewEncryptedStore wraps store with a 16, 24 or 32 byte AES key.
+func NewEncryptedStore(store NodeStore, key []byte) (*EncryptedStore, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedStore{
+		store: store,
+		aead:  aead,
+	}, nil
+}
+
+func (store *EncryptedStore) Get(key []byte) ([]byte, error) {
+	b, err := store.store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return store.open(key, b)
+}
+
+func (store *EncryptedStore) Put(key, value []byte) error {
+	nonce := make([]byte, store.aead.NonceSize(), store.aead.NonceSize()+len(value)+store.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	return store.store.Put(key, store.aead.Seal(nonce, nonce, value, key))
+}
+
+func (store *EncryptedStore) Delete(key []byte) error {
+	return store.store.Delete(key)
+}
+
+func (store *EncryptedStore) Iterate(f func(key, value []byte) error) error {
+	return store.store.Iterate(func(key, b []byte) error {
+		value, err := store.open(key, b)
+		if err != nil {
+			return err
+		}
+		return f(key, value)
+	})
+}
+
+func (store *EncryptedStore) open(key, b []byte) ([]byte, error) {
+	if len(b) < store.aead.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+
+	nonce, ciphertext := b[:store.aead.NonceSize()], b[store.aead.NonceSize():]
+
+	value, err := store.aead.Open(nil, nonce, ciphertext, key)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+
+	return value, nil
+}
@@ -0,0 +1,62 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestEncryptedStore(t *testing.T) {
+	fileStore, err := NewFileStore(t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewEncryptedStore(fileStore, make([]byte, 15)); err == nil {
+		t.Errorf("expected: error, actual: %v", err)
+	}
+
+	store, err := NewEncryptedStore(fileStore, make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tree, err := NewTree(sha256.New(), 3, map[uint64][]byte{
+		0: []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		3: []byte{0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03},
+	}, WithNodeStore(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := fileStore.Get(nodeKey(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(raw, tree.Root()) {
+		t.Errorf("expected: ciphertext, actual: %x", raw)
+	}
+
+	loaded, err := LoadTree(sha256.New(), 3, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(loaded.Root(), tree.Root()) {
+		t.Errorf("expected: %x, actual: %x", tree.Root(), loaded.Root())
+	}
+
+	if err := fileStore.Put(nodeKey(0, 1), raw); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(nodeKey(0, 1)); err != ErrInvalidCiphertext {
+		t.Errorf("expected: %v, actual: %v", ErrInvalidCiphertext, err)
+	}
+
+	otherStore, err := NewEncryptedStore(fileStore, bytes.Repeat([]byte{0x01}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := otherStore.Get(nodeKey(0, 0)); err != ErrInvalidCiphertext {
+		t.Errorf("expected: %v, actual: %v", ErrInvalidCiphertext, err)
+	}
+}
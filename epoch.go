@@ -0,0 +1,214 @@
+package merkle
+
+import (
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const (
+	epochTreeNamePrefix = "epoch/"
+)
+
+var (
+	ErrNoEpoch             = errors.New("no epoch")
+	ErrInvalidCurrentEpoch = errors.New("invalid current epoch")
+)
+
+// currentEpochKey is the key of the current epoch (8 bytes) in the store of
+// a forest. Its first byte, 0, keeps it apart from the keys of the trees,
+// which start with the size of a non-empty name.
+var currentEpochKey = []byte("\x00current-epoch")
+
+// EpochTreeName returns the name of the tree of an epoch in a Forest.
+func EpochTreeName(epoch uint64) string {
+	return epochTreeNamePrefix + strconv.FormatUint(epoch, 10)
+}
+
+// Rotate freezes the current epoch and starts the next one, seeded with the
+// leaves of the current epoch if seed is true and empty otherwise, or starts
+// epoch 0 if there is no epoch yet. It returns the new epoch and its tree.
+// Frozen epochs are retained for historical proofs and are read-only: their
+// trees, including those returned before they were frozen, fail every write
+// with ErrReadOnlyTree.
+func (forest *Forest) Rotate(seed bool) (uint64, *Tree, error) {
+	forest.mu.Lock()
+	defer forest.mu.Unlock()
+
+	current, ok, err := forest.currentEpoch()
+	if err != nil {
+		return 0, nil, err
+	}
+	if !ok {
+		tree, err := forest.createEpoch(0, nil)
+		return 0, tree, err
+	}
+
+	var base *Tree
+	if seed {
+		if base, err = forest.openEpoch(current); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	tree, err := forest.createEpoch(current+1, base)
+	if err != nil {
+		return 0, nil, err
+	}
+	if frozen, ok := forest.trees[EpochTreeName(current)]; ok {
+		frozen.frozen = true
+	}
+	return current + 1, tree, nil
+}
+
+// CurrentEpoch returns the current epoch and its tree, or ErrNoEpoch before
+// the first Rotate.
+func (forest *Forest) CurrentEpoch() (uint64, *Tree, error) {
+	forest.mu.Lock()
+	defer forest.mu.Unlock()
+
+	current, ok, err := forest.currentEpoch()
+	if err != nil {
+		return 0, nil, err
+	}
+	if !ok {
+		return 0, nil, ErrNoEpoch
+	}
+
+	tree, err := forest.openEpoch(current)
+	if err != nil {
+		return 0, nil, err
+	}
+	return current, tree, nil
+}
+
+// Epoch returns a read-only view of the tree of a frozen or the current
+// epoch.
+func (forest *Forest) Epoch(epoch uint64) (ReadOnlyTree, error) {
+	forest.mu.Lock()
+	defer forest.mu.Unlock()
+
+	tree, err := forest.openEpoch(epoch)
+	if err != nil {
+		return ReadOnlyTree{}, err
+	}
+	return tree.ReadOnly(), nil
+}
+
+// currentEpoch returns the current epoch from its key in the store. A store
+// written before the key existed is scanned for the latest epoch once, and
+// the key is written then.
+func (forest *Forest) currentEpoch() (uint64, bool, error) {
+	value, err := forest.store.Get(currentEpochKey)
+	if err == nil {
+		if len(value) != 8 {
+			return 0, false, ErrInvalidCurrentEpoch
+		}
+		return binary.BigEndian.Uint64(value), true, nil
+	}
+	if !errors.Is(err, ErrNodeNotFound) {
+		return 0, false, err
+	}
+
+	current, ok, err := forest.scanEpochs()
+	if err != nil || !ok {
+		return 0, false, err
+	}
+	if err := forest.putCurrentEpoch(current); err != nil {
+		return 0, false, err
+	}
+	return current, true, nil
+}
+
+func (forest *Forest) putCurrentEpoch(epoch uint64) error {
+	return forest.store.Put(currentEpochKey, binary.BigEndian.AppendUint64(nil, epoch))
+}
+
+// scanEpochs returns the latest epoch among the trees of the store.
+func (forest *Forest) scanEpochs() (uint64, bool, error) {
+	names, err := forest.Names()
+	if err != nil {
+		return 0, false, err
+	}
+
+	var (
+		current uint64
+		ok      bool
+	)
+	for _, name := range names {
+		epoch, isEpoch := parseEpochTreeName(name)
+		if isEpoch && (!ok || epoch > current) {
+			current, ok = epoch, true
+		}
+	}
+
+	return current, ok, nil
+}
+
+// parseEpochTreeName returns the epoch of name if it is the name of the tree
+// of an epoch.
+func parseEpochTreeName(name string) (uint64, bool) {
+	if !strings.HasPrefix(name, epochTreeNamePrefix) {
+		return 0, false
+	}
+	epoch, err := strconv.ParseUint(name[len(epochTreeNamePrefix):], 10, 64)
+	if err != nil || name != EpochTreeName(epoch) {
+		return 0, false
+	}
+	return epoch, true
+}
+
+func (forest *Forest) openEpoch(epoch uint64) (*Tree, error) {
+	name := EpochTreeName(epoch)
+	prefix, err := treePrefix(name)
+	if err != nil {
+		return nil, err
+	}
+	return forest.openTree(name, prefix)
+}
+
+// createEpoch creates the tree of epoch holding the nodes of base, if any,
+// and makes epoch the current one.
+func (forest *Forest) createEpoch(epoch uint64, base *Tree) (*Tree, error) {
+	name := EpochTreeName(epoch)
+	prefix, err := treePrefix(name)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := forest.createTree(name, prefix, nil)
+	if err != nil {
+		return nil, err
+	}
+	if base != nil {
+		if err := tree.copyNodes(base); err != nil {
+			return nil, err
+		}
+	}
+	if err := forest.putCurrentEpoch(epoch); err != nil {
+		return nil, err
+	}
+
+	return tree, nil
+}
+
+// copyNodes writes the nodes and salts of base to tree, which is empty.
+func (tree *Tree) copyNodes(base *Tree) error {
+	for d, level := range base.levels {
+		for index, node := range level.all() {
+			if err := tree.putNode(uint64(d), index, node); err != nil {
+				return err
+			}
+		}
+	}
+	if base.salts != nil && tree.salts != nil {
+		for index, salt := range base.salts {
+			if err := tree.putSalt(index, salt); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package merkle
+
+import (
+	"crypto/sha256"
+	"testing"
+)
+
+func TestForest_Rotate(t *testing.T) {
+	store, err := NewFileStore(t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forest, err := NewForest(sha256.New, 3, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := forest.CurrentEpoch(); err != ErrNoEpoch {
+		t.Errorf("expected: %v, actual: %v", ErrNoEpoch, err)
+	}
+
+	epoch, tree, err := forest.Rotate(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if epoch != 0 {
+		t.Errorf("expected: %d, actual: %d", 0, epoch)
+	}
+	if err := tree.Update(0, []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}); err != nil {
+		t.Fatal(err)
+	}
+	root0, tree0 := tree.Root(), tree
+
+	// a seeded epoch starts from the leaves of the frozen one
+	epoch, tree, err = forest.Rotate(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tree0.Update(3, []byte{0x03}); err != ErrReadOnlyTree {
+		t.Errorf("expected: %v, actual: %v", ErrReadOnlyTree, err)
+	}
+	if err := tree0.Delete(0); err != ErrReadOnlyTree {
+		t.Errorf("expected: %v, actual: %v", ErrReadOnlyTree, err)
+	}
+	if epoch != 1 {
+		t.Errorf("expected: %d, actual: %d", 1, epoch)
+	}
+	if !tree.Root().Equal(root0) {
+		t.Errorf("expected: %x, actual: %x", root0, tree.Root())
+	}
+	if err := tree.Update(3, []byte{0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03}); err != nil {
+		t.Fatal(err)
+	}
+	if expected := newTestTree(t).Root(); !tree.Root().Equal(expected) {
+		t.Errorf("expected: %x, actual: %x", expected, tree.Root())
+	}
+
+	if _, tree, err = forest.Rotate(false); err != nil {
+		t.Fatal(err)
+	}
+	if emptyRoot, _ := EmptyRoot(sha256.New(), 3); !tree.Root().Equal(emptyRoot) {
+		t.Errorf("expected: %x, actual: %x", emptyRoot, tree.Root())
+	}
+
+	// frozen epochs survive reopening the forest
+	reopened, err := NewForest(sha256.New, 3, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if epoch, _, err := reopened.CurrentEpoch(); err != nil || epoch != 2 {
+		t.Errorf("expected: %d, actual: %d (%v)", 2, epoch, err)
+	}
+	frozen, err := reopened.Epoch(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !frozen.Root().Equal(root0) {
+		t.Errorf("expected: %x, actual: %x", root0, frozen.Root())
+	}
+	if err := frozen.Update(3, []byte{0x03}); err != ErrReadOnlyTree {
+		t.Errorf("expected: %v, actual: %v", ErrReadOnlyTree, err)
+	}
+	opened, err := reopened.OpenTree(EpochTreeName(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := opened.Update(3, []byte{0x03}); err != ErrReadOnlyTree {
+		t.Errorf("expected: %v, actual: %v", ErrReadOnlyTree, err)
+	}
+	if _, current, err := reopened.CurrentEpoch(); err != nil {
+		t.Fatal(err)
+	} else if err := current.Update(3, []byte{0x03}); err != nil {
+		t.Errorf("expected: %v, actual: %v", nil, err)
+	}
+
+	// the current epoch is read from its key, not from the names of the trees
+	names, err := reopened.Names()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 3 {
+		t.Errorf("expected: %d, actual: %d", 3, len(names))
+	}
+	if err := store.Put(currentEpochKey, []byte{0x00}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := reopened.CurrentEpoch(); err != ErrInvalidCurrentEpoch {
+		t.Errorf("expected: %v, actual: %v", ErrInvalidCurrentEpoch, err)
+	}
+}
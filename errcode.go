@@ -0,0 +1,184 @@
+package merkle
+
+import (
+	"errors"
+)
+
+// ErrorCode classifies the errors of the package for RPC surfaces, so that
+// remote clients can tell a bad index from a bad proof or a failing store
+// without matching error strings. The values are stable and new ones are
+// only ever appended.
+type ErrorCode uint32
+
+const (
+	CodeOK ErrorCode = iota
+	// CodeInternal is any error the package does not classify, such as an
+	// I/O error of a node store.
+	CodeInternal
+	CodeInvalidIndex
+	CodeInvalidProof
+	CodeInvalidArgument
+	CodeNotFound
+	CodeConflict
+	CodeFailedPrecondition
+	// CodeCorruptedStore is a node store holding data that cannot be read
+	// back as a tree.
+	CodeCorruptedStore
+)
+
+var errorCodes = []struct {
+	code ErrorCode
+	errs []error
+}{
+	{CodeInvalidIndex, []error{
+		ErrTooLargeLeafIndex,
+		ErrTooLargeNodeIndex,
+		ErrInvalidGeneralizedIndex,
+		ErrInvalidRange,
+		ErrNotAdjacentLeaves,
+	}},
+	{CodeInvalidProof, []error{
+		ErrInvalidProof,
+		ErrTooLargeProofSize,
+		ErrInvalidProofSize,
+		ErrInvalidProofHead,
+		ErrUnsupportedProofVersion,
+		ErrInvalidChainProof,
+		ErrInvalidEVMProof,
+		ErrInvalidVRFProof,
+		ErrInconsistentTransitions,
+		ErrWitnessRootMismatch,
+		ErrUnexpectedWitnessNode,
+	}},
+	{CodeInvalidArgument, []error{
+		ErrTooLargeTreeDepth,
+		ErrInvalidHashSize,
+		ErrInvalidNodeSize,
+		ErrUnsupportedHashSize,
+		ErrInvalidShardDepth,
+		ErrInvalidChunkSize,
+		ErrInvalidSaltSize,
+		ErrInvalidTreeName,
+		ErrInvalidCommand,
+		ErrInvalidMapLeaf,
+		ErrInvalidKey,
+		ErrInvalidLeafEncoding,
+		ErrInvalidPatch,
+		ErrInvalidRecord,
+		ErrUnknownRecordFormat,
+		ErrTooLargeRecord,
+		ErrInvalidJournalOp,
+		ErrInvalidEventOp,
+		ErrInvalidStoreDSN,
+		ErrInvalidPageLimit,
+		ErrInvalidResumeToken,
+		ErrUnknownHasher,
+		ErrInvalidFieldPacking,
+		ErrFieldOverflow,
+		ErrIncompatibleTrees,
+		ErrTooLargeValue,
+		ErrInvalidVRFKey,
+	}},
+	{CodeNotFound, []error{
+		ErrTreeNotFound,
+		ErrSaltNotFound,
+		ErrMetadataNotFound,
+		ErrNoEpoch,
+		ErrNotInWitness,
+		ErrEmptyFrontier,
+	}},
+	{CodeConflict, []error{
+		ErrTreeExists,
+		ErrKeyCollision,
+		ErrChainMismatch,
+		ErrPatchRootMismatch,
+		ErrReplacementRootMismatch,
+		ErrDuplicateIndex,
+		ErrSyncRootMismatch,
+	}},
+	{CodeFailedPrecondition, []error{
+		ErrReadOnlyTree,
+		ErrJournalDisabled,
+		ErrSaltedLeavesDisabled,
+		ErrStoreStatsUnavailable,
+		ErrNondeterministicApply,
+		ErrUncopyableHasher,
+		ErrFrontierFull,
+		ErrNotSortedPairTree,
+		ErrUnencodableState,
+	}},
+	{CodeCorruptedStore, []error{
+		ErrNodeNotFound,
+		ErrInvalidNodeKey,
+		ErrCorruptedNode,
+		ErrCorruptedReplacement,
+		ErrInvalidCiphertext,
+		ErrInvalidCompressedValue,
+		ErrInvalidCurrentEpoch,
+		ErrOutdatedSchema,
+		ErrUnsupportedSchemaVersion,
+	}},
+}
+
+// ErrorCodeOf returns the code of err, looking through wrapped errors.
+func ErrorCodeOf(err error) ErrorCode {
+	if err == nil {
+		return CodeOK
+	}
+	for _, class := range errorCodes {
+		for _, target := range class.errs {
+			if errors.Is(err, target) {
+				return class.code
+			}
+		}
+	}
+	return CodeInternal
+}
+
+// GRPCCode returns the canonical gRPC status code matching the code, for
+// servers to pass to status.Error as a codes.Code.
+func (code ErrorCode) GRPCCode() uint32 {
+	switch code {
+	case CodeOK:
+		return 0 // OK
+	case CodeInvalidIndex:
+		return 11 // OutOfRange
+	case CodeInvalidProof, CodeInvalidArgument:
+		return 3 // InvalidArgument
+	case CodeNotFound:
+		return 5 // NotFound
+	case CodeConflict:
+		return 10 // Aborted
+	case CodeFailedPrecondition:
+		return 9 // FailedPrecondition
+	case CodeCorruptedStore:
+		return 15 // DataLoss
+	default:
+		return 13 // Internal
+	}
+}
+
+func (code ErrorCode) String() string {
+	switch code {
+	case CodeOK:
+		return "ok"
+	case CodeInternal:
+		return "internal"
+	case CodeInvalidIndex:
+		return "invalid index"
+	case CodeInvalidProof:
+		return "invalid proof"
+	case CodeInvalidArgument:
+		return "invalid argument"
+	case CodeNotFound:
+		return "not found"
+	case CodeConflict:
+		return "conflict"
+	case CodeFailedPrecondition:
+		return "failed precondition"
+	case CodeCorruptedStore:
+		return "corrupted store"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,57 @@
+package merkle
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorCodeOf(t *testing.T) {
+	type output struct {
+		code     ErrorCode
+		grpcCode uint32
+	}
+	testCases := []struct {
+		name string
+		err  error
+		out  output
+	}{
+		{
+			"success: nil",
+			nil,
+			output{CodeOK, 0},
+		},
+		{
+			"success: bad index",
+			ErrTooLargeLeafIndex,
+			output{CodeInvalidIndex, 11},
+		},
+		{
+			"success: wrapped bad proof",
+			&ProofSizeError{Err: ErrInvalidProofSize},
+			output{CodeInvalidProof, 3},
+		},
+		{
+			"success: corrupted store",
+			fmt.Errorf("load: %w", ErrCorruptedNode),
+			output{CodeCorruptedStore, 15},
+		},
+		{
+			"success: store failure",
+			errors.New("disk full"),
+			output{CodeInternal, 13},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			code := ErrorCodeOf(tc.err)
+			if code != tc.out.code {
+				t.Errorf("expected: %v, actual: %v", tc.out.code, code)
+			}
+			if code.GRPCCode() != tc.out.grpcCode {
+				t.Errorf("expected: %d, actual: %d", tc.out.grpcCode, code.GRPCCode())
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+// Package ethanchor anchors tree roots on Ethereum by submitting them to a
+// contract on commit, retrying with exponential backoff.
+//
+// The package does not depend on go-ethereum itself. The binding generated
+// by abigen for a contract with a submitRoot(bytes32) method is adapted to
+// Contract by a closure over its transactor and transact options, which
+// also leaves waiting for receipts, if any, to the caller.
+package ethanchor
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	merkle "github.com/m0t0k1ch1/sparse-merkle-tree"
+)
+
+var (
+	ErrInvalidRootSize = errors.New("invalid root size")
+)
+
+// Contract submits a root to the anchoring contract.
+type Contract interface {
+	SubmitRoot(ctx context.Context, root [32]byte) error
+}
+
+// ContractFunc adapts a function to Contract.
+type ContractFunc func(ctx context.Context, root [32]byte) error
+
+func (f ContractFunc) SubmitRoot(ctx context.Context, root [32]byte) error {
+	return f(ctx, root)
+}
+
+// Backoff is the retry policy of an Anchor: up to Attempts submissions,
+// waiting Initial after the first failure and doubling the wait after each
+// further one, up to Max.
+type Backoff struct {
+	Attempts int
+	Initial  time.Duration
+	Max      time.Duration
+}
+
+var DefaultBackoff = Backoff{
+	Attempts: 5,
+	Initial:  time.Second,
+	Max:      30 * time.Second,
+}
+
+type Anchor struct {
+	contract Contract
+	backoff  Backoff
+}
+
+func New(contract Contract, backoff Backoff) *Anchor {
+	return &Anchor{
+		contract: contract,
+		backoff:  backoff,
+	}
+}
+
+// Submit submits root, retrying until it succeeds, the attempts run out or
+// ctx is done. It returns the last error of the contract in the second case.
+func (anchor *Anchor) Submit(ctx context.Context, root merkle.Root) error {
+	if len(root) != 32 {
+		return ErrInvalidRootSize
+	}
+	var b [32]byte
+	copy(b[:], root)
+
+	wait := anchor.backoff.Initial
+	for attempt := 1; ; attempt++ {
+		err := anchor.contract.SubmitRoot(ctx, b)
+		if err == nil {
+			return nil
+		}
+		if attempt >= anchor.backoff.Attempts {
+			return err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		if wait *= 2; wait > anchor.backoff.Max {
+			wait = anchor.backoff.Max
+		}
+	}
+}
+
+// Commit commits tree and submits the committed root, unless a commit hook
+// of tree vetoes the commit.
+func (anchor *Anchor) Commit(ctx context.Context, tree *merkle.AtomicTree) error {
+	if err := tree.Commit(); err != nil {
+		return err
+	}
+	return anchor.Submit(ctx, tree.Root())
+}
@@ -0,0 +1,76 @@
+package ethanchor
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"testing"
+	"time"
+
+	merkle "github.com/m0t0k1ch1/sparse-merkle-tree"
+)
+
+func TestAnchor_Commit(t *testing.T) {
+	tree, err := merkle.NewAtomicTree(sha256.New, 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Update(3, []byte{0x03}); err != nil {
+		t.Fatal(err)
+	}
+
+	errUnavailable := errors.New("unavailable")
+
+	var (
+		failures  int
+		submitted [][32]byte
+	)
+	anchor := New(ContractFunc(func(ctx context.Context, root [32]byte) error {
+		submitted = append(submitted, root)
+		if failures > 0 {
+			failures--
+			return errUnavailable
+		}
+		return nil
+	}), Backoff{
+		Attempts: 3,
+		Initial:  time.Millisecond,
+		Max:      2 * time.Millisecond,
+	})
+
+	failures = 2
+	if err := anchor.Commit(context.Background(), tree); err != nil {
+		t.Fatal(err)
+	}
+	if len(submitted) != 3 {
+		t.Fatalf("expected: %d, actual: %d", 3, len(submitted))
+	}
+	if !tree.Root().Equal(submitted[2][:]) {
+		t.Errorf("expected: %x, actual: %x", tree.Root(), submitted[2])
+	}
+
+	failures, submitted = 3, nil
+	if err := anchor.Submit(context.Background(), tree.Root()); err != errUnavailable {
+		t.Errorf("expected: %v, actual: %v", errUnavailable, err)
+	}
+	if len(submitted) != 3 {
+		t.Errorf("expected: %d, actual: %d", 3, len(submitted))
+	}
+
+	if err := anchor.Submit(context.Background(), tree.Root()[1:]); err != ErrInvalidRootSize {
+		t.Errorf("expected: %v, actual: %v", ErrInvalidRootSize, err)
+	}
+}
+
+func TestAnchor_Submit_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	anchor := New(ContractFunc(func(ctx context.Context, root [32]byte) error {
+		cancel()
+		return errors.New("unavailable")
+	}), DefaultBackoff)
+
+	if err := anchor.Submit(ctx, make(merkle.Root, 32)); err != context.Canceled {
+		t.Errorf("expected: %v, actual: %v", context.Canceled, err)
+	}
+}
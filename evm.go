@@ -0,0 +1,75 @@
+package merkle
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/bits"
+)
+
+const (
+	evmWordSize = 32
+)
+
+var (
+	ErrUnsupportedHashSize = errors.New("unsupported hash size")
+	ErrInvalidEVMProof     = errors.New("invalid evm proof")
+)
+
+// EncodeEVMProof converts a proof of 32 byte nodes into the ABI encoding of
+// (uint256 bitmap, bytes32[] siblings), which a contract can take as
+// arguments without slicing the proof by hand.
+func EncodeEVMProof(proof []byte) ([]byte, error) {
+	if uint64(len(proof)) < proofHeadSize {
+		return nil, ErrInvalidProofSize
+	}
+
+	// the siblings of a proof of nodes of any other size cannot add up to
+	// one word per bit of the head
+	proofHead := binary.BigEndian.Uint64(proof[:proofHeadSize])
+	siblings := proof[proofHeadSize:]
+	if len(siblings) != bits.OnesCount64(proofHead)*evmWordSize {
+		return nil, ErrUnsupportedHashSize
+	}
+
+	b := make([]byte, 3*evmWordSize, 3*evmWordSize+len(siblings))
+	copy(b[evmWordSize-proofHeadSize:evmWordSize], proof[:proofHeadSize])
+	b[2*evmWordSize-1] = 2 * evmWordSize
+	binary.BigEndian.PutUint64(b[3*evmWordSize-8:], uint64(len(siblings)/evmWordSize))
+
+	return append(b, siblings...), nil
+}
+
+func DecodeEVMProof(b []byte) ([]byte, error) {
+	if len(b) < 3*evmWordSize || (len(b)-3*evmWordSize)%evmWordSize != 0 {
+		return nil, ErrInvalidEVMProof
+	}
+	if !isZero(b[:evmWordSize-proofHeadSize]) {
+		return nil, ErrInvalidEVMProof
+	}
+	if !isZero(b[evmWordSize:2*evmWordSize-1]) || b[2*evmWordSize-1] != 2*evmWordSize {
+		return nil, ErrInvalidEVMProof
+	}
+	if !isZero(b[2*evmWordSize : 3*evmWordSize-8]) {
+		return nil, ErrInvalidEVMProof
+	}
+
+	proofHead := binary.BigEndian.Uint64(b[evmWordSize-proofHeadSize : evmWordSize])
+	length := binary.BigEndian.Uint64(b[3*evmWordSize-8 : 3*evmWordSize])
+	if length != uint64(bits.OnesCount64(proofHead)) || length != uint64(len(b)/evmWordSize-3) {
+		return nil, ErrInvalidEVMProof
+	}
+
+	proof := make([]byte, proofHeadSize, proofHeadSize+uint64(len(b))-3*evmWordSize)
+	binary.BigEndian.PutUint64(proof, proofHead)
+
+	return append(proof, b[3*evmWordSize:]...), nil
+}
+
+func isZero(b []byte) bool {
+	for _, x := range b {
+		if x != 0 {
+			return false
+		}
+	}
+	return true
+}
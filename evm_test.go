@@ -0,0 +1,127 @@
+package merkle
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestEncodeEVMProof(t *testing.T) {
+	type input struct {
+		proofHex string
+	}
+	type output struct {
+		encodedHex string
+		err        error
+	}
+	testCases := []struct {
+		name string
+		in   input
+		out  output
+	}{
+		{
+			"failure: unsupported hash size",
+			input{
+				"0000000000000001" +
+					"00000000000000000000000000000000000000000000000000000000000000",
+			},
+			output{
+				"",
+				ErrUnsupportedHashSize,
+			},
+		},
+		{
+			"failure: 64 byte nodes",
+			input{
+				"0000000000000001" +
+					"af5570f5a1810b7af78caf4bc70a660f0df51e42baf91d4de5b2328de0e83dfc" +
+					"1b6d2a8dca8d96e6dfa28a826037521bb587d3cb435c44c90139e87a7a4fa164",
+			},
+			output{
+				"",
+				ErrUnsupportedHashSize,
+			},
+		},
+		{
+			"success",
+			input{
+				"0000000000000003" +
+					"af5570f5a1810b7af78caf4bc70a660f0df51e42baf91d4de5b2328de0e83dfc" +
+					"1b6d2a8dca8d96e6dfa28a826037521bb587d3cb435c44c90139e87a7a4fa164",
+			},
+			output{
+				"0000000000000000000000000000000000000000000000000000000000000003" +
+					"0000000000000000000000000000000000000000000000000000000000000040" +
+					"0000000000000000000000000000000000000000000000000000000000000002" +
+					"af5570f5a1810b7af78caf4bc70a660f0df51e42baf91d4de5b2328de0e83dfc" +
+					"1b6d2a8dca8d96e6dfa28a826037521bb587d3cb435c44c90139e87a7a4fa164",
+				nil,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			in, out := tc.in, tc.out
+
+			proof, err := hex.DecodeString(in.proofHex)
+			if err != nil {
+				t.Fatal(err)
+			}
+			encoded, err := EncodeEVMProof(proof)
+			if err != out.err {
+				t.Errorf("expected: %v, actual: %v", out.err, err)
+			}
+			if err == nil {
+				encodedHex := hex.EncodeToString(encoded)
+				if encodedHex != out.encodedHex {
+					t.Errorf("expected: %s, actual: %s", out.encodedHex, encodedHex)
+				}
+
+				decoded, err := DecodeEVMProof(encoded)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if proofHex := hex.EncodeToString(decoded); proofHex != in.proofHex {
+					t.Errorf("expected: %s, actual: %s", in.proofHex, proofHex)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeEVMProof(t *testing.T) {
+	testCases := []struct {
+		name       string
+		encodedHex string
+	}{
+		{
+			"failure: too short",
+			"0000000000000000000000000000000000000000000000000000000000000000",
+		},
+		{
+			"failure: invalid offset",
+			"0000000000000000000000000000000000000000000000000000000000000000" +
+				"0000000000000000000000000000000000000000000000000000000000000020" +
+				"0000000000000000000000000000000000000000000000000000000000000000",
+		},
+		{
+			"failure: bitmap and length mismatch",
+			"0000000000000000000000000000000000000000000000000000000000000003" +
+				"0000000000000000000000000000000000000000000000000000000000000040" +
+				"0000000000000000000000000000000000000000000000000000000000000001" +
+				"af5570f5a1810b7af78caf4bc70a660f0df51e42baf91d4de5b2328de0e83dfc",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			encoded, err := hex.DecodeString(tc.encodedHex)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := DecodeEVMProof(encoded); err != ErrInvalidEVMProof {
+				t.Errorf("expected: %v, actual: %v", ErrInvalidEVMProof, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package merkle
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var (
+	ErrNotAdjacentLeaves = errors.New("not adjacent leaves")
+)
+
+// CreateExclusionProof proves that left and right are occupied and that no
+// leaf between them is, which is how sorted-key deployments show that no
+// key exists between the keys stored at left and right.
+func (tree *Tree) CreateExclusionProof(left, right uint64) ([]byte, []byte, error) {
+	if right > tree.indexMax {
+		return nil, nil, ErrTooLargeLeafIndex
+	}
+	if left >= right || !tree.HasLeaf(left) || !tree.HasLeaf(right) {
+		return nil, nil, ErrNotAdjacentLeaves
+	}
+	if next, ok := tree.NextOccupied(left); !ok || next != right {
+		return nil, nil, ErrNotAdjacentLeaves
+	}
+
+	leftProof, err := tree.CreateMembershipProof(left)
+	if err != nil {
+		return nil, nil, err
+	}
+	rightProof, err := tree.CreateMembershipProof(right)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return leftProof, rightProof, nil
+}
+
+func (tree *Tree) VerifyExclusionProof(left, right uint64, leftProof, rightProof []byte) (bool, error) {
+	if right > tree.indexMax {
+		return false, ErrTooLargeLeafIndex
+	}
+	if left >= right || !tree.HasLeaf(left) || !tree.HasLeaf(right) {
+		return false, nil
+	}
+
+	for _, p := range []struct {
+		index uint64
+		proof []byte
+	}{
+		{left, leftProof},
+		{right, rightProof},
+	} {
+		ok, err := tree.VerifyMembershipProof(p.index, p.proof)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	// Every subtree strictly between left and right hangs off the right
+	// side of the left path or the left side of the right path below their
+	// common ancestor, and the proof heads must mark all of them as default.
+	leftHead := binary.BigEndian.Uint64(leftProof[:proofHeadSize])
+	rightHead := binary.BigEndian.Uint64(rightProof[:proofHeadSize])
+
+	for left/2 != right/2 {
+		if left%2 == 0 && leftHead&1 != 0 {
+			return false, nil
+		}
+		if right%2 == 1 && rightHead&1 != 0 {
+			return false, nil
+		}
+
+		leftHead >>= 1
+		rightHead >>= 1
+		left /= 2
+		right /= 2
+	}
+
+	return true, nil
+}
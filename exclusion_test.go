@@ -0,0 +1,117 @@
+package merkle
+
+import (
+	"crypto/sha256"
+	"testing"
+)
+
+func TestTree_ExclusionProof(t *testing.T) {
+	tree, err := NewTree(sha256.New(), 3, map[uint64][]byte{
+		1: []byte{0x01},
+		4: []byte{0x04},
+		6: []byte{0x06},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type input struct {
+		left  uint64
+		right uint64
+	}
+	type output struct {
+		err error
+	}
+	testCases := []struct {
+		name string
+		in   input
+		out  output
+	}{
+		{
+			"failure: too large leaf index",
+			input{
+				6,
+				8,
+			},
+			output{
+				ErrTooLargeLeafIndex,
+			},
+		},
+		{
+			"failure: empty leaf",
+			input{
+				1,
+				3,
+			},
+			output{
+				ErrNotAdjacentLeaves,
+			},
+		},
+		{
+			"failure: not adjacent",
+			input{
+				1,
+				6,
+			},
+			output{
+				ErrNotAdjacentLeaves,
+			},
+		},
+		{
+			"success: across the root",
+			input{
+				1,
+				4,
+			},
+			output{
+				nil,
+			},
+		},
+		{
+			"success: within a subtree",
+			input{
+				4,
+				6,
+			},
+			output{
+				nil,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			in, out := tc.in, tc.out
+
+			leftProof, rightProof, err := tree.CreateExclusionProof(in.left, in.right)
+			if err != out.err {
+				t.Errorf("expected: %v, actual: %v", out.err, err)
+			}
+			if err == nil {
+				ok, err := tree.VerifyExclusionProof(in.left, in.right, leftProof, rightProof)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !ok {
+					t.Errorf("expected: %t, actual: %t", true, ok)
+				}
+			}
+		})
+	}
+
+	leftProof, err := tree.CreateMembershipProof(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rightProof, err := tree.CreateMembershipProof(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err := tree.VerifyExclusionProof(1, 6, leftProof, rightProof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Errorf("expected: %t, actual: %t", false, ok)
+	}
+}
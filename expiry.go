@@ -0,0 +1,50 @@
+package merkle
+
+import (
+	"slices"
+	"time"
+)
+
+// UpdateWithExpiry writes leaf at index like Update and has the first Sweep
+// at or after expiry delete it, for registries of nullifiers or sessions
+// that must not grow forever. Writing the leaf again with Update clears its
+// expiry. Unlike salts, expiries are neither journaled nor stored.
+func (tree *Tree) UpdateWithExpiry(index uint64, leaf []byte, expiry time.Time) error {
+	if err := tree.Update(index, leaf); err != nil {
+		return err
+	}
+
+	if tree.expiries == nil {
+		tree.expiries = map[uint64]time.Time{}
+	}
+	tree.expiries[index] = expiry
+
+	return nil
+}
+
+// Expiry returns the expiry of the leaf at index, if it has one.
+func (tree *Tree) Expiry(index uint64) (time.Time, bool) {
+	expiry, ok := tree.expiries[index]
+	return expiry, ok
+}
+
+// Sweep deletes the leaves whose expiry is not after now in ascending order
+// of their indices, and returns those indices. On an error it returns the
+// indices deleted so far.
+func (tree *Tree) Sweep(now time.Time) ([]uint64, error) {
+	var indices []uint64
+	for index, expiry := range tree.expiries {
+		if !expiry.After(now) {
+			indices = append(indices, index)
+		}
+	}
+	slices.Sort(indices)
+
+	for i, index := range indices {
+		if err := tree.Delete(index); err != nil {
+			return indices[:i], err
+		}
+	}
+
+	return indices, nil
+}
@@ -0,0 +1,87 @@
+package merkle
+
+import (
+	"crypto/sha256"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTree_Sweep(t *testing.T) {
+	tree, err := NewTree(sha256.New(), 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	empty := tree.Root()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for index, ttl := range map[uint64]time.Duration{
+		1: time.Minute,
+		2: time.Hour,
+		5: time.Minute,
+	} {
+		if err := tree.UpdateWithExpiry(index, []byte{byte(index)}, now.Add(ttl)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tree.Update(3, []byte{0x03}); err != nil {
+		t.Fatal(err)
+	}
+	// writing the leaf again clears its expiry
+	if err := tree.Update(5, []byte{0x05}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := tree.Expiry(5); ok {
+		t.Errorf("expected: %t, actual: %t", false, ok)
+	}
+
+	type input struct {
+		now time.Time
+	}
+	type output struct {
+		indices []uint64
+	}
+	testCases := []struct {
+		name string
+		in   input
+		out  output
+	}{
+		{
+			"success: nothing expired",
+			input{now.Add(time.Second)},
+			output{nil},
+		},
+		{
+			"success: expiring at now",
+			input{now.Add(time.Minute)},
+			output{[]uint64{1}},
+		},
+		{
+			"success: expired long ago",
+			input{now.Add(24 * time.Hour)},
+			output{[]uint64{2}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			indices, err := tree.Sweep(tc.in.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(indices, tc.out.indices) {
+				t.Errorf("expected: %v, actual: %v", tc.out.indices, indices)
+			}
+		})
+	}
+
+	if err := tree.Delete(3); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Delete(5); err != nil {
+		t.Fatal(err)
+	}
+	if !tree.Root().Equal(empty) {
+		t.Errorf("expected: %x, actual: %x", empty, tree.Root())
+	}
+}
@@ -0,0 +1,269 @@
+package merkle
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	fileStoreSchemaName = ".schema"
+)
+
+var (
+	// fileStoreSchemaVersion is the version of the layout written by
+	// FileStore. Version 1 is one file per node holding the value followed by
+	// its CRC-32 (Castagnoli); directories written before the layout was
+	// versioned are version 1 as well.
+	fileStoreSchemaVersion = 1
+
+	// fileStoreMigrations[v-1] migrates a store from version v to v+1. There
+	// is no migration yet, as the layout has not changed since version 1:
+	// the first one comes with the first change of the layout.
+	fileStoreMigrations []func(ctx context.Context, store *FileStore) error
+)
+
+var (
+	ErrOutdatedSchema           = errors.New("outdated schema")
+	ErrUnsupportedSchemaVersion = errors.New("unsupported schema version")
+)
+
+// FileStore is a NodeStore keeping one file per node in a directory. Each
+// file holds the value followed by its CRC-32 (Castagnoli), and is written to
+// a temporary file and renamed into place so that a crash never leaves a
+// partially written node behind.
+type FileStore struct {
+	dir           string
+	fsys          fs.FS
+	sync          bool
+	version       int
+	schemaWritten atomic.Bool
+	reads         atomic.Uint64
+	writes        atomic.Uint64
+	deletes       atomic.Uint64
+}
+
+// NewFileStore opens the store in dir, creating it if needed. When sync is
+// true every write is fsynced, together with the directory, before it
+// returns. A store written in an older layout must be upgraded with Migrate
+// before it can be used. Opening a store writes nothing: the version of the
+// layout is recorded with the first write, or by Migrate.
+func NewFileStore(dir string, sync bool) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	store := &FileStore{
+		dir:  dir,
+		fsys: os.DirFS(dir),
+		sync: sync,
+	}
+
+	b, err := fs.ReadFile(store.fsys, fileStoreSchemaName)
+	if errors.Is(err, fs.ErrNotExist) {
+		// a directory holding nodes but no schema predates versioning
+		empty, err := store.isEmpty()
+		if err != nil {
+			return nil, err
+		}
+		store.version = 1
+		if empty {
+			store.version = fileStoreSchemaVersion
+		}
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if store.version, err = strconv.Atoi(strings.TrimSpace(string(b))); err != nil || store.version < 1 {
+		return nil, ErrUnsupportedSchemaVersion
+	}
+	if store.version > fileStoreSchemaVersion {
+		return nil, ErrUnsupportedSchemaVersion
+	}
+	store.schemaWritten.Store(true)
+
+	return store, nil
+}
+
+// SchemaVersion returns the version of the layout the store is in.
+func (store *FileStore) SchemaVersion() int {
+	return store.version
+}
+
+// Migrate upgrades the store to the current layout one version at a time,
+// recording the version after each step so that an interrupted migration
+// resumes where it stopped.
+func (store *FileStore) Migrate(ctx context.Context) error {
+	for store.version < fileStoreSchemaVersion {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fileStoreMigrations[store.version-1](ctx, store); err != nil {
+			return err
+		}
+		if err := store.writeSchemaVersion(store.version + 1); err != nil {
+			return err
+		}
+		store.version++
+	}
+	return nil
+}
+
+func (store *FileStore) isEmpty() (bool, error) {
+	entries, err := fs.ReadDir(store.fsys, ".")
+	if err != nil {
+		return false, err
+	}
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), ".") {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (store *FileStore) writeSchemaVersion(version int) error {
+	if err := store.writeFile(fileStoreSchemaName, []byte(strconv.Itoa(version))); err != nil {
+		return err
+	}
+	store.schemaWritten.Store(true)
+	return nil
+}
+
+func (store *FileStore) checkSchema() error {
+	if store.version != fileStoreSchemaVersion {
+		return ErrOutdatedSchema
+	}
+	return nil
+}
+
+// checkWritableSchema is checkSchema for writes, recording the version of the
+// layout before the first one.
+func (store *FileStore) checkWritableSchema() error {
+	if err := store.checkSchema(); err != nil {
+		return err
+	}
+	if store.schemaWritten.Load() {
+		return nil
+	}
+	return store.writeSchemaVersion(store.version)
+}
+
+func (store *FileStore) Get(key []byte) ([]byte, error) {
+	if err := store.checkSchema(); err != nil {
+		return nil, err
+	}
+
+	store.reads.Add(1)
+	b, err := fs.ReadFile(store.fsys, hex.EncodeToString(key))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNodeNotFound
+		}
+		return nil, err
+	}
+	return verifyChecksum(b)
+}
+
+func (store *FileStore) Put(key, value []byte) error {
+	if err := store.checkWritableSchema(); err != nil {
+		return err
+	}
+	store.writes.Add(1)
+	return store.writeFile(hex.EncodeToString(key), appendChecksum(value))
+}
+
+// writeFile writes b to a temporary file and renames it to name, so that a
+// crash never leaves a partially written file behind.
+func (store *FileStore) writeFile(name string, b []byte) error {
+	f, err := os.CreateTemp(store.dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if store.sync {
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(f.Name(), filepath.Join(store.dir, name)); err != nil {
+		return err
+	}
+
+	return store.syncDir()
+}
+
+func (store *FileStore) Delete(key []byte) error {
+	if err := store.checkWritableSchema(); err != nil {
+		return err
+	}
+	store.deletes.Add(1)
+	if err := os.Remove(filepath.Join(store.dir, hex.EncodeToString(key))); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return store.syncDir()
+}
+
+func (store *FileStore) Iterate(f func(key, value []byte) error) error {
+	if err := store.checkSchema(); err != nil {
+		return err
+	}
+
+	entries, err := fs.ReadDir(store.fsys, ".")
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		key, err := hex.DecodeString(entry.Name())
+		if err != nil {
+			continue
+		}
+		value, err := store.Get(key)
+		if err != nil {
+			return err
+		}
+
+		if err := f(key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (store *FileStore) syncDir() error {
+	if !store.sync {
+		return nil
+	}
+
+	dir, err := os.Open(store.dir)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	return dir.Sync()
+}
@@ -0,0 +1,162 @@
+package merkle
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := NewFileStore(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tree, err := NewTree(sha256.New(), 3, map[uint64][]byte{
+		0: []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+	}, WithNodeStore(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Update(3, []byte{0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Update(5, []byte{0x05}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Delete(5); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadTree(sha256.New(), 3, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := newTestTree(t)
+	if !bytes.Equal(loaded.Root(), expected.Root()) {
+		t.Errorf("expected: %x, actual: %x", expected.Root(), loaded.Root())
+	}
+	for d := range loaded.levels {
+		if loaded.levels[d].len() != expected.levels[d].len() {
+			t.Errorf("expected: %d, actual: %d", expected.levels[d].len(), loaded.levels[d].len())
+		}
+	}
+
+	if _, err := store.Get(nodeKey(3, 5)); err != ErrNodeNotFound {
+		t.Errorf("expected: %v, actual: %v", ErrNodeNotFound, err)
+	}
+
+	path := filepath.Join(dir, hex.EncodeToString(nodeKey(3, 0)))
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b[0] ^= 0xff
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(nodeKey(3, 0)); err != ErrCorruptedNode {
+		t.Errorf("expected: %v, actual: %v", ErrCorruptedNode, err)
+	}
+	if _, err := LoadTree(sha256.New(), 3, store); err != ErrCorruptedNode {
+		t.Errorf("expected: %v, actual: %v", ErrCorruptedNode, err)
+	}
+}
+
+func TestFileStore_Migrate(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := NewFileStore(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.SchemaVersion() != fileStoreSchemaVersion {
+		t.Errorf("expected: %d, actual: %d", fileStoreSchemaVersion, store.SchemaVersion())
+	}
+
+	// the version is recorded with the first write, not on open
+	schemaPath := filepath.Join(dir, fileStoreSchemaName)
+	if _, err := os.Stat(schemaPath); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected: %v, actual: %v", fs.ErrNotExist, err)
+	}
+	if err := store.Put(nodeKey(3, 0), []byte{0x00}); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(schemaPath); err != nil || string(b) != strconv.Itoa(fileStoreSchemaVersion) {
+		t.Errorf("expected: %d, actual: %s (%v)", fileStoreSchemaVersion, b, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, fileStoreSchemaName), []byte("99"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFileStore(dir, false); err != ErrUnsupportedSchemaVersion {
+		t.Errorf("expected: %v, actual: %v", ErrUnsupportedSchemaVersion, err)
+	}
+
+	// a directory written before the layout was versioned
+	legacyDir := t.TempDir()
+	key := nodeKey(3, 0)
+	if err := os.WriteFile(filepath.Join(legacyDir, hex.EncodeToString(key)), appendChecksum([]byte{0x00}), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(version int, migrations []func(context.Context, *FileStore) error) {
+		fileStoreSchemaVersion, fileStoreMigrations = version, migrations
+	}(fileStoreSchemaVersion, fileStoreMigrations)
+
+	var migrated int
+	fileStoreSchemaVersion = 2
+	fileStoreMigrations = []func(context.Context, *FileStore) error{
+		func(ctx context.Context, store *FileStore) error {
+			migrated++
+			return nil
+		},
+	}
+
+	store, err = NewFileStore(legacyDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.SchemaVersion() != 1 {
+		t.Errorf("expected: %d, actual: %d", 1, store.SchemaVersion())
+	}
+	if _, err := os.Stat(filepath.Join(legacyDir, fileStoreSchemaName)); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected: %v, actual: %v", fs.ErrNotExist, err)
+	}
+	if _, err := store.Get(key); err != ErrOutdatedSchema {
+		t.Errorf("expected: %v, actual: %v", ErrOutdatedSchema, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := store.Migrate(ctx); err != context.Canceled {
+		t.Errorf("expected: %v, actual: %v", context.Canceled, err)
+	}
+
+	if err := store.Migrate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if migrated != 1 {
+		t.Errorf("expected: %d, actual: %d", 1, migrated)
+	}
+	if _, err := store.Get(key); err != nil {
+		t.Error(err)
+	}
+
+	reopened, err := NewFileStore(legacyDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.SchemaVersion() != 2 {
+		t.Errorf("expected: %d, actual: %d", 2, reopened.SchemaVersion())
+	}
+}
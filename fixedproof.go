@@ -0,0 +1,120 @@
+package merkle
+
+import (
+	"bytes"
+)
+
+// CreateFixedLengthProof returns the depth siblings on the path of the leaf
+// at index from the leaf level up, default nodes included, for consumers
+// such as circuits that need inputs of a constant size.
+func (tree *Tree) CreateFixedLengthProof(index uint64) ([][]byte, error) {
+	if index > tree.indexMax {
+		return nil, ErrTooLargeLeafIndex
+	}
+
+	siblings := make([][]byte, tree.depth)
+	for h := range siblings {
+		siblingNode := tree.siblingNode(index, uint64(h))
+		if siblingNode == nil {
+			siblingNode = tree.defaultNodes[tree.depth-uint64(h)]
+		}
+		siblings[h] = append([]byte(nil), siblingNode...)
+	}
+
+	return siblings, nil
+}
+
+func (tree *Tree) VerifyFixedLengthProof(index uint64, siblings [][]byte) (bool, error) {
+	if index > tree.indexMax {
+		return false, ErrTooLargeLeafIndex
+	}
+	if uint64(len(siblings)) != tree.depth {
+		return false, ErrInvalidProofSize
+	}
+	for _, siblingNode := range siblings {
+		if uint64(len(siblingNode)) != tree.hashSize {
+			return false, ErrInvalidProofSize
+		}
+	}
+
+	node, ok := tree.levels[tree.depth].get(index)
+	if !ok {
+		node = tree.defaultNodes[tree.depth]
+	}
+	root, err := tree.computeRoot(index, node, siblings)
+	if err != nil {
+		return false, err
+	}
+
+	return tree.equalRoots(root, tree.Root()), nil
+}
+
+const (
+	fixedProofDefaultRun byte = 0x00
+	fixedProofSibling    byte = 0x01
+)
+
+// CompressFixedLengthProof encodes the siblings of a fixed-length proof as a
+// sequence of tokens, each either 0x00 || n for a run of n default siblings
+// or 0x01 || sibling for any other one.
+func (tree *Tree) CompressFixedLengthProof(siblings [][]byte) ([]byte, error) {
+	if uint64(len(siblings)) != tree.depth {
+		return nil, ErrInvalidProofSize
+	}
+
+	var b []byte
+	for h := 0; h < len(siblings); {
+		if !bytes.Equal(siblings[h], tree.defaultNodes[tree.depth-uint64(h)]) {
+			if uint64(len(siblings[h])) != tree.hashSize {
+				return nil, ErrInvalidProofSize
+			}
+			b = append(b, fixedProofSibling)
+			b = append(b, siblings[h]...)
+			h++
+			continue
+		}
+
+		n := 0
+		for h+n < len(siblings) && bytes.Equal(siblings[h+n], tree.defaultNodes[tree.depth-uint64(h+n)]) {
+			n++
+		}
+		b = append(b, fixedProofDefaultRun, byte(n))
+		h += n
+	}
+
+	return b, nil
+}
+
+// DecompressFixedLengthProof decodes the output of CompressFixedLengthProof
+// back into depth siblings.
+func (tree *Tree) DecompressFixedLengthProof(b []byte) ([][]byte, error) {
+	siblings := make([][]byte, 0, tree.depth)
+	for len(b) > 0 {
+		switch b[0] {
+		case fixedProofDefaultRun:
+			if len(b) < 2 || b[1] == 0 || uint64(len(siblings))+uint64(b[1]) > tree.depth {
+				return nil, ErrInvalidProofSize
+			}
+			for i := byte(0); i < b[1]; i++ {
+				h := uint64(len(siblings))
+				siblings = append(siblings, append([]byte(nil), tree.defaultNodes[tree.depth-h]...))
+			}
+			b = b[2:]
+
+		case fixedProofSibling:
+			if uint64(len(b)) < 1+tree.hashSize || uint64(len(siblings)) == tree.depth {
+				return nil, ErrInvalidProofSize
+			}
+			siblings = append(siblings, append([]byte(nil), b[1:1+tree.hashSize]...))
+			b = b[1+tree.hashSize:]
+
+		default:
+			return nil, ErrInvalidProofSize
+		}
+	}
+	if uint64(len(siblings)) != tree.depth {
+		return nil, ErrInvalidProofSize
+	}
+
+	return siblings, nil
+}
@@ -0,0 +1,93 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestTree_CreateFixedLengthProof(t *testing.T) {
+	tree := newTestTree(t)
+
+	if _, err := tree.CreateFixedLengthProof(8); err != ErrTooLargeLeafIndex {
+		t.Errorf("expected: %v, actual: %v", ErrTooLargeLeafIndex, err)
+	}
+
+	siblings, err := tree.CreateFixedLengthProof(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][]byte{
+		tree.defaultNodes[3],
+		testNode(tree, 2, 0),
+		tree.defaultNodes[1],
+	}
+	if len(siblings) != len(expected) {
+		t.Fatalf("expected: %d, actual: %d", len(expected), len(siblings))
+	}
+	for h, siblingNode := range siblings {
+		if !bytes.Equal(siblingNode, expected[h]) {
+			t.Errorf("height %d: expected: %x, actual: %x", h, expected[h], siblingNode)
+		}
+	}
+
+	if ok, err := tree.VerifyFixedLengthProof(3, siblings); err != nil || !ok {
+		t.Errorf("expected: %t, actual: %t (%v)", true, ok, err)
+	}
+	if ok, err := tree.VerifyFixedLengthProof(2, siblings); err != nil || ok {
+		t.Errorf("expected: %t, actual: %t (%v)", false, ok, err)
+	}
+	if _, err := tree.VerifyFixedLengthProof(3, siblings[1:]); err != ErrInvalidProofSize {
+		t.Errorf("expected: %v, actual: %v", ErrInvalidProofSize, err)
+	}
+}
+
+func TestTree_CompressFixedLengthProof(t *testing.T) {
+	tree, err := NewTree(sha256.New(), 8, map[uint64][]byte{
+		0:   []byte{0x00},
+		3:   []byte{0x03},
+		255: []byte{0xff},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	siblings, err := tree.CreateFixedLengthProof(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := tree.CompressFixedLengthProof(siblings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a default run, a sibling, a default run of five and a sibling
+	if expected := 2 + 33 + 2 + 33; len(b) != expected {
+		t.Errorf("expected: %d, actual: %d", expected, len(b))
+	}
+
+	decompressed, err := tree.DecompressFixedLengthProof(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decompressed) != len(siblings) {
+		t.Fatalf("expected: %d, actual: %d", len(siblings), len(decompressed))
+	}
+	for h := range siblings {
+		if !bytes.Equal(decompressed[h], siblings[h]) {
+			t.Errorf("height %d: expected: %x, actual: %x", h, siblings[h], decompressed[h])
+		}
+	}
+
+	for _, invalid := range [][]byte{
+		b[:len(b)-1],
+		append(append([]byte(nil), b...), fixedProofDefaultRun, 1),
+		append([]byte{0x02}, b...),
+		{fixedProofDefaultRun, 0},
+	} {
+		if _, err := tree.DecompressFixedLengthProof(invalid); err != ErrInvalidProofSize {
+			t.Errorf("expected: %v, actual: %v", ErrInvalidProofSize, err)
+		}
+	}
+}
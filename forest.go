@@ -0,0 +1,252 @@
+package merkle
+
+import (
+	"bytes"
+	"errors"
+	"hash"
+	"sort"
+	"sync"
+)
+
+const (
+	TreeNameSizeMax = 255
+)
+
+var (
+	ErrInvalidTreeName = errors.New("invalid tree name")
+	ErrTreeExists      = errors.New("tree exists")
+	ErrTreeNotFound    = errors.New("tree not found")
+)
+
+// Forest manages named trees of the same hasher and depth, such as one tree
+// per epoch or per tenant, over a single node store. The keys of a tree are
+// prefixed with its name size (1 byte) and name, and the prefix alone marks
+// that the tree exists. The trees share the default nodes, computed once, and
+// whatever caching the store does, e.g. when it is a CachedStore.
+//
+// The methods of a Forest may be called concurrently, but each tree it
+// returns must be used the way a Tree is.
+type Forest struct {
+	newHasher    func() hash.Hash
+	depth        uint64
+	store        NodeStore
+	opts         []Option
+	defaultNodes [][]byte
+	mu           sync.Mutex
+	trees        map[string]*Tree
+}
+
+// NewForest returns a forest over store whose trees are built with opts,
+// which must not include WithNodeStore.
+func NewForest(newHasher func() hash.Hash, depth uint64, store NodeStore, opts ...Option) (*Forest, error) {
+	empty, err := NewTree(newHasher(), depth, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Forest{
+		newHasher:    newHasher,
+		depth:        depth,
+		store:        store,
+		opts:         opts,
+		defaultNodes: empty.defaultNodes,
+		trees:        map[string]*Tree{},
+	}, nil
+}
+
+// CreateTree creates the tree name from leaves and writes it to the store.
+func (forest *Forest) CreateTree(name string, leaves map[uint64][]byte) (*Tree, error) {
+	prefix, err := treePrefix(name)
+	if err != nil {
+		return nil, err
+	}
+
+	forest.mu.Lock()
+	defer forest.mu.Unlock()
+
+	return forest.createTree(name, prefix, leaves)
+}
+
+func (forest *Forest) createTree(name string, prefix []byte, leaves map[uint64][]byte) (*Tree, error) {
+	if ok, err := forest.exists(prefix); err != nil {
+		return nil, err
+	} else if ok {
+		return nil, ErrTreeExists
+	}
+
+	if err := forest.store.Put(prefix, nil); err != nil {
+		return nil, err
+	}
+	tree, err := NewTree(forest.newHasher(), forest.depth, leaves, forest.treeOpts(prefix)...)
+	if err != nil {
+		return nil, err
+	}
+	forest.trees[name] = tree
+
+	return tree, nil
+}
+
+// OpenTree returns the tree name, loading it from the store unless it is
+// already open. The tree of a frozen epoch is read-only.
+func (forest *Forest) OpenTree(name string) (*Tree, error) {
+	prefix, err := treePrefix(name)
+	if err != nil {
+		return nil, err
+	}
+
+	forest.mu.Lock()
+	defer forest.mu.Unlock()
+
+	return forest.openTree(name, prefix)
+}
+
+func (forest *Forest) openTree(name string, prefix []byte) (*Tree, error) {
+	if tree, ok := forest.trees[name]; ok {
+		return tree, nil
+	}
+
+	if ok, err := forest.exists(prefix); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, ErrTreeNotFound
+	}
+
+	tree, err := LoadTree(forest.newHasher(), forest.depth, &prefixedStore{forest.store, prefix}, forest.treeOpts(nil)...)
+	if err != nil {
+		return nil, err
+	}
+	if epoch, ok := parseEpochTreeName(name); ok {
+		current, _, err := forest.currentEpoch()
+		if err != nil {
+			return nil, err
+		}
+		tree.frozen = epoch < current
+	}
+	forest.trees[name] = tree
+
+	return tree, nil
+}
+
+// DeleteTree deletes the tree name and all of its nodes from the store. A
+// tree returned for name before must not be used afterwards.
+func (forest *Forest) DeleteTree(name string) error {
+	prefix, err := treePrefix(name)
+	if err != nil {
+		return err
+	}
+
+	forest.mu.Lock()
+	defer forest.mu.Unlock()
+
+	if ok, err := forest.exists(prefix); err != nil {
+		return err
+	} else if !ok {
+		return ErrTreeNotFound
+	}
+
+	var keys [][]byte
+	if err := forest.store.Iterate(func(key, value []byte) error {
+		if bytes.HasPrefix(key, prefix) {
+			keys = append(keys, append([]byte(nil), key...))
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	// the marker goes last, so that an interrupted deletion can be retried
+	sort.Slice(keys, func(i, j int) bool {
+		return len(keys[i]) > len(keys[j])
+	})
+	for _, key := range keys {
+		if err := forest.store.Delete(key); err != nil {
+			return err
+		}
+	}
+	delete(forest.trees, name)
+
+	return nil
+}
+
+// Names returns the names of the trees in the store in ascending order.
+func (forest *Forest) Names() ([]string, error) {
+	var names []string
+	if err := forest.store.Iterate(func(key, value []byte) error {
+		if len(key) > 0 && len(key) == 1+int(key[0]) {
+			names = append(names, string(key[1:]))
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (forest *Forest) exists(prefix []byte) (bool, error) {
+	if _, err := forest.store.Get(prefix); err != nil {
+		if errors.Is(err, ErrNodeNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// treeOpts returns the options of a tree of the forest, attached to the
+// store under prefix unless prefix is nil.
+func (forest *Forest) treeOpts(prefix []byte) []Option {
+	opts := append([]Option{withDefaultNodes(forest.defaultNodes)}, forest.opts...)
+	if prefix != nil {
+		opts = append(opts, WithNodeStore(&prefixedStore{forest.store, prefix}))
+	}
+	return opts
+}
+
+func treePrefix(name string) ([]byte, error) {
+	if len(name) == 0 || len(name) > TreeNameSizeMax {
+		return nil, ErrInvalidTreeName
+	}
+	return append([]byte{byte(len(name))}, name...), nil
+}
+
+// withDefaultNodes fills the default nodes of the tree from nodes, which
+// must be those of its hasher and depth, instead of computing them.
+func withDefaultNodes(nodes [][]byte) Option {
+	return func(tree *Tree) {
+		copy(tree.defaultNodes, nodes)
+	}
+}
+
+// prefixedStore is the part of a store whose keys start with prefix, with
+// the prefix stripped.
+type prefixedStore struct {
+	store  NodeStore
+	prefix []byte
+}
+
+func (store *prefixedStore) key(key []byte) []byte {
+	return append(store.prefix[:len(store.prefix):len(store.prefix)], key...)
+}
+
+func (store *prefixedStore) Get(key []byte) ([]byte, error) {
+	return store.store.Get(store.key(key))
+}
+
+func (store *prefixedStore) Put(key, value []byte) error {
+	return store.store.Put(store.key(key), value)
+}
+
+func (store *prefixedStore) Delete(key []byte) error {
+	return store.store.Delete(store.key(key))
+}
+
+// Iterate visits the keys under the prefix other than the prefix itself.
+func (store *prefixedStore) Iterate(f func(key, value []byte) error) error {
+	return store.store.Iterate(func(key, value []byte) error {
+		if len(key) <= len(store.prefix) || !bytes.HasPrefix(key, store.prefix) {
+			return nil
+		}
+		return f(key[len(store.prefix):], value)
+	})
+}
@@ -0,0 +1,118 @@
+package merkle
+
+import (
+	"crypto/sha256"
+	"reflect"
+	"testing"
+)
+
+func TestForest(t *testing.T) {
+	store, err := NewFileStore(t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forest, err := NewForest(sha256.New, 3, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := forest.CreateTree("", nil); err != ErrInvalidTreeName {
+		t.Errorf("expected: %v, actual: %v", ErrInvalidTreeName, err)
+	}
+
+	epoch1, err := forest.CreateTree("epoch-1", map[uint64][]byte{
+		0: []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		3: []byte{0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := forest.CreateTree("epoch-2", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := forest.CreateTree("epoch-1", nil); err != ErrTreeExists {
+		t.Errorf("expected: %v, actual: %v", ErrTreeExists, err)
+	}
+
+	expected := newTestTree(t)
+	if !epoch1.Root().Equal(expected.Root()) {
+		t.Errorf("expected: %x, actual: %x", expected.Root(), epoch1.Root())
+	}
+
+	// a forest reopened over the same store loads the trees from it
+	reopened, err := NewForest(sha256.New, 3, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, err := reopened.Names()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{"epoch-1", "epoch-2"}) {
+		t.Errorf("expected: %v, actual: %v", []string{"epoch-1", "epoch-2"}, names)
+	}
+
+	loaded, err := reopened.OpenTree("epoch-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Root().Equal(expected.Root()) {
+		t.Errorf("expected: %x, actual: %x", expected.Root(), loaded.Root())
+	}
+	epoch2, err := reopened.OpenTree("epoch-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if emptyRoot, _ := EmptyRoot(sha256.New(), 3); !epoch2.Root().Equal(emptyRoot) {
+		t.Errorf("expected: %x, actual: %x", emptyRoot, epoch2.Root())
+	}
+
+	if err := reopened.DeleteTree("epoch-1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reopened.OpenTree("epoch-1"); err != ErrTreeNotFound {
+		t.Errorf("expected: %v, actual: %v", ErrTreeNotFound, err)
+	}
+	if err := reopened.DeleteTree("epoch-1"); err != ErrTreeNotFound {
+		t.Errorf("expected: %v, actual: %v", ErrTreeNotFound, err)
+	}
+
+	var keys int
+	if err := store.Iterate(func(key, value []byte) error {
+		keys++
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if keys != 1 {
+		t.Errorf("expected: %d, actual: %d", 1, keys)
+	}
+}
+
+func TestForest_Names(t *testing.T) {
+	store, err := NewFileStore(t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forest, err := NewForest(sha256.New, 3, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the keys of the trees start with the name size, so "zz" comes first
+	// in the store
+	for _, name := range []string{"zz", "aaa"} {
+		if _, err := forest.CreateTree(name, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names, err := forest.Names()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{"aaa", "zz"}) {
+		t.Errorf("expected: %v, actual: %v", []string{"aaa", "zz"}, names)
+	}
+}
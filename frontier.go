@@ -0,0 +1,115 @@
+package merkle
+
+import (
+	"errors"
+	"hash"
+)
+
+var (
+	ErrFrontierFull  = errors.New("frontier full")
+	ErrEmptyFrontier = errors.New("empty frontier")
+)
+
+// Frontier is a tree filled from left to right, such as a deposit tree,
+// kept as its leaf count and the right frontier: for every height, the root
+// of the last complete subtree of that height that is a left child, and
+// the root once the tree is full. It
+// takes O(depth) memory however many leaves are appended, and gives the
+// same roots as a Tree holding the same leaves at indices 0, 1, 2, ...
+//
+// Only the last leaf appended can be proven, as the siblings of any other
+// leaf are gone.
+type Frontier struct {
+	tree   *Tree
+	count  uint64
+	branch [][]byte
+}
+
+// NewFrontier returns an empty frontier of a tree of the given hasher,
+// depth and opts, which apply as they would to a Tree.
+func NewFrontier(hasher hash.Hash, depth uint64, opts ...Option) (*Frontier, error) {
+	tree, err := NewTree(hasher, depth, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Frontier{
+		tree:   tree,
+		branch: make([][]byte, depth+1),
+	}, nil
+}
+
+func (f *Frontier) Count() uint64 {
+	return f.count
+}
+
+// Append writes leaf at the index following the last leaf.
+func (f *Frontier) Append(leaf []byte) error {
+	if f.count > f.tree.indexMax {
+		return ErrFrontierFull
+	}
+
+	node, err := f.tree.hashLeaf(leaf)
+	if err != nil {
+		return err
+	}
+
+	f.count++
+	size := f.count
+	for h := uint64(0); ; h++ {
+		if size&1 == 1 || h == f.tree.depth {
+			f.branch[h] = node
+			break
+		}
+		if node, err = f.tree.pairHash(h+1, f.branch[h], node); err != nil {
+			return err
+		}
+		size >>= 1
+	}
+
+	return nil
+}
+
+func (f *Frontier) Root() (Root, error) {
+	if f.count > f.tree.indexMax {
+		return f.branch[f.tree.depth], nil
+	}
+
+	node := f.tree.defaultNodes[f.tree.depth]
+
+	var err error
+	size := f.count
+	for h := uint64(0); h < f.tree.depth; h++ {
+		if size&1 == 1 {
+			node, err = f.tree.pairHash(h+1, f.branch[h], node)
+		} else {
+			node, err = f.tree.pairHash(h+1, node, f.tree.defaultNodes[f.tree.depth-h])
+		}
+		if err != nil {
+			return nil, err
+		}
+		size >>= 1
+	}
+
+	return node, nil
+}
+
+// CreateLastLeafProof returns the index of the last leaf appended and its
+// membership proof, the same as a Tree holding the same leaves would give.
+func (f *Frontier) CreateLastLeafProof() (uint64, []byte, error) {
+	if f.count == 0 {
+		return 0, nil, ErrEmptyFrontier
+	}
+
+	// the left siblings on the path of the last leaf are complete subtrees
+	// held by the frontier, and the right ones are empty
+	index := f.count - 1
+	siblings := make([][]byte, f.tree.depth)
+	for h := range siblings {
+		if index>>uint(h)&1 == 1 {
+			siblings[h] = f.branch[h]
+		}
+	}
+
+	return index, encodeProof(siblings), nil
+}
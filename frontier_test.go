@@ -0,0 +1,65 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestFrontier(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"plain", nil},
+		{"level tweak", []Option{WithLevelTweak()}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := NewFrontier(sha256.New(), 4, tc.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tree, err := NewTree(sha256.New(), 4, nil, tc.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, _, err := f.CreateLastLeafProof(); err != ErrEmptyFrontier {
+				t.Errorf("expected: %v, actual: %v", ErrEmptyFrontier, err)
+			}
+			if root, err := f.Root(); err != nil || !root.Equal(tree.Root()) {
+				t.Errorf("expected: %x, actual: %x (%v)", tree.Root(), root, err)
+			}
+
+			for i := uint64(0); i < 16; i++ {
+				leaf := []byte{byte(i)}
+				if err := f.Append(leaf); err != nil {
+					t.Fatal(err)
+				}
+				if err := tree.Update(i, leaf); err != nil {
+					t.Fatal(err)
+				}
+
+				if root, err := f.Root(); err != nil || !root.Equal(tree.Root()) {
+					t.Errorf("%d: expected: %x, actual: %x (%v)", i, tree.Root(), root, err)
+				}
+
+				index, proof, err := f.CreateLastLeafProof()
+				if err != nil {
+					t.Fatal(err)
+				}
+				expected, err := tree.CreateMembershipProof(i)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if index != i || !bytes.Equal(proof, expected) {
+					t.Errorf("%d: expected: %d %x, actual: %d %x", i, i, expected, index, proof)
+				}
+			}
+
+			if err := f.Append([]byte{0x10}); err != ErrFrontierFull {
+				t.Errorf("expected: %v, actual: %v", ErrFrontierFull, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package merkle
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+)
+
+// EVM gas schedule (EIP-2028, EIP-2929, yellow paper)
+const (
+	gasTransaction   uint64 = 21000
+	gasTxDataZero    uint64 = 4
+	gasTxDataNonZero uint64 = 16
+	gasKeccak256     uint64 = 30
+	gasKeccak256Word uint64 = 6
+	gasSHA256        uint64 = 60
+	gasSHA256Word    uint64 = 12
+	gasWarmAccess    uint64 = 100
+
+	evmSelectorSize = 4
+
+	// the digests of the empty input, which tell the hashers a verifier can
+	// run natively apart
+	keccak256EmptyDigestHex = "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470"
+	sha256EmptyDigestHex    = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+type GasEstimate struct {
+	Transaction uint64
+	Calldata    uint64
+	Hashing     uint64
+}
+
+func (estimate GasEstimate) Total() uint64 {
+	return estimate.Transaction + estimate.Calldata + estimate.Hashing
+}
+
+// EstimateVerificationGas estimates the gas of a transaction calling
+// verify(bytes32 root, bytes32 leaf, uint256 index, uint256 bitmap,
+// bytes32[] siblings) on an on-chain verifier with the proof of the leaf at
+// index: the base cost of the transaction, the calldata, i.e. the selector,
+// the root, leaf and index words and the proof encoded by EncodeEVMProof,
+// and one hash over each sibling pair from the leaf up to the root. The
+// root and the leaf node are priced as words of non-zero bytes, as digests
+// almost always are.
+//
+// The hashes are priced as keccak256 or as calls to the SHA-256 precompile
+// depending on the hasher of the tree; any other hasher, which a verifier
+// could not run natively, fails with ErrUnknownHasher, and nodes of other
+// than 32 bytes with ErrUnsupportedHashSize.
+func (tree *Tree) EstimateVerificationGas(index uint64, proof []byte) (GasEstimate, error) {
+	if index > tree.indexMax {
+		return GasEstimate{}, ErrTooLargeLeafIndex
+	}
+	if err := tree.SanitizeProof(proof); err != nil {
+		return GasEstimate{}, err
+	}
+	b, err := EncodeEVMProof(proof)
+	if err != nil {
+		return GasEstimate{}, err
+	}
+
+	hasher := tree.getHasher()
+	hasher.Reset()
+	emptyDigest := hasher.Sum(nil)
+	tree.putHasher(hasher)
+
+	// the input of a pair hash, with the height in front under the level
+	// tweak
+	inputSize := 2 * tree.hashSize
+	if tree.levelTweak {
+		inputSize++
+	}
+	words := (inputSize + evmWordSize - 1) / evmWordSize
+
+	var hashGas uint64
+	switch hex.EncodeToString(emptyDigest) {
+	case keccak256EmptyDigestHex:
+		hashGas = gasKeccak256 + gasKeccak256Word*words
+	case sha256EmptyDigestHex:
+		hashGas = gasWarmAccess + gasSHA256 + gasSHA256Word*words
+	default:
+		return GasEstimate{}, ErrUnknownHasher
+	}
+
+	indexWord := make([]byte, evmWordSize)
+	binary.BigEndian.PutUint64(indexWord[evmWordSize-8:], index)
+
+	estimate := GasEstimate{
+		Transaction: gasTransaction,
+		Calldata:    (evmSelectorSize + 2*evmWordSize) * gasTxDataNonZero,
+		Hashing:     tree.depth * hashGas,
+	}
+	for _, b := range append(indexWord, b...) {
+		if b == 0 {
+			estimate.Calldata += gasTxDataZero
+		} else {
+			estimate.Calldata += gasTxDataNonZero
+		}
+	}
+
+	return estimate, nil
+}
@@ -0,0 +1,144 @@
+package merkle
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"testing"
+)
+
+func TestTree_EstimateVerificationGas(t *testing.T) {
+	newTree := func(hasher hash.Hash, opts ...Option) *Tree {
+		tree, err := NewTree(hasher, 3, nil, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tree
+	}
+
+	type input struct {
+		index    uint64
+		proofHex string
+	}
+	type output struct {
+		estimate GasEstimate
+		err      error
+	}
+	testCases := []struct {
+		name string
+		tree *Tree
+		in   input
+		out  output
+	}{
+		{
+			"failure: too large leaf index",
+			newTestTree(t),
+			input{
+				8,
+				"0000000000000002" +
+					"de1c789a456bfc1c1aac18062f751ebc10dc3b358bdfe2f47c8fc76a84ec8cdf",
+			},
+			output{
+				GasEstimate{},
+				ErrTooLargeLeafIndex,
+			},
+		},
+		{
+			"failure: invalid proof size",
+			newTestTree(t),
+			input{
+				0,
+				"0000000000000002" +
+					"de1c789a456bfc1c1aac18062f751ebc10dc3b358bdfe2f47c8fc76a84ec8c",
+			},
+			output{
+				GasEstimate{},
+				ErrInvalidProofSize,
+			},
+		},
+		{
+			"failure: unsupported hash size",
+			newTree(sha1.New()),
+			input{
+				0,
+				"0000000000000002" +
+					"de1c789a456bfc1c1aac18062f751ebc10dc3b35",
+			},
+			output{
+				GasEstimate{},
+				ErrUnsupportedHashSize,
+			},
+		},
+		{
+			"failure: unknown hasher",
+			newTree(sha512.New512_256()),
+			input{
+				0,
+				"0000000000000002" +
+					"de1c789a456bfc1c1aac18062f751ebc10dc3b358bdfe2f47c8fc76a84ec8cdf",
+			},
+			output{
+				GasEstimate{},
+				ErrUnknownHasher,
+			},
+		},
+		{
+			"success: sha256",
+			newTestTree(t),
+			input{
+				0,
+				"0000000000000002" +
+					"de1c789a456bfc1c1aac18062f751ebc10dc3b358bdfe2f47c8fc76a84ec8cdf",
+			},
+			output{
+				GasEstimate{
+					Transaction: 21000,
+					// selector, root, leaf, index, bitmap, offset, length, sibling
+					Calldata: 4*16 + 32*16 + 32*16 + 32*4 + (31*4 + 16) + (31*4 + 16) + (31*4 + 16) + 32*16,
+					Hashing:  3 * (100 + 60 + 12*2),
+				},
+				nil,
+			},
+		},
+		{
+			"success: sha256 with level tweak",
+			newTree(sha256.New(), WithLevelTweak()),
+			input{
+				3,
+				"0000000000000002" +
+					"de1c789a456bfc1c1aac18062f751ebc10dc3b358bdfe2f47c8fc76a84ec8cdf",
+			},
+			output{
+				GasEstimate{
+					Transaction: 21000,
+					Calldata:    4*16 + 32*16 + 32*16 + (31*4 + 16) + (31*4 + 16) + (31*4 + 16) + (31*4 + 16) + 32*16,
+					Hashing:     3 * (100 + 60 + 12*3),
+				},
+				nil,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tree, in, out := tc.tree, tc.in, tc.out
+
+			proof, err := hex.DecodeString(in.proofHex)
+			if err != nil {
+				t.Fatal(err)
+			}
+			estimate, err := tree.EstimateVerificationGas(in.index, proof)
+			if !errors.Is(err, out.err) {
+				t.Errorf("expected: %v, actual: %v", out.err, err)
+			}
+			if err == nil {
+				if estimate != out.estimate {
+					t.Errorf("expected: %+v, actual: %+v", out.estimate, estimate)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package merkle
+
+import (
+	"errors"
+	"math/bits"
+)
+
+var (
+	ErrInvalidGeneralizedIndex = errors.New("invalid generalized index")
+)
+
+// GeneralizedIndex returns the SSZ generalized index of the node at (depth,
+// index), i.e. 2^depth + index, where the root is 1. Nodes at depth 64 are
+// not addressable.
+func GeneralizedIndex(depth, index uint64) (uint64, error) {
+	if depth >= DepthMax || index>>depth != 0 {
+		return 0, ErrInvalidGeneralizedIndex
+	}
+	return 1<<depth | index, nil
+}
+
+// nodeOfGeneralizedIndex returns the depth and index of the node addressed
+// by gindex.
+func (tree *Tree) nodeOfGeneralizedIndex(gindex uint64) (uint64, uint64, error) {
+	if gindex == 0 {
+		return 0, 0, ErrInvalidGeneralizedIndex
+	}
+	d := uint64(bits.Len64(gindex)) - 1
+	if d > tree.depth {
+		return 0, 0, ErrInvalidGeneralizedIndex
+	}
+	return d, gindex &^ (1 << d), nil
+}
+
+// leafRangeOfGeneralizedIndex returns the first and last leaves under the
+// node addressed by gindex.
+func (tree *Tree) leafRangeOfGeneralizedIndex(gindex uint64) (uint64, uint64, error) {
+	d, index, err := tree.nodeOfGeneralizedIndex(gindex)
+	if err != nil {
+		return 0, 0, err
+	}
+	h := tree.depth - d
+	return index << h, index<<h | (1<<h - 1), nil
+}
+
+// CreateGeneralizedIndexProof returns the node addressed by gindex, leaf or
+// internal, and a proof linking it to the root of the tree.
+func (tree *Tree) CreateGeneralizedIndexProof(gindex uint64) ([]byte, []byte, error) {
+	start, end, err := tree.leafRangeOfGeneralizedIndex(gindex)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tree.RangeRoot(start, end)
+}
+
+func (tree *Tree) VerifyGeneralizedIndexProof(gindex uint64, node []byte, proof []byte) (bool, error) {
+	start, end, err := tree.leafRangeOfGeneralizedIndex(gindex)
+	if err != nil {
+		return false, err
+	}
+	return tree.VerifyRangeRoot(start, end, node, proof)
+}
@@ -0,0 +1,70 @@
+package merkle
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGeneralizedIndex(t *testing.T) {
+	testCases := []struct {
+		depth  uint64
+		index  uint64
+		gindex uint64
+		err    error
+	}{
+		{0, 0, 1, nil},
+		{1, 1, 3, nil},
+		{3, 5, 13, nil},
+		{3, 8, 0, ErrInvalidGeneralizedIndex},
+		{64, 0, 0, ErrInvalidGeneralizedIndex},
+	}
+
+	for _, tc := range testCases {
+		gindex, err := GeneralizedIndex(tc.depth, tc.index)
+		if err != tc.err {
+			t.Errorf("expected: %v, actual: %v", tc.err, err)
+		}
+		if gindex != tc.gindex {
+			t.Errorf("expected: %d, actual: %d", tc.gindex, gindex)
+		}
+	}
+}
+
+func TestTree_CreateGeneralizedIndexProof(t *testing.T) {
+	tree := newTestTree(t)
+
+	for _, gindex := range []uint64{0, 16} {
+		if _, _, err := tree.CreateGeneralizedIndexProof(gindex); err != ErrInvalidGeneralizedIndex {
+			t.Errorf("expected: %v, actual: %v", ErrInvalidGeneralizedIndex, err)
+		}
+	}
+
+	testCases := []struct {
+		gindex uint64
+		node   []byte
+	}{
+		{1, tree.Root()},
+		{2, testNode(tree, 1, 0)},
+		{5, testNode(tree, 2, 1)},
+		{11, testNode(tree, 3, 3)},
+		{15, tree.defaultNodes[3]},
+	}
+
+	for _, tc := range testCases {
+		node, proof, err := tree.CreateGeneralizedIndexProof(tc.gindex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(node, tc.node) {
+			t.Errorf("gindex %d: expected: %x, actual: %x", tc.gindex, tc.node, node)
+		}
+
+		ok, err := tree.VerifyGeneralizedIndexProof(tc.gindex, node, proof)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			t.Errorf("gindex %d: expected: %t, actual: %t", tc.gindex, true, ok)
+		}
+	}
+}
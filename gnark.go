@@ -0,0 +1,107 @@
+package merkle
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	ErrInvalidFieldPacking = errors.New("invalid field packing")
+	ErrFieldOverflow       = errors.New("field overflow")
+)
+
+var (
+	// BN254Packing packs nodes into 31 byte big-endian chunks, which always
+	// fit in the scalar field of BN254 used by gnark.
+	BN254Packing = FieldPacking{
+		ChunkSize: 31,
+		Modulus:   bn254ScalarField(),
+	}
+)
+
+// FieldPacking splits a node into big-endian chunks of ChunkSize bytes, the
+// first one taking the remainder, each of which must be below Modulus when
+// it is set.
+type FieldPacking struct {
+	ChunkSize int
+	Modulus   *big.Int
+}
+
+// GnarkWitness holds the public and secret inputs of a membership circuit
+// in the shape of a gnark witness assignment: nodes as field elements, and
+// the path as one bit per level from the leaf up, set when the node is the
+// right child.
+type GnarkWitness struct {
+	Root     []*big.Int
+	Leaf     []*big.Int
+	PathBits []bool
+	Siblings [][]*big.Int
+}
+
+func (tree *Tree) ProofToGnarkWitness(index uint64, proof []byte, packing FieldPacking) (*GnarkWitness, error) {
+	if index > tree.indexMax {
+		return nil, ErrTooLargeLeafIndex
+	}
+	if err := tree.SanitizeProof(proof); err != nil {
+		return nil, err
+	}
+	if packing.ChunkSize <= 0 {
+		return nil, ErrInvalidFieldPacking
+	}
+
+	siblings, err := tree.decodeProof(proof)
+	if err != nil {
+		return nil, err
+	}
+
+	leafNode, ok := tree.levels[tree.depth].get(index)
+	if !ok {
+		leafNode = tree.defaultNodes[tree.depth]
+	}
+
+	witness := &GnarkWitness{
+		PathBits: make([]bool, tree.depth),
+		Siblings: make([][]*big.Int, tree.depth),
+	}
+	if witness.Root, err = packing.pack(tree.Root()); err != nil {
+		return nil, err
+	}
+	if witness.Leaf, err = packing.pack(leafNode); err != nil {
+		return nil, err
+	}
+
+	for h, siblingNode := range siblings {
+		if siblingNode == nil {
+			siblingNode = tree.defaultNodes[tree.depth-uint64(h)]
+		}
+		if witness.Siblings[h], err = packing.pack(siblingNode); err != nil {
+			return nil, err
+		}
+		witness.PathBits[h] = (index>>uint(h))&1 == 1
+	}
+
+	return witness, nil
+}
+
+func (packing FieldPacking) pack(node []byte) ([]*big.Int, error) {
+	var elements []*big.Int
+
+	first := len(node) % packing.ChunkSize
+	if first == 0 {
+		first = packing.ChunkSize
+	}
+	for i, j := 0, first; i < len(node); i, j = j, j+packing.ChunkSize {
+		element := new(big.Int).SetBytes(node[i:j])
+		if packing.Modulus != nil && element.Cmp(packing.Modulus) >= 0 {
+			return nil, ErrFieldOverflow
+		}
+		elements = append(elements, element)
+	}
+
+	return elements, nil
+}
+
+func bn254ScalarField() *big.Int {
+	modulus, _ := new(big.Int).SetString("21888242871839275222246405745257275088548364400416034343698204186575808495617", 10)
+	return modulus
+}
@@ -0,0 +1,60 @@
+package merkle
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func TestTree_ProofToGnarkWitness(t *testing.T) {
+	tree := newTestTree(t)
+
+	proof, err := tree.CreateMembershipProof(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tree.ProofToGnarkWitness(1, proof, FieldPacking{}); err != ErrInvalidFieldPacking {
+		t.Errorf("expected: %v, actual: %v", ErrInvalidFieldPacking, err)
+	}
+	if _, err := tree.ProofToGnarkWitness(1, proof, FieldPacking{32, big.NewInt(2)}); err != ErrFieldOverflow {
+		t.Errorf("expected: %v, actual: %v", ErrFieldOverflow, err)
+	}
+
+	witness, err := tree.ProofToGnarkWitness(1, proof, BN254Packing)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unpack := func(elements []*big.Int) []byte {
+		if len(elements) != 2 {
+			t.Fatalf("expected: %d, actual: %d", 2, len(elements))
+		}
+		return append(elements[0].FillBytes(make([]byte, 1)), elements[1].FillBytes(make([]byte, 31))...)
+	}
+
+	if root := unpack(witness.Root); !bytes.Equal(root, tree.Root()) {
+		t.Errorf("expected: %x, actual: %x", tree.Root(), root)
+	}
+	if leaf := unpack(witness.Leaf); !bytes.Equal(leaf, tree.defaultNodes[tree.depth]) {
+		t.Errorf("expected: %x, actual: %x", tree.defaultNodes[tree.depth], leaf)
+	}
+
+	expectedBits := []bool{true, false, false}
+	for h := range expectedBits {
+		if witness.PathBits[h] != expectedBits[h] {
+			t.Errorf("height %d: expected: %t, actual: %t", h, expectedBits[h], witness.PathBits[h])
+		}
+	}
+
+	siblings, err := tree.decodeProof(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedSiblings := [][]byte{siblings[0], siblings[1], tree.defaultNodes[1]}
+	for h := range expectedSiblings {
+		if sibling := unpack(witness.Siblings[h]); !bytes.Equal(sibling, expectedSiblings[h]) {
+			t.Errorf("height %d: expected: %x, actual: %x", h, expectedSiblings[h], sibling)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !go1.25
+
+package merkle
+
+// The package requires Go 1.25 or later: Copy and Snapshot rely on
+// hash.Cloner (Go 1.25), and HKDFKeyMapper on crypto/hkdf (Go 1.24). This
+// declaration makes older toolchains fail with a message saying so.
+var _ = requires_go1_25_or_later
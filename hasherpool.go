@@ -0,0 +1,29 @@
+package merkle
+
+import (
+	"hash"
+	"sync"
+)
+
+func newHasherPool(newHasher func() hash.Hash) *sync.Pool {
+	return &sync.Pool{
+		New: func() any {
+			return newHasher()
+		},
+	}
+}
+
+// getHasher returns a hasher from the pool of the tree if it has one, and
+// the hasher of the tree otherwise. It must be handed back with putHasher.
+func (tree *Tree) getHasher() hash.Hash {
+	if tree.hasherPool != nil {
+		return tree.hasherPool.Get().(hash.Hash)
+	}
+	return tree.hasher
+}
+
+func (tree *Tree) putHasher(hasher hash.Hash) {
+	if tree.hasherPool != nil {
+		tree.hasherPool.Put(hasher)
+	}
+}
@@ -0,0 +1,65 @@
+package merkle
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"hash"
+	"sync"
+)
+
+var (
+	ErrUnknownHasher = errors.New("unknown hasher")
+)
+
+var (
+	hashersMu sync.RWMutex
+	hashers   = map[string]func() hash.Hash{
+		"sha1":       sha1.New,
+		"sha256":     sha256.New,
+		"sha512":     sha512.New,
+		"sha512/256": sha512.New512_256,
+	}
+)
+
+// RegisterHasher makes a hasher available to NewTreeByName under name,
+// replacing any hasher registered under the same name.
+func RegisterHasher(name string, newHasher func() hash.Hash) {
+	hashersMu.Lock()
+	defer hashersMu.Unlock()
+
+	hashers[name] = newHasher
+}
+
+func LookupHasher(name string) (func() hash.Hash, error) {
+	hashersMu.RLock()
+	defer hashersMu.RUnlock()
+
+	newHasher, ok := hashers[name]
+	if !ok {
+		return nil, ErrUnknownHasher
+	}
+	return newHasher, nil
+}
+
+func NewTreeByName(hasherName string, depth uint64, leaves map[uint64][]byte, opts ...Option) (*Tree, error) {
+	newHasher, err := LookupHasher(hasherName)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := NewTree(newHasher(), depth, leaves, opts...)
+	if err != nil {
+		return nil, err
+	}
+	tree.hasherName = hasherName
+
+	return tree, nil
+}
+
+// HasherName returns the name the tree was constructed with by
+// NewTreeByName, or an empty string.
+func (tree *Tree) HasherName() string {
+	return tree.hasherName
+}
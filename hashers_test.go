@@ -0,0 +1,38 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/md5"
+	"testing"
+)
+
+func TestNewTreeByName(t *testing.T) {
+	if _, err := NewTreeByName("md5", 3, nil); err != ErrUnknownHasher {
+		t.Errorf("expected: %v, actual: %v", ErrUnknownHasher, err)
+	}
+
+	RegisterHasher("md5", md5.New)
+
+	tree, err := NewTreeByName("md5", 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.HasherName() != "md5" {
+		t.Errorf("expected: %s, actual: %s", "md5", tree.HasherName())
+	}
+	if tree.hashSize != md5.Size {
+		t.Errorf("expected: %d, actual: %d", md5.Size, tree.hashSize)
+	}
+
+	tree, err = NewTreeByName("sha256", 3, map[uint64][]byte{
+		0: []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		3: []byte{0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := newTestTree(t)
+	if !bytes.Equal(tree.Root(), expected.Root()) {
+		t.Errorf("expected: %x, actual: %x", expected.Root(), tree.Root())
+	}
+}
@@ -0,0 +1,138 @@
+package merkle
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+type EventOp byte
+
+const (
+	EventOpUpdate EventOp = EventOp(journalOpUpdate)
+	EventOpDelete EventOp = EventOp(journalOpDelete)
+)
+
+var (
+	ErrInvalidEventOp = errors.New("invalid event op")
+)
+
+// Event is a leaf write read from an external log, such as a Kafka topic or
+// a chain indexer, at Offset.
+type Event struct {
+	Offset uint64
+	Op     EventOp
+	Index  uint64
+	Leaf   []byte
+}
+
+// Checkpoint ties the offset of the last event applied to the root
+// committed right after it.
+type Checkpoint struct {
+	Offset uint64
+	Root   Root
+}
+
+// Ingester applies events to an AtomicTree in order, committing every so
+// many events, so that readers of the tree only ever see roots at
+// checkpoints.
+type Ingester struct {
+	tree        *AtomicTree
+	commitEvery int
+
+	mu         sync.Mutex
+	checkpoint Checkpoint
+	applied    bool
+}
+
+// NewIngester returns an ingester committing tree every commitEvery events.
+// Events up to after, inclusive, are considered applied already, as when
+// resuming from a stored checkpoint.
+func NewIngester(tree *AtomicTree, commitEvery int, after Checkpoint) *Ingester {
+	return &Ingester{
+		tree:        tree,
+		commitEvery: commitEvery,
+		checkpoint:  after,
+		applied:     after.Root != nil,
+	}
+}
+
+// Run applies the events received from events until it is closed or ctx is
+// done, and commits the events applied so far before returning. Events at
+// or before the last applied offset are skipped, so that a log delivering
+// at least once can be replayed from an earlier offset.
+func (ing *Ingester) Run(ctx context.Context, events <-chan Event) (err error) {
+	var (
+		pending    int
+		lastOffset = ing.checkpoint.Offset
+		applied    = ing.applied
+	)
+
+	commit := func() error {
+		if pending == 0 {
+			return nil
+		}
+		if err := ing.tree.Commit(); err != nil {
+			pending = 0
+			return err
+		}
+		ing.mu.Lock()
+		ing.checkpoint = Checkpoint{
+			Offset: lastOffset,
+			Root:   ing.tree.Root(),
+		}
+		ing.applied = true
+		ing.mu.Unlock()
+		pending = 0
+		return nil
+	}
+	defer func() {
+		if cerr := commit(); err == nil {
+			err = cerr
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if applied && event.Offset <= lastOffset {
+				continue
+			}
+
+			var err error
+			switch event.Op {
+			case EventOpUpdate:
+				err = ing.tree.Update(event.Index, event.Leaf)
+			case EventOpDelete:
+				err = ing.tree.Delete(event.Index)
+			default:
+				err = ErrInvalidEventOp
+			}
+			if err != nil {
+				return err
+			}
+
+			lastOffset, applied = event.Offset, true
+			if pending++; pending >= ing.commitEvery {
+				if err := commit(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// Checkpoint returns the last checkpoint, and false if nothing has been
+// applied yet.
+func (ing *Ingester) Checkpoint() (Checkpoint, bool) {
+	ing.mu.Lock()
+	defer ing.mu.Unlock()
+
+	return ing.checkpoint, ing.applied
+}
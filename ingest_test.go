@@ -0,0 +1,58 @@
+package merkle
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestIngester(t *testing.T) {
+	atree, err := NewAtomicTree(sha256.New, 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ing := NewIngester(atree, 2, Checkpoint{})
+	if _, ok := ing.Checkpoint(); ok {
+		t.Errorf("expected: %t, actual: %t", false, ok)
+	}
+
+	events := make(chan Event, 8)
+	events <- Event{Offset: 10, Op: EventOpUpdate, Index: 0, Leaf: []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}}
+	events <- Event{Offset: 11, Op: EventOpUpdate, Index: 5, Leaf: []byte{0x05}}
+	events <- Event{Offset: 12, Op: EventOpUpdate, Index: 3, Leaf: []byte{0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03}}
+	events <- Event{Offset: 11, Op: EventOpUpdate, Index: 6, Leaf: []byte{0x06}}
+	events <- Event{Offset: 13, Op: EventOpDelete, Index: 5}
+	close(events)
+
+	if err := ing.Run(context.Background(), events); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := newTestTree(t)
+	checkpoint, ok := ing.Checkpoint()
+	if !ok {
+		t.Fatalf("expected: %t, actual: %t", true, ok)
+	}
+	if checkpoint.Offset != 13 {
+		t.Errorf("expected: %d, actual: %d", 13, checkpoint.Offset)
+	}
+	if !checkpoint.Root.Equal(expected.Root()) || !atree.Root().Equal(expected.Root()) {
+		t.Errorf("expected: %x, actual: %x", expected.Root(), checkpoint.Root)
+	}
+
+	// resuming from the checkpoint skips the events replayed before it
+	resumed := NewIngester(atree, 2, checkpoint)
+
+	events = make(chan Event, 2)
+	events <- Event{Offset: 13, Op: EventOpUpdate, Index: 5, Leaf: []byte{0x05}}
+	events <- Event{Offset: 14, Op: EventOp(0xff)}
+	close(events)
+
+	if err := resumed.Run(context.Background(), events); err != ErrInvalidEventOp {
+		t.Errorf("expected: %v, actual: %v", ErrInvalidEventOp, err)
+	}
+	if !atree.Root().Equal(expected.Root()) {
+		t.Errorf("expected: %x, actual: %x", expected.Root(), atree.Root())
+	}
+}
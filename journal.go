@@ -0,0 +1,144 @@
+package merkle
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+const (
+	journalOpUpdate       byte = 0x01
+	journalOpDelete       byte = 0x02
+	journalOpSaltedUpdate byte = 0x03
+	journalOpNode         byte = 0x04
+	journalOpSaltedNode   byte = 0x05
+)
+
+var (
+	ErrJournalDisabled  = errors.New("journal disabled")
+	ErrInvalidJournalOp = errors.New("invalid journal op")
+)
+
+type journalEntry struct {
+	index uint64
+	leaf  []byte
+	salt  []byte
+	op    byte
+}
+
+type journal struct {
+	entries []journalEntry
+}
+
+// record records a leaf write. An update salted with salt is recorded as a
+// salted update, so that replaying it gives the same leaf node. A write of a
+// leaf node records the node in place of the leaf.
+func (j *journal) record(op byte, index uint64, leaf, salt []byte) {
+	if salt != nil {
+		switch op {
+		case journalOpUpdate:
+			op = journalOpSaltedUpdate
+		case journalOpNode:
+			op = journalOpSaltedNode
+		}
+	}
+	j.entries = append(j.entries, journalEntry{
+		index: index,
+		leaf:  leaf,
+		salt:  salt,
+		op:    op,
+	})
+}
+
+// ExportJournal writes the recorded leaf writes in order, each encoded as
+// op (1 byte) || index (8 bytes) || leaf size (4 bytes) || leaf, followed by
+// the salt (SaltSize bytes) for the updates of a tree with salted leaves.
+// Writes made by leaf node, such as those of Sync, ApplyPatch and the
+// rollback of an AtomicTree, carry the leaf node in place of the leaf, empty
+// for a removal.
+func (tree *Tree) ExportJournal(w io.Writer) error {
+	if tree.journal == nil {
+		return ErrJournalDisabled
+	}
+
+	bw := bufio.NewWriter(w)
+
+	head := make([]byte, 13)
+	for _, entry := range tree.journal.entries {
+		head[0] = entry.op
+		binary.BigEndian.PutUint64(head[1:9], entry.index)
+		binary.BigEndian.PutUint32(head[9:13], uint32(len(entry.leaf)))
+
+		if _, err := bw.Write(head); err != nil {
+			return err
+		}
+		if _, err := bw.Write(entry.leaf); err != nil {
+			return err
+		}
+		if _, err := bw.Write(entry.salt); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// ReplayJournal applies the leaf writes of a journal written by
+// ExportJournal. Salted writes can only be replayed to a tree with salted
+// leaves, and are salted with their recorded salts.
+func (tree *Tree) ReplayJournal(r io.Reader) error {
+	br := bufio.NewReader(r)
+
+	head := make([]byte, 13)
+	for {
+		if _, err := io.ReadFull(br, head); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		index := binary.BigEndian.Uint64(head[1:9])
+
+		switch head[0] {
+		case journalOpUpdate, journalOpSaltedUpdate, journalOpNode, journalOpSaltedNode:
+			// the leaf size is not trusted: the leaf is read as far as the
+			// journal goes, not allocated upfront
+			size := binary.BigEndian.Uint32(head[9:13])
+			leaf, err := io.ReadAll(io.LimitReader(br, int64(size)))
+			if err != nil {
+				return err
+			}
+			if len(leaf) != int(size) {
+				return io.ErrUnexpectedEOF
+			}
+			var salt []byte
+			if head[0] == journalOpSaltedUpdate || head[0] == journalOpSaltedNode {
+				salt = make([]byte, SaltSize)
+				if _, err := io.ReadFull(br, salt); err != nil {
+					return err
+				}
+			}
+			if head[0] == journalOpNode || head[0] == journalOpSaltedNode {
+				if len(leaf) == 0 {
+					leaf = nil
+				}
+				err = tree.writeLeafNode(index, leaf, salt)
+			} else {
+				err = tree.update(index, leaf, salt)
+			}
+			if err != nil {
+				return err
+			}
+
+		case journalOpDelete:
+			if err := tree.Delete(index); err != nil {
+				return err
+			}
+
+		default:
+			return ErrInvalidJournalOp
+		}
+	}
+}
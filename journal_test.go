@@ -0,0 +1,148 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"testing"
+)
+
+func TestTree_ExportJournal(t *testing.T) {
+	tree, err := NewTree(sha256.New(), 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.ExportJournal(new(bytes.Buffer)); err != ErrJournalDisabled {
+		t.Errorf("expected: %v, actual: %v", ErrJournalDisabled, err)
+	}
+
+	tree, err = NewTree(sha256.New(), 3, map[uint64][]byte{
+		0: []byte{0x00},
+		3: []byte{0x03},
+	}, WithJournal())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Update(5, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Delete(0); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := tree.ExportJournal(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	replayed, err := NewTree(sha256.New(), 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := replayed.ReplayJournal(buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(replayed.Root(), tree.Root()) {
+		t.Errorf("expected: %x, actual: %x", tree.Root(), replayed.Root())
+	}
+	if !replayed.HasLeaf(5) || replayed.HasLeaf(0) {
+		t.Errorf("expected: %t, actual: %t", true, false)
+	}
+}
+
+func TestTree_ExportJournal_sync(t *testing.T) {
+	tree, err := NewTree(sha256.New(), 3, map[uint64][]byte{
+		0: []byte{0x00},
+		3: []byte{0x03},
+	}, WithJournal())
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote, err := NewTree(sha256.New(), 3, map[uint64][]byte{
+		3: []byte{0x03},
+		6: []byte{0x06},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Sync(remote); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := tree.ExportJournal(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	replayed, err := NewTree(sha256.New(), 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := replayed.ReplayJournal(buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(replayed.Root(), remote.Root()) {
+		t.Errorf("expected: %x, actual: %x", remote.Root(), replayed.Root())
+	}
+	if !replayed.HasLeaf(6) || replayed.HasLeaf(0) {
+		t.Errorf("expected: %t, actual: %t", true, false)
+	}
+}
+
+func TestTree_ReplayJournal(t *testing.T) {
+	tree, err := NewTree(sha256.New(), 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tree.ReplayJournal(bytes.NewReader([]byte{0x03, 0x00})); err == nil {
+		t.Errorf("expected: error, actual: %v", err)
+	}
+	if err := tree.ReplayJournal(bytes.NewReader(make([]byte, 13))); err != ErrInvalidJournalOp {
+		t.Errorf("expected: %v, actual: %v", ErrInvalidJournalOp, err)
+	}
+	truncated := []byte{journalOpUpdate, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xff, 0xff, 0xff, 0xff, 0x00}
+	if err := tree.ReplayJournal(bytes.NewReader(truncated)); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected: %v, actual: %v", io.ErrUnexpectedEOF, err)
+	}
+	shortNode := []byte{journalOpNode, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00}
+	if err := tree.ReplayJournal(bytes.NewReader(shortNode)); err != ErrInvalidNodeSize {
+		t.Errorf("expected: %v, actual: %v", ErrInvalidNodeSize, err)
+	}
+}
+
+func TestTree_WithoutInputCopy(t *testing.T) {
+	testCases := []struct {
+		name string
+		opts []Option
+		out  byte
+	}{
+		{
+			"success: copied",
+			[]Option{WithJournal()},
+			0x01,
+		},
+		{
+			"success: borrowed",
+			[]Option{WithJournal(), WithoutInputCopy()},
+			0xff,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			leaf := []byte{0x01}
+			tree, err := NewTree(sha256.New(), 3, map[uint64][]byte{
+				0: leaf,
+			}, tc.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			leaf[0] = 0xff
+
+			if actual := tree.journal.entries[0].leaf[0]; actual != tc.out {
+				t.Errorf("expected: %x, actual: %x", tc.out, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package merkle
+
+import (
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash"
+)
+
+var (
+	ErrInvalidKey = errors.New("invalid key")
+)
+
+// KeyMapper derives the index of the leaf slot of a key. Deployments differ
+// in how much they care about collisions, which truncating a digest to the
+// depth of the tree makes possible, and about keys being guessable from
+// their indices.
+type KeyMapper interface {
+	// KeyIndex returns the index of key in a tree of the given depth.
+	KeyIndex(key []byte, depth uint64) (uint64, error)
+}
+
+// HashKeyMapper maps a key to the first depth bits of its digest, SHA-256
+// unless NewHasher is set. It is the default key mapper.
+type HashKeyMapper struct {
+	NewHasher func() hash.Hash
+}
+
+func (mapper HashKeyMapper) KeyIndex(key []byte, depth uint64) (uint64, error) {
+	var digest []byte
+	if mapper.NewHasher == nil {
+		h := sha256.Sum256(key)
+		digest = h[:]
+	} else {
+		hasher := mapper.NewHasher()
+		if _, err := hasher.Write(key); err != nil {
+			return 0, err
+		}
+		digest = hasher.Sum(nil)
+	}
+	return truncateIndex(digest, depth), nil
+}
+
+// HKDFKeyMapper maps a key to the first depth bits of the output of
+// HKDF-SHA256 with the key as the secret. With a secret Salt, indices do not
+// tell whether a guessed key is in the tree.
+type HKDFKeyMapper struct {
+	Salt []byte
+	Info string
+}
+
+func (mapper HKDFKeyMapper) KeyIndex(key []byte, depth uint64) (uint64, error) {
+	b, err := hkdf.Key(sha256.New, key, mapper.Salt, mapper.Info, 8)
+	if err != nil {
+		return 0, err
+	}
+	return truncateIndex(b, depth), nil
+}
+
+// IdentityKeyMapper maps a key holding a big-endian integer of up to 8
+// bytes to that integer, for numeric keys that are leaf indices already.
+type IdentityKeyMapper struct{}
+
+func (mapper IdentityKeyMapper) KeyIndex(key []byte, depth uint64) (uint64, error) {
+	if len(key) == 0 || len(key) > 8 {
+		return 0, ErrInvalidKey
+	}
+
+	b := make([]byte, 8)
+	copy(b[8-len(key):], key)
+	index := binary.BigEndian.Uint64(b)
+	if depth < DepthMax && index>>depth != 0 {
+		return 0, ErrTooLargeLeafIndex
+	}
+	return index, nil
+}
+
+// truncateIndex returns the first depth bits of b as an index.
+func truncateIndex(b []byte, depth uint64) uint64 {
+	head := make([]byte, 8)
+	copy(head, b)
+	return binary.BigEndian.Uint64(head) >> (DepthMax - depth)
+}
+
+// KeyIndex returns the index of the leaf slot of key, derived by the key
+// mapper set with WithKeyMapper or by HashKeyMapper.
+func (tree *Tree) KeyIndex(key []byte) (uint64, error) {
+	mapper := tree.keyMapper
+	if mapper == nil {
+		mapper = HashKeyMapper{}
+	}
+	return mapper.KeyIndex(key, tree.depth)
+}
@@ -0,0 +1,110 @@
+package merkle
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"testing"
+)
+
+func TestKeyMapper_KeyIndex(t *testing.T) {
+	type input struct {
+		mapper KeyMapper
+		key    []byte
+		depth  uint64
+	}
+	type output struct {
+		index uint64
+		err   error
+	}
+	testCases := []struct {
+		name string
+		in   input
+		out  output
+	}{
+		{
+			"success: sha256",
+			// SHA-256("abc") = ba7816bf...
+			input{HashKeyMapper{}, []byte("abc"), 16},
+			output{0xba78, nil},
+		},
+		{
+			"success: sha512",
+			// SHA-512("abc") = ddaf35a1...
+			input{HashKeyMapper{func() hash.Hash { return sha512.New() }}, []byte("abc"), 8},
+			output{0xdd, nil},
+		},
+		{
+			"success: depth 0",
+			input{HashKeyMapper{}, []byte("abc"), 0},
+			output{0, nil},
+		},
+		{
+			"failure: identity key too long",
+			input{IdentityKeyMapper{}, make([]byte, 9), 64},
+			output{0, ErrInvalidKey},
+		},
+		{
+			"failure: identity index too large",
+			input{IdentityKeyMapper{}, []byte{0x01, 0x00}, 8},
+			output{0, ErrTooLargeLeafIndex},
+		},
+		{
+			"success: identity",
+			input{IdentityKeyMapper{}, []byte{0x01, 0x00}, 9},
+			output{256, nil},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			index, err := tc.in.mapper.KeyIndex(tc.in.key, tc.in.depth)
+			if err != tc.out.err {
+				t.Errorf("expected: %v, actual: %v", tc.out.err, err)
+			}
+			if index != tc.out.index {
+				t.Errorf("expected: %d, actual: %d", tc.out.index, index)
+			}
+		})
+	}
+}
+
+func TestHKDFKeyMapper_KeyIndex(t *testing.T) {
+	key := []byte("alice")
+
+	index1, err := HKDFKeyMapper{Salt: []byte("salt1")}.KeyIndex(key, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index2, err := HKDFKeyMapper{Salt: []byte("salt2")}.KeyIndex(key, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index1 == index2 {
+		t.Errorf("expected indices to differ by salt: %d", index1)
+	}
+
+	index3, err := HKDFKeyMapper{Salt: []byte("salt1")}.KeyIndex(key, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index3 != index1>>44 {
+		t.Errorf("expected: %d, actual: %d", index1>>44, index3)
+	}
+}
+
+func TestTree_WithKeyMapper(t *testing.T) {
+	m, err := NewMap(sha256.New(), WithKeyMapper(IdentityKeyMapper{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Set("\x05", []byte{0x05}); err != nil {
+		t.Fatal(err)
+	}
+	if !m.tree.HasLeaf(5) {
+		t.Errorf("expected: %t, actual: %t", true, false)
+	}
+	if err := m.Set("", []byte{0x00}); err != ErrInvalidKey {
+		t.Errorf("expected: %v, actual: %v", ErrInvalidKey, err)
+	}
+}
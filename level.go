@@ -0,0 +1,135 @@
+package merkle
+
+import (
+	"iter"
+	"slices"
+)
+
+const (
+	inlineNodeSize = 32
+)
+
+// nodeLevel holds the non-default nodes of a level of a tree. When the
+// hasher outputs 32 bytes, as most in use do, the nodes are kept as [32]byte
+// values held in the map itself instead of a slice per node pointing to the
+// heap, which saves the slice header and an allocation per node and spares
+// the garbage collector from scanning them. Other sizes are kept as slices.
+//
+// Nodes read from an inline level are copies, so reading one may allocate.
+type nodeLevel struct {
+	nodes       map[uint64][]byte
+	inlineNodes map[uint64][inlineNodeSize]byte
+}
+
+func newNodeLevel(hashSize uint64) *nodeLevel {
+	if hashSize == inlineNodeSize {
+		return &nodeLevel{
+			inlineNodes: map[uint64][inlineNodeSize]byte{},
+		}
+	}
+	return &nodeLevel{
+		nodes: map[uint64][]byte{},
+	}
+}
+
+func (level *nodeLevel) inline() bool {
+	return level.inlineNodes != nil
+}
+
+func (level *nodeLevel) get(index uint64) ([]byte, bool) {
+	if level.inline() {
+		node, ok := level.inlineNodes[index]
+		if !ok {
+			return nil, false
+		}
+		return node[:], true
+	}
+	node, ok := level.nodes[index]
+	return node, ok
+}
+
+func (level *nodeLevel) has(index uint64) bool {
+	if level.inline() {
+		_, ok := level.inlineNodes[index]
+		return ok
+	}
+	_, ok := level.nodes[index]
+	return ok
+}
+
+// put sets the node at index. An inline level copies the node, which must
+// be 32 bytes; any other level retains it.
+func (level *nodeLevel) put(index uint64, node []byte) {
+	if level.inline() {
+		level.inlineNodes[index] = [inlineNodeSize]byte(node)
+		return
+	}
+	level.nodes[index] = node
+}
+
+func (level *nodeLevel) remove(index uint64) {
+	if level.inline() {
+		delete(level.inlineNodes, index)
+		return
+	}
+	delete(level.nodes, index)
+}
+
+func (level *nodeLevel) len() int {
+	if level.inline() {
+		return len(level.inlineNodes)
+	}
+	return len(level.nodes)
+}
+
+// all iterates over the nodes of the level in no particular order. Like a
+// map, the level may have nodes removed while it is iterated over.
+func (level *nodeLevel) all() iter.Seq2[uint64, []byte] {
+	return func(yield func(uint64, []byte) bool) {
+		if level.inline() {
+			for index, node := range level.inlineNodes {
+				if !yield(index, node[:]) {
+					return
+				}
+			}
+			return
+		}
+		for index, node := range level.nodes {
+			if !yield(index, node) {
+				return
+			}
+		}
+	}
+}
+
+// indices returns the indices of the nodes of the level in ascending order.
+func (level *nodeLevel) indices() []uint64 {
+	indices := make([]uint64, 0, level.len())
+	for index := range level.all() {
+		indices = append(indices, index)
+	}
+	slices.Sort(indices)
+	return indices
+}
+
+// clone copies the level. The nodes of a level that is not inline are
+// shared, as nodes are never modified in place.
+func (level *nodeLevel) clone() *nodeLevel {
+	if level.inline() {
+		clone := &nodeLevel{
+			inlineNodes: make(map[uint64][inlineNodeSize]byte, len(level.inlineNodes)),
+		}
+		for index, node := range level.inlineNodes {
+			clone.inlineNodes[index] = node
+		}
+		return clone
+	}
+
+	clone := &nodeLevel{
+		nodes: make(map[uint64][]byte, len(level.nodes)),
+	}
+	for index, node := range level.nodes {
+		clone.nodes[index] = node
+	}
+	return clone
+}
@@ -0,0 +1,66 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"testing"
+)
+
+func TestNodeLevel(t *testing.T) {
+	for _, hashSize := range []uint64{inlineNodeSize, 64} {
+		level := newNodeLevel(hashSize)
+		if level.inline() != (hashSize == inlineNodeSize) {
+			t.Errorf("%d: expected: %t, actual: %t", hashSize, hashSize == inlineNodeSize, level.inline())
+		}
+
+		node := bytes.Repeat([]byte{0x01}, int(hashSize))
+		level.put(3, node)
+		level.put(1, node)
+		level.remove(1)
+
+		if actual, ok := level.get(3); !ok || !bytes.Equal(actual, node) {
+			t.Errorf("%d: expected: %x, actual: %x", hashSize, node, actual)
+		}
+		if level.has(1) || level.len() != 1 {
+			t.Errorf("%d: expected: %d node, actual: %d", hashSize, 1, level.len())
+		}
+
+		clone := level.clone()
+		clone.remove(3)
+		if !level.has(3) {
+			t.Errorf("%d: expected: the level untouched", hashSize)
+		}
+	}
+}
+
+func TestTree_InlineNodes(t *testing.T) {
+	leaves := map[uint64][]byte{
+		0: []byte{0x00},
+		3: []byte{0x03},
+	}
+
+	for _, newHasher := range []func() hash.Hash{sha256.New, sha512.New} {
+		tree, err := NewTree(newHasher(), 3, leaves)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if inline := newHasher().Size() == inlineNodeSize; tree.levels[3].inline() != inline {
+			t.Errorf("expected: %t, actual: %t", inline, tree.levels[3].inline())
+		}
+
+		proof, err := tree.CreateMembershipProof(3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := tree.VerifyMembershipProof(3, proof); err != nil || !ok {
+			t.Errorf("expected: %t, actual: %t (%v)", true, ok, err)
+		}
+
+		// nodes of the wrong size are rejected rather than truncated
+		if err := tree.setLeafNode(5, []byte{0x05}); err != ErrInvalidNodeSize {
+			t.Errorf("expected: %v, actual: %v", ErrInvalidNodeSize, err)
+		}
+	}
+}
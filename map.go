@@ -0,0 +1,285 @@
+package merkle
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrKeyCollision   = errors.New("key collision")
+	ErrInvalidMapLeaf = errors.New("invalid map leaf")
+)
+
+// Map is a map of string keys to values committed to by a tree, for
+// applications that want a verifiable key-value store without dealing with
+// leaf indices. A key is stored at the index the key mapper of
+// the tree derives from it, and its leaf is its size (4 bytes) || key ||
+// value, so a proof also binds the key.
+//
+// Two keys sharing an index are rejected with a *KeyCollisionError, which
+// with the default key mapper takes around 2^32 keys to become likely,
+// unless the tree is built with WithKeyBuckets, in which case every leaf is
+// the bucket of the keys of its index encoded by EncodeMapBucket. Buckets
+// keep a tree shallower than DepthMax usable with more keys than it has
+// leaves to spare, e.g. tens of millions of keys at depth 24 with buckets of
+// a few keys each, at the cost of proofs carrying the rest of the bucket.
+type Map struct {
+	tree    *Tree
+	entries map[uint64][]MapEntry
+	size    int
+}
+
+type MapEntry struct {
+	Key   string
+	Value []byte
+}
+
+// MapProof proves that Key maps to Value, or to nothing when Value is nil,
+// under a root of a Map. With WithKeyBuckets, Bucket holds the other
+// entries of the bucket of Key.
+type MapProof struct {
+	Key    string
+	Value  []byte
+	Bucket []MapEntry
+	Proof  []byte
+}
+
+// KeyCollisionError reports that Key maps to Index, whose leaf holds
+// StoredKey already.
+type KeyCollisionError struct {
+	Key       string
+	StoredKey string
+	Index     uint64
+}
+
+func (e *KeyCollisionError) Error() string {
+	return fmt.Sprintf("key collision: %q and %q map to index %d", e.Key, e.StoredKey, e.Index)
+}
+
+func (e *KeyCollisionError) Unwrap() error {
+	return ErrKeyCollision
+}
+
+// NewMap returns an empty map over a tree of depth DepthMax.
+func NewMap(hasher hash.Hash, opts ...Option) (*Map, error) {
+	return NewMapOfDepth(hasher, DepthMax, opts...)
+}
+
+// NewMapOfDepth returns an empty map over a tree of the given depth, to
+// which the key mapper truncates indices.
+func NewMapOfDepth(hasher hash.Hash, depth uint64, opts ...Option) (*Map, error) {
+	tree, err := NewTree(hasher, depth, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Map{
+		tree:    tree,
+		entries: map[uint64][]MapEntry{},
+	}, nil
+}
+
+func (m *Map) Set(key string, value []byte) error {
+	index, slot, i, found, err := m.lookup(key)
+	if err != nil {
+		return err
+	}
+	if !found && len(slot) > 0 && !m.tree.keyBuckets {
+		return &KeyCollisionError{key, slot[0].Key, index}
+	}
+
+	entry := MapEntry{key, append([]byte{}, value...)}
+	if found {
+		slot = slices.Clone(slot)
+		slot[i] = entry
+	} else {
+		slot = slices.Insert(slices.Clone(slot), i, entry)
+	}
+	if err := m.tree.Update(index, m.slotLeaf(slot)); err != nil {
+		return err
+	}
+	m.entries[index] = slot
+	if !found {
+		m.size++
+	}
+
+	return nil
+}
+
+// Get returns a copy of the value of key.
+func (m *Map) Get(key string) ([]byte, bool) {
+	_, slot, i, found, err := m.lookup(key)
+	if err != nil || !found {
+		return nil, false
+	}
+	return append([]byte{}, slot[i].Value...), true
+}
+
+// Delete removes key, which need not be set.
+func (m *Map) Delete(key string) error {
+	index, slot, i, found, err := m.lookup(key)
+	if err != nil || !found {
+		return err
+	}
+
+	slot = slices.Delete(slices.Clone(slot), i, i+1)
+	if len(slot) == 0 {
+		err = m.tree.Delete(index)
+	} else {
+		err = m.tree.Update(index, m.slotLeaf(slot))
+	}
+	if err != nil {
+		return err
+	}
+	if len(slot) == 0 {
+		delete(m.entries, index)
+	} else {
+		m.entries[index] = slot
+	}
+	m.size--
+
+	return nil
+}
+
+func (m *Map) Len() int {
+	return m.size
+}
+
+func (m *Map) Root() Root {
+	return m.tree.Root()
+}
+
+// Prove returns a proof of the value of key, or of its absence.
+func (m *Map) Prove(key string) (*MapProof, error) {
+	index, slot, i, found, err := m.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+	if !found && len(slot) > 0 && !m.tree.keyBuckets {
+		// the leaf is that of another key, so the absence of key cannot be
+		// proven
+		return nil, &KeyCollisionError{key, slot[0].Key, index}
+	}
+
+	proof, err := m.tree.CreateMembershipProof(index)
+	if err != nil {
+		return nil, err
+	}
+
+	mp := &MapProof{
+		Key:   key,
+		Proof: proof,
+	}
+	for j, entry := range slot {
+		entry.Value = append([]byte{}, entry.Value...)
+		if found && j == i {
+			mp.Value = entry.Value
+		} else {
+			mp.Bucket = append(mp.Bucket, entry)
+		}
+	}
+
+	return mp, nil
+}
+
+// Verify checks proof against the current root of the map.
+func (m *Map) Verify(proof *MapProof) (bool, error) {
+	return m.VerifyAgainstRoots(proof, [][]byte{m.tree.Root()})
+}
+
+// VerifyAgainstRoots checks proof against any of roots, such as published
+// roots of a map of the parameters of this one, which may be empty.
+func (m *Map) VerifyAgainstRoots(proof *MapProof, roots [][]byte) (bool, error) {
+	index, err := m.tree.KeyIndex([]byte(proof.Key))
+	if err != nil {
+		return false, err
+	}
+
+	slot := slices.Clone(proof.Bucket)
+	if proof.Value != nil {
+		slot = append(slot, MapEntry{proof.Key, proof.Value})
+	}
+	slices.SortFunc(slot, compareMapEntries)
+	for i := range slot {
+		if i > 0 && slot[i-1].Key == slot[i].Key {
+			return false, nil
+		}
+		if slot[i].Key == proof.Key && proof.Value == nil {
+			return false, nil
+		}
+	}
+	if !m.tree.keyBuckets && len(proof.Bucket) > 0 {
+		return false, nil
+	}
+
+	var leaf []byte
+	if len(slot) > 0 {
+		leaf = m.slotLeaf(slot)
+	}
+	return m.tree.VerifyAgainstRoots(index, leaf, proof.Proof, roots)
+}
+
+// lookup returns the index of key, the entries stored there and the
+// position of key among them, or where it would go.
+func (m *Map) lookup(key string) (uint64, []MapEntry, int, bool, error) {
+	index, err := m.tree.KeyIndex([]byte(key))
+	if err != nil {
+		return 0, nil, 0, false, err
+	}
+
+	slot := m.entries[index]
+	i, found := slices.BinarySearchFunc(slot, MapEntry{Key: key}, compareMapEntries)
+	return index, slot, i, found, nil
+}
+
+func (m *Map) slotLeaf(slot []MapEntry) []byte {
+	if m.tree.keyBuckets {
+		return EncodeMapBucket(slot)
+	}
+	return EncodeMapLeaf(slot[0].Key, slot[0].Value)
+}
+
+func compareMapEntries(a, b MapEntry) int {
+	return strings.Compare(a.Key, b.Key)
+}
+
+// EncodeMapLeaf returns the leaf a Map stores for key and value.
+func EncodeMapLeaf(key string, value []byte) []byte {
+	leaf := make([]byte, 0, 4+len(key)+len(value))
+	leaf = binary.BigEndian.AppendUint32(leaf, uint32(len(key)))
+	leaf = append(leaf, key...)
+	return append(leaf, value...)
+}
+
+// EncodeMapBucket returns the leaf a Map built with WithKeyBuckets stores
+// for the entries of an index: for each entry in ascending order of keys,
+// key size (4 bytes) || key || value size (4 bytes) || value.
+func EncodeMapBucket(entries []MapEntry) []byte {
+	entries = slices.Clone(entries)
+	slices.SortFunc(entries, compareMapEntries)
+
+	var leaf []byte
+	for _, entry := range entries {
+		leaf = binary.BigEndian.AppendUint32(leaf, uint32(len(entry.Key)))
+		leaf = append(leaf, entry.Key...)
+		leaf = binary.BigEndian.AppendUint32(leaf, uint32(len(entry.Value)))
+		leaf = append(leaf, entry.Value...)
+	}
+	return leaf
+}
+
+// DecodeMapLeaf returns the key and value of a leaf of a Map.
+func DecodeMapLeaf(leaf []byte) (string, []byte, error) {
+	if len(leaf) < 4 {
+		return "", nil, ErrInvalidMapLeaf
+	}
+	size := uint64(binary.BigEndian.Uint32(leaf[:4]))
+	if uint64(len(leaf)-4) < size {
+		return "", nil, ErrInvalidMapLeaf
+	}
+	return string(leaf[4 : 4+size]), leaf[4+size:], nil
+}
@@ -0,0 +1,202 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestMap(t *testing.T) {
+	m, err := NewMap(sha256.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	empty := m.Root()
+
+	if err := m.Set("alice", []byte{0x01}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Set("bob", []byte{0x02}); err != nil {
+		t.Fatal(err)
+	}
+
+	if value, ok := m.Get("alice"); !ok || !bytes.Equal(value, []byte{0x01}) {
+		t.Errorf("expected: %x, actual: %x", []byte{0x01}, value)
+	}
+	if _, ok := m.Get("carol"); ok {
+		t.Errorf("expected: %t, actual: %t", false, ok)
+	}
+
+	proof, err := m.Prove("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := m.Verify(proof); err != nil || !ok {
+		t.Errorf("expected: %t, actual: %t (%v)", true, ok, err)
+	}
+	proof.Value = []byte{0x03}
+	if ok, err := m.Verify(proof); err != nil || ok {
+		t.Errorf("expected: %t, actual: %t (%v)", false, ok, err)
+	}
+
+	proof, err = m.Prove("carol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proof.Value != nil {
+		t.Errorf("expected: %x, actual: %x", []byte(nil), proof.Value)
+	}
+	if ok, err := m.Verify(proof); err != nil || !ok {
+		t.Errorf("expected: %t, actual: %t (%v)", true, ok, err)
+	}
+
+	// a key stored where another one would go
+	index, err := m.tree.KeyIndex([]byte("carol"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.entries[index] = []MapEntry{{"dave", []byte{0x04}}}
+	var collision *KeyCollisionError
+	if err := m.Set("carol", []byte{0x03}); !errors.As(err, &collision) || collision.StoredKey != "dave" {
+		t.Errorf("expected: %v, actual: %v", ErrKeyCollision, err)
+	}
+	if _, err := m.Prove("carol"); !errors.Is(err, ErrKeyCollision) {
+		t.Errorf("expected: %v, actual: %v", ErrKeyCollision, err)
+	}
+	delete(m.entries, index)
+
+	if err := m.Delete("alice"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Delete("bob"); err != nil {
+		t.Fatal(err)
+	}
+	if m.Len() != 0 {
+		t.Errorf("expected: %d, actual: %d", 0, m.Len())
+	}
+	if !m.Root().Equal(empty) {
+		t.Errorf("expected: %x, actual: %x", empty, m.Root())
+	}
+}
+
+func TestDecodeMapLeaf(t *testing.T) {
+	key, value, err := DecodeMapLeaf(EncodeMapLeaf("alice", []byte{0x01}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "alice" || !bytes.Equal(value, []byte{0x01}) {
+		t.Errorf("expected: %s %x, actual: %s %x", "alice", []byte{0x01}, key, value)
+	}
+
+	if _, _, err := DecodeMapLeaf([]byte{0x00, 0x00, 0x00, 0x02, 0x61}); err != ErrInvalidMapLeaf {
+		t.Errorf("expected: %v, actual: %v", ErrInvalidMapLeaf, err)
+	}
+}
+
+// lengthKeyMapper maps keys to their length, so keys of a length collide.
+type lengthKeyMapper struct{}
+
+func (mapper lengthKeyMapper) KeyIndex(key []byte, depth uint64) (uint64, error) {
+	return uint64(len(key)), nil
+}
+
+func TestMap_WithKeyBuckets(t *testing.T) {
+	m, err := NewMap(sha256.New(), WithKeyMapper(lengthKeyMapper{}), WithKeyBuckets())
+	if err != nil {
+		t.Fatal(err)
+	}
+	empty := m.Root()
+
+	for _, key := range []string{"bob", "amy", "eve", "alice"} {
+		if err := m.Set(key, []byte(key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if m.Len() != 4 {
+		t.Errorf("expected: %d, actual: %d", 4, m.Len())
+	}
+	if value, ok := m.Get("eve"); !ok || string(value) != "eve" {
+		t.Errorf("expected: %s, actual: %s", "eve", value)
+	}
+
+	expected := EncodeMapBucket([]MapEntry{{"amy", []byte("amy")}, {"bob", []byte("bob")}, {"eve", []byte("eve")}})
+	if !bytes.Equal(testNode(m.tree, DepthMax, 3), mustHashLeaf(t, m.tree, expected)) {
+		t.Errorf("expected the bucket of index 3 to be %x", expected)
+	}
+
+	proof, err := m.Prove("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proof.Bucket) != 2 {
+		t.Errorf("expected: %d, actual: %d", 2, len(proof.Bucket))
+	}
+	if ok, err := m.Verify(proof); err != nil || !ok {
+		t.Errorf("expected: %t, actual: %t (%v)", true, ok, err)
+	}
+
+	// absence within a bucket is proven by the whole bucket
+	proof, err = m.Prove("dan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := m.Verify(proof); err != nil || !ok {
+		t.Errorf("expected: %t, actual: %t (%v)", true, ok, err)
+	}
+	proof.Bucket = proof.Bucket[1:]
+	if ok, err := m.Verify(proof); err != nil || ok {
+		t.Errorf("expected: %t, actual: %t (%v)", false, ok, err)
+	}
+
+	for _, key := range []string{"amy", "bob", "eve", "alice"} {
+		if err := m.Delete(key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !m.Root().Equal(empty) {
+		t.Errorf("expected: %x, actual: %x", empty, m.Root())
+	}
+}
+
+func mustHashLeaf(t *testing.T, tree *Tree, leaf []byte) []byte {
+	node, err := tree.hashLeaf(leaf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return node
+}
+
+func TestNewMapOfDepth(t *testing.T) {
+	// 512 keys over 256 leaves
+	m, err := NewMapOfDepth(sha256.New(), 8, WithKeyBuckets())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 512; i++ {
+		if err := m.Set(fmt.Sprintf("key%d", i), []byte{byte(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if m.Len() != 512 {
+		t.Errorf("expected: %d, actual: %d", 512, m.Len())
+	}
+
+	// a verifier holds only the published root and a map of the same
+	// parameters
+	root := m.Root()
+	verifier, err := NewMapOfDepth(sha256.New(), 8, WithKeyBuckets())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"key7", "key511", "key512"} {
+		proof, err := m.Prove(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := verifier.VerifyAgainstRoots(proof, [][]byte{root}); err != nil || !ok {
+			t.Errorf("%s: expected: %t, actual: %t (%v)", key, true, ok, err)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package merkle
+
+const (
+	// approximate size of a map[uint64][]byte entry besides the node: the
+	// key, the slice header and a share of the bucket overhead
+	nodeEntryOverhead = 8 + 24 + 8
+	sliceOverhead     = 24
+
+	// approximate size of a map[uint64][32]byte entry: the key, the node and
+	// a share of the bucket overhead
+	inlineNodeEntrySize = 8 + inlineNodeSize + 8
+)
+
+type LevelMemStats struct {
+	Nodes uint64
+	Bytes uint64
+}
+
+// MemStats approximates the memory held by a tree. Levels is indexed by
+// depth, from the root to the leaves.
+type MemStats struct {
+	Levels          []LevelMemStats
+	JournalBytes    uint64
+	ProofCacheBytes uint64
+}
+
+func (stats MemStats) Total() uint64 {
+	total := stats.JournalBytes + stats.ProofCacheBytes
+	for _, level := range stats.Levels {
+		total += level.Bytes
+	}
+	return total
+}
+
+func (tree *Tree) MemStats() MemStats {
+	stats := MemStats{
+		Levels: make([]LevelMemStats, len(tree.levels)),
+	}
+
+	for d, level := range tree.levels {
+		stats.Levels[d].Nodes = uint64(level.len())
+		if level.inline() {
+			stats.Levels[d].Bytes = stats.Levels[d].Nodes * inlineNodeEntrySize
+			continue
+		}
+		for _, node := range level.nodes {
+			stats.Levels[d].Bytes += nodeEntryOverhead + uint64(cap(node))
+		}
+	}
+
+	if tree.journal != nil {
+		for _, entry := range tree.journal.entries {
+			stats.JournalBytes += 8 + 2*sliceOverhead + 1 + uint64(cap(entry.leaf)+cap(entry.salt))
+		}
+	}
+
+	if tree.proofCache != nil {
+		tree.proofCache.mu.Lock()
+		for _, elem := range tree.proofCache.entries {
+			entry := elem.Value.(*proofCacheEntry)
+			stats.ProofCacheBytes += nodeEntryOverhead + 8 + 2*sliceOverhead + 8 + uint64(cap(entry.proof))
+			stats.ProofCacheBytes += uint64(len(entry.siblings)) * (sliceOverhead + nodeEntryOverhead)
+		}
+		tree.proofCache.mu.Unlock()
+	}
+
+	return stats
+}
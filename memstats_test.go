@@ -0,0 +1,42 @@
+package merkle
+
+import (
+	"crypto/sha256"
+	"testing"
+)
+
+func TestTree_MemStats(t *testing.T) {
+	tree, err := NewTree(sha256.New(), 3, map[uint64][]byte{
+		0: []byte{0x00},
+		3: []byte{0x03},
+	}, WithJournal(), WithProofCache(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tree.CreateMembershipProof(0); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := tree.MemStats()
+
+	expectedNodes := []uint64{1, 1, 2, 2}
+	for d, level := range stats.Levels {
+		if level.Nodes != expectedNodes[d] {
+			t.Errorf("depth %d: expected: %d, actual: %d", d, expectedNodes[d], level.Nodes)
+		}
+		if level.Bytes < level.Nodes*sha256.Size {
+			t.Errorf("depth %d: expected: >= %d, actual: %d", d, level.Nodes*sha256.Size, level.Bytes)
+		}
+	}
+	if stats.JournalBytes == 0 || stats.ProofCacheBytes == 0 {
+		t.Errorf("expected: journal and proof cache accounted for")
+	}
+
+	total := stats.JournalBytes + stats.ProofCacheBytes
+	for _, level := range stats.Levels {
+		total += level.Bytes
+	}
+	if stats.Total() != total {
+		t.Errorf("expected: %d, actual: %d", total, stats.Total())
+	}
+}
@@ -0,0 +1,107 @@
+// Package merkletest provides a fake tree for testing code that depends on
+// merkle.Prover and merkle.Verifier.
+package merkletest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+	"sync"
+
+	merkle "github.com/m0t0k1ch1/sparse-merkle-tree"
+)
+
+var (
+	_ merkle.Prover   = (*FakeTree)(nil)
+	_ merkle.Verifier = (*FakeTree)(nil)
+)
+
+// FakeTree commits to its leaves with a single hash instead of a Merkle tree.
+// Its roots and proofs are deterministic but meaningless outside of it: a
+// proof verifies only while the root it was created under is current.
+type FakeTree struct {
+	mu       sync.Mutex
+	indexMax uint64
+	leaves   map[uint64][]byte
+}
+
+func NewFakeTree(depth uint64, leaves map[uint64][]byte) *FakeTree {
+	tree := &FakeTree{
+		indexMax: ^uint64(0) >> (merkle.DepthMax - depth),
+		leaves:   map[uint64][]byte{},
+	}
+	for index, leaf := range leaves {
+		tree.leaves[index] = append([]byte(nil), leaf...)
+	}
+	return tree
+}
+
+func (tree *FakeTree) Update(index uint64, leaf []byte) error {
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+
+	if index > tree.indexMax {
+		return merkle.ErrTooLargeLeafIndex
+	}
+	tree.leaves[index] = append([]byte(nil), leaf...)
+	return nil
+}
+
+func (tree *FakeTree) Delete(index uint64) error {
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+
+	if index > tree.indexMax {
+		return merkle.ErrTooLargeLeafIndex
+	}
+	delete(tree.leaves, index)
+	return nil
+}
+
+func (tree *FakeTree) Root() merkle.Root {
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+
+	return tree.root()
+}
+
+// CreateMembershipProof returns root || index.
+func (tree *FakeTree) CreateMembershipProof(index uint64) ([]byte, error) {
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+
+	if index > tree.indexMax {
+		return nil, merkle.ErrTooLargeLeafIndex
+	}
+	return binary.BigEndian.AppendUint64(tree.root(), index), nil
+}
+
+func (tree *FakeTree) VerifyMembershipProof(index uint64, proof []byte) (bool, error) {
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+
+	if index > tree.indexMax {
+		return false, merkle.ErrTooLargeLeafIndex
+	}
+	return bytes.Equal(proof, binary.BigEndian.AppendUint64(tree.root(), index)), nil
+}
+
+func (tree *FakeTree) root() []byte {
+	indices := make([]uint64, 0, len(tree.leaves))
+	for index := range tree.leaves {
+		indices = append(indices, index)
+	}
+	sort.Slice(indices, func(i, j int) bool {
+		return indices[i] < indices[j]
+	})
+
+	h := sha256.New()
+	for _, index := range indices {
+		leaf := tree.leaves[index]
+		h.Write(binary.BigEndian.AppendUint64(nil, index))
+		h.Write(binary.BigEndian.AppendUint64(nil, uint64(len(leaf))))
+		h.Write(leaf)
+	}
+	return h.Sum(nil)
+}
@@ -0,0 +1,49 @@
+package merkletest
+
+import (
+	"bytes"
+	"testing"
+
+	merkle "github.com/m0t0k1ch1/sparse-merkle-tree"
+)
+
+func TestFakeTree(t *testing.T) {
+	tree := NewFakeTree(3, map[uint64][]byte{
+		0: []byte{0x00},
+	})
+	other := NewFakeTree(3, map[uint64][]byte{
+		0: []byte{0x00},
+	})
+	if !bytes.Equal(tree.Root(), other.Root()) {
+		t.Errorf("expected: %x, actual: %x", other.Root(), tree.Root())
+	}
+
+	if _, err := tree.CreateMembershipProof(8); err != merkle.ErrTooLargeLeafIndex {
+		t.Errorf("expected: %v, actual: %v", merkle.ErrTooLargeLeafIndex, err)
+	}
+
+	proof, err := tree.CreateMembershipProof(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := tree.VerifyMembershipProof(0, proof); err != nil || !ok {
+		t.Errorf("expected: %t, actual: %t (%v)", true, ok, err)
+	}
+	if ok, _ := tree.VerifyMembershipProof(1, proof); ok {
+		t.Errorf("expected: %t, actual: %t", false, ok)
+	}
+
+	if err := tree.Update(3, []byte{0x03}); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := tree.VerifyMembershipProof(0, proof); ok {
+		t.Errorf("expected: %t, actual: %t", false, ok)
+	}
+
+	if err := tree.Delete(3); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := tree.VerifyMembershipProof(0, proof); !ok {
+		t.Errorf("expected: %t, actual: %t", true, ok)
+	}
+}
@@ -0,0 +1,134 @@
+package merkle
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+const (
+	leafMetadataSize = 16
+)
+
+var (
+	ErrMetadataNotFound    = errors.New("metadata not found")
+	ErrInvalidLeafEncoding = errors.New("invalid leaf encoding")
+)
+
+// LeafMetadata is a leaf value along with its version and expiry. Its
+// canonical encoding is value || version (8 bytes) || expiry (8 bytes, Unix
+// seconds, 0 for none), so the node of the leaf is H(value || version ||
+// expiry) and a proof of the leaf commits to the metadata as well.
+type LeafMetadata struct {
+	Value   []byte
+	Version uint64
+	Expiry  time.Time
+}
+
+func (meta *LeafMetadata) MarshalBinary() ([]byte, error) {
+	var expiry int64
+	if !meta.Expiry.IsZero() {
+		expiry = meta.Expiry.Unix()
+	}
+
+	b := make([]byte, 0, len(meta.Value)+leafMetadataSize)
+	b = append(b, meta.Value...)
+	b = binary.BigEndian.AppendUint64(b, meta.Version)
+	return binary.BigEndian.AppendUint64(b, uint64(expiry)), nil
+}
+
+func (meta *LeafMetadata) UnmarshalBinary(b []byte) error {
+	if len(b) < leafMetadataSize {
+		return ErrInvalidLeafEncoding
+	}
+	n := len(b) - leafMetadataSize
+
+	var expiry time.Time
+	if seconds := int64(binary.BigEndian.Uint64(b[n+8:])); seconds != 0 {
+		expiry = time.Unix(seconds, 0).UTC()
+	}
+
+	*meta = LeafMetadata{
+		Value:   append([]byte{}, b[:n]...),
+		Version: binary.BigEndian.Uint64(b[n : n+8]),
+		Expiry:  expiry,
+	}
+	return nil
+}
+
+// Expired reports whether the leaf has an expiry that is not after now.
+func (meta *LeafMetadata) Expired(now time.Time) bool {
+	return !meta.Expiry.IsZero() && !meta.Expiry.After(now)
+}
+
+// MetadataProof proves the leaf at Index along with its decoded metadata,
+// which verifiers can check freshness constraints against.
+type MetadataProof struct {
+	Index    uint64
+	Metadata LeafMetadata
+	Proof    []byte
+}
+
+// UpdateWithMetadata writes the canonical encoding of meta at index. A leaf
+// with an expiry is also deleted by Sweep once it expires. The tree keeps
+// the encoding, unlike other leaf values, so that proofs can carry it.
+func (tree *Tree) UpdateWithMetadata(index uint64, meta LeafMetadata) error {
+	leaf, err := meta.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	if meta.Expiry.IsZero() {
+		err = tree.Update(index, leaf)
+	} else {
+		err = tree.UpdateWithExpiry(index, leaf, meta.Expiry)
+	}
+	if err != nil {
+		return err
+	}
+
+	if tree.metadata == nil {
+		tree.metadata = map[uint64][]byte{}
+	}
+	tree.metadata[index] = leaf
+
+	return nil
+}
+
+// CreateMetadataProof returns the proof of the leaf at index, which must
+// have been written with UpdateWithMetadata.
+func (tree *Tree) CreateMetadataProof(index uint64) (*MetadataProof, error) {
+	if index > tree.indexMax {
+		return nil, ErrTooLargeLeafIndex
+	}
+
+	leaf, ok := tree.metadata[index]
+	if !ok {
+		return nil, ErrMetadataNotFound
+	}
+
+	proof, err := tree.CreateMembershipProof(index)
+	if err != nil {
+		return nil, err
+	}
+
+	mp := &MetadataProof{
+		Index: index,
+		Proof: proof,
+	}
+	if err := mp.Metadata.UnmarshalBinary(leaf); err != nil {
+		return nil, err
+	}
+
+	return mp, nil
+}
+
+// VerifyMetadataProof checks that the leaf at proof.Index is the encoding
+// of proof.Metadata. Whether the leaf is fresh is left to the caller.
+func (tree *Tree) VerifyMetadataProof(proof *MetadataProof) (bool, error) {
+	leaf, err := proof.Metadata.MarshalBinary()
+	if err != nil {
+		return false, err
+	}
+	return tree.VerifyAgainstRoots(proof.Index, leaf, proof.Proof, [][]byte{tree.Root()})
+}
@@ -0,0 +1,106 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+	"time"
+)
+
+func TestLeafMetadata_MarshalBinary(t *testing.T) {
+	type input struct {
+		meta LeafMetadata
+	}
+	type output struct {
+		b []byte
+	}
+	testCases := []struct {
+		name string
+		in   input
+		out  output
+	}{
+		{
+			"success: without expiry",
+			input{LeafMetadata{[]byte{0xaa}, 1, time.Time{}}},
+			output{[]byte{
+				0xaa,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			}},
+		},
+		{
+			"success: with expiry",
+			input{LeafMetadata{nil, 2, time.Unix(256, 0)}},
+			output{[]byte{
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00,
+			}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := tc.in.meta.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(b, tc.out.b) {
+				t.Errorf("expected: %x, actual: %x", tc.out.b, b)
+			}
+
+			var meta LeafMetadata
+			if err := meta.UnmarshalBinary(b); err != nil {
+				t.Fatal(err)
+			}
+			if meta.Version != tc.in.meta.Version || !meta.Expiry.Equal(tc.in.meta.Expiry) {
+				t.Errorf("expected: %v, actual: %v", tc.in.meta, meta)
+			}
+		})
+	}
+}
+
+func TestTree_MetadataProof(t *testing.T) {
+	tree, err := NewTree(sha256.New(), 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expiry := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := tree.UpdateWithMetadata(2, LeafMetadata{[]byte{0x02}, 7, expiry}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Update(3, []byte{0x03}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tree.CreateMetadataProof(3); err != ErrMetadataNotFound {
+		t.Errorf("expected: %v, actual: %v", ErrMetadataNotFound, err)
+	}
+
+	proof, err := tree.CreateMetadataProof(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proof.Metadata.Version != 7 || !proof.Metadata.Expiry.Equal(expiry) {
+		t.Errorf("expected: %d %v, actual: %d %v", 7, expiry, proof.Metadata.Version, proof.Metadata.Expiry)
+	}
+	if !proof.Metadata.Expired(expiry) || proof.Metadata.Expired(expiry.Add(-time.Second)) {
+		t.Errorf("expected: expiring at %v", expiry)
+	}
+
+	if ok, err := tree.VerifyMetadataProof(proof); err != nil || !ok {
+		t.Errorf("expected: %t, actual: %t (%v)", true, ok, err)
+	}
+	proof.Metadata.Version = 8
+	if ok, err := tree.VerifyMetadataProof(proof); err != nil || ok {
+		t.Errorf("expected: %t, actual: %t (%v)", false, ok, err)
+	}
+
+	// the leaf is swept once it expires
+	if _, err := tree.Sweep(expiry); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tree.CreateMetadataProof(2); err != ErrMetadataNotFound {
+		t.Errorf("expected: %v, actual: %v", ErrMetadataNotFound, err)
+	}
+}
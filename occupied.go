@@ -0,0 +1,68 @@
+package merkle
+
+func (tree *Tree) MinIndex() (uint64, bool) {
+	return tree.firstOccupied(0, 0, 0)
+}
+
+func (tree *Tree) MaxIndex() (uint64, bool) {
+	return tree.lastOccupied(0, 0, tree.indexMax)
+}
+
+func (tree *Tree) NextOccupied(after uint64) (uint64, bool) {
+	if after >= tree.indexMax {
+		return 0, false
+	}
+	return tree.firstOccupied(0, 0, after+1)
+}
+
+func (tree *Tree) PrevOccupied(before uint64) (uint64, bool) {
+	if before == 0 {
+		return 0, false
+	}
+	if before > tree.indexMax {
+		before = tree.indexMax + 1
+	}
+	return tree.lastOccupied(0, 0, before-1)
+}
+
+// firstOccupied returns the smallest occupied leaf index >= from under the
+// node at (depth, index), descending only into non-empty subtrees.
+func (tree *Tree) firstOccupied(depth, index, from uint64) (uint64, bool) {
+	if !tree.levels[depth].has(index) {
+		return 0, false
+	}
+	if _, last := tree.leafRange(depth, index); last < from {
+		return 0, false
+	}
+	if depth == tree.depth {
+		return index, true
+	}
+	if leafIndex, ok := tree.firstOccupied(depth+1, index*2, from); ok {
+		return leafIndex, true
+	}
+	return tree.firstOccupied(depth+1, index*2+1, from)
+}
+
+// lastOccupied returns the largest occupied leaf index <= to under the node
+// at (depth, index), descending only into non-empty subtrees.
+func (tree *Tree) lastOccupied(depth, index, to uint64) (uint64, bool) {
+	if !tree.levels[depth].has(index) {
+		return 0, false
+	}
+	if first, _ := tree.leafRange(depth, index); first > to {
+		return 0, false
+	}
+	if depth == tree.depth {
+		return index, true
+	}
+	if leafIndex, ok := tree.lastOccupied(depth+1, index*2+1, to); ok {
+		return leafIndex, true
+	}
+	return tree.lastOccupied(depth+1, index*2, to)
+}
+
+func (tree *Tree) leafRange(depth, index uint64) (uint64, uint64) {
+	height := tree.depth - depth
+	first := index << height
+	return first, first + (1 << height) - 1
+}
@@ -0,0 +1,61 @@
+package merkle
+
+import (
+	"crypto/sha256"
+	"testing"
+)
+
+func TestTree_Occupied(t *testing.T) {
+	tree, err := NewTree(sha256.New(), 3, map[uint64][]byte{
+		1: []byte{0x01},
+		4: []byte{0x04},
+		5: []byte{0x05},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type output struct {
+		index uint64
+		ok    bool
+	}
+	testCases := []struct {
+		name string
+		f    func() (uint64, bool)
+		out  output
+	}{
+		{"MinIndex", tree.MinIndex, output{1, true}},
+		{"MaxIndex", tree.MaxIndex, output{5, true}},
+		{"NextOccupied: before min", func() (uint64, bool) { return tree.NextOccupied(0) }, output{1, true}},
+		{"NextOccupied: occupied", func() (uint64, bool) { return tree.NextOccupied(1) }, output{4, true}},
+		{"NextOccupied: adjacent", func() (uint64, bool) { return tree.NextOccupied(4) }, output{5, true}},
+		{"NextOccupied: after max", func() (uint64, bool) { return tree.NextOccupied(5) }, output{0, false}},
+		{"NextOccupied: too large", func() (uint64, bool) { return tree.NextOccupied(7) }, output{0, false}},
+		{"PrevOccupied: after max", func() (uint64, bool) { return tree.PrevOccupied(7) }, output{5, true}},
+		{"PrevOccupied: occupied", func() (uint64, bool) { return tree.PrevOccupied(4) }, output{1, true}},
+		{"PrevOccupied: before min", func() (uint64, bool) { return tree.PrevOccupied(1) }, output{0, false}},
+		{"PrevOccupied: too large", func() (uint64, bool) { return tree.PrevOccupied(100) }, output{5, true}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := tc.out
+
+			index, ok := tc.f()
+			if ok != out.ok {
+				t.Errorf("expected: %t, actual: %t", out.ok, ok)
+			}
+			if index != out.index {
+				t.Errorf("expected: %d, actual: %d", out.index, index)
+			}
+		})
+	}
+
+	empty, err := NewTree(sha256.New(), 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := empty.MinIndex(); ok {
+		t.Errorf("expected: %t, actual: %t", false, ok)
+	}
+}
@@ -0,0 +1,157 @@
+package merkle
+
+import (
+	"hash"
+	"log/slog"
+)
+
+type Option func(*Tree)
+
+// WithJournal records every leaf write, starting with the initial leaves in
+// index order, so that the tree can be exported with ExportJournal.
+func WithJournal() Option {
+	return func(tree *Tree) {
+		tree.journal = &journal{}
+	}
+}
+
+// WithNodeStore writes every node change through to store, from which the
+// tree can be restored with LoadTree.
+func WithNodeStore(store NodeStore) Option {
+	return func(tree *Tree) {
+		tree.store = store
+	}
+}
+
+// WithProofCache caches the proofs of up to size leaves, patching only the
+// siblings that later writes change.
+func WithProofCache(size int) Option {
+	return func(tree *Tree) {
+		tree.proofCache = newProofCache(size)
+	}
+}
+
+// WithLogger reports build and rebuild timings at debug and info level and
+// failed node store writes at error level to logger.
+func WithLogger(logger *slog.Logger) Option {
+	return func(tree *Tree) {
+		tree.logger = logger
+	}
+}
+
+// WithTracer traces the operations of the tree with tracer. Untraced trees
+// skip tracing altogether.
+func WithTracer(tracer Tracer) Option {
+	return func(tree *Tree) {
+		tree.tracer = tracer
+	}
+}
+
+// WithoutInputCopy makes the tree retain caller-owned slices, such as the
+// leaves recorded in the journal and the nodes read from a store or a sync
+// transport, instead of copying them. The caller must not modify them
+// afterwards.
+func WithoutInputCopy() Option {
+	return func(tree *Tree) {
+		tree.noInputCopy = true
+	}
+}
+
+// WithConstantTimeCompare compares the roots computed during proof
+// verification with the expected ones in constant time, for verification
+// on authentication paths where timing side channels matter.
+func WithConstantTimeCompare() Option {
+	return func(tree *Tree) {
+		tree.constantTime = true
+	}
+}
+
+// WithHasherPool hashes with hashers taken from a pool filled by newHasher
+// instead of the hasher of the tree, so that verification may run on
+// several goroutines at once without constructing a hasher per call. Writes
+// still require exclusive access to the tree.
+func WithHasherPool(newHasher func() hash.Hash) Option {
+	return func(tree *Tree) {
+		tree.hasherPool = newHasherPool(newHasher)
+	}
+}
+
+// WithSSZ merkleizes like the SSZ of the Ethereum consensus specs: leaves
+// are 32-byte chunks used as leaf nodes as they are, and empty leaves are
+// zero chunks. It is meant to be used with SHA-256.
+func WithSSZ() Option {
+	return func(tree *Tree) {
+		tree.ssz = true
+	}
+}
+
+// WithLevelTweak hashes every internal node as H(h || left || right), where
+// h is its height as a byte, 1 right above the leaves, so that a node cannot
+// be passed off as a node of another level. Schemes such as some
+// transparency logs require it; trees with it are not interoperable with
+// those without it, nor with verifiers hashing pairs on their own.
+func WithLevelTweak() Option {
+	return func(tree *Tree) {
+		tree.levelTweak = true
+	}
+}
+
+// WithSortedPairs hashes every internal node over its children in ascending
+// byte order, so that the hash of a pair does not depend on which child is
+// on the left, as MerkleProof of OpenZeppelin expects. The proofs of such a
+// tree still carry the positions of the siblings, which CreateSortedPairProof
+// leaves out.
+func WithSortedPairs() Option {
+	return func(tree *Tree) {
+		tree.sortedPairs = true
+	}
+}
+
+// WithKeyMapper derives the leaf indices of keys, such as those of a Map,
+// with mapper instead of HashKeyMapper.
+func WithKeyMapper(mapper KeyMapper) Option {
+	return func(tree *Tree) {
+		tree.keyMapper = mapper
+	}
+}
+
+// WithKeyBuckets has a Map keep the keys mapped to the same index in a
+// bucket held by the leaf of the index, instead of rejecting all but the
+// first with a *KeyCollisionError.
+func WithKeyBuckets() Option {
+	return func(tree *Tree) {
+		tree.keyBuckets = true
+	}
+}
+
+// WithSaltedLeaves hashes every leaf written to the tree as H(salt || leaf)
+// with a fresh random salt of SaltSize bytes, so that published roots and
+// proofs do not allow guessing low-entropy leaf values. The salts are handed
+// out with Salt and CreateSaltedMembershipProof, and are journaled, written
+// to the node store and exported with the leaves, so that a tree replayed,
+// loaded or imported with this option has the same leaf nodes.
+func WithSaltedLeaves() Option {
+	return func(tree *Tree) {
+		tree.salts = map[uint64][]byte{}
+	}
+}
+
+// WithParallelism bounds the goroutines parallel operations of the tree, such
+// as Prefetch, VerifyMembershipProofs and the hashing of the leaves of a new
+// tree, run at once. It defaults to GOMAXPROCS; n of 1 runs them
+// sequentially on the calling goroutine.
+func WithParallelism(n int) Option {
+	return func(tree *Tree) {
+		tree.parallelism = n
+	}
+}
+
+// WithDuplicateIndexRejection fails batches writing an index more than once
+// with ErrDuplicateIndex instead of letting the later write win: batches
+// given to ProveBatchTransition, and the writes made to an AtomicTree over
+// the tree between two commits.
+func WithDuplicateIndexRejection() Option {
+	return func(tree *Tree) {
+		tree.uniqueWrites = true
+	}
+}
@@ -0,0 +1,45 @@
+package merkle
+
+import (
+	"runtime"
+	"sync"
+)
+
+// workers returns the number of goroutines parallel operations of the tree
+// may run at once.
+func (tree *Tree) workers() int {
+	if tree.parallelism > 0 {
+		return tree.parallelism
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// parallelFor calls f for every i in [0, n) on up to workers goroutines.
+func parallelFor(n, workers int, f func(i int)) {
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			f(i)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	indexCh := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexCh {
+				f(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexCh <- i
+	}
+	close(indexCh)
+	wg.Wait()
+}
@@ -0,0 +1,132 @@
+package merkle
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+)
+
+var (
+	ErrIncompatibleTrees = errors.New("incompatible trees")
+	ErrInvalidPatch      = errors.New("invalid patch")
+	ErrPatchRootMismatch = errors.New("patch root mismatch")
+)
+
+// DiffSnapshots returns a patch turning oldTree into newTree, two trees of the same
+// depth and hasher, encoded as old root || new root followed by one entry per
+// changed leaf in index order: index (8 bytes) || op (1 byte) || leaf node,
+// where the op is that of the journal and the node is left out for deletes.
+func DiffSnapshots(oldTree, newTree *Tree) ([]byte, error) {
+	if oldTree.depth != newTree.depth || oldTree.hashSize != newTree.hashSize {
+		return nil, ErrIncompatibleTrees
+	}
+
+	patch := make([]byte, 0, 2*oldTree.hashSize)
+	patch = append(patch, oldTree.Root()...)
+	patch = append(patch, newTree.Root()...)
+
+	for change := range oldTree.DiffStream(context.Background(), newTree) {
+		if change.Err != nil {
+			return nil, change.Err
+		}
+
+		patch = binary.BigEndian.AppendUint64(patch, change.Index)
+		if change.NewNode == nil {
+			patch = append(patch, journalOpDelete)
+		} else {
+			patch = append(patch, journalOpUpdate)
+			patch = append(patch, change.NewNode...)
+		}
+	}
+
+	return patch, nil
+}
+
+// ApplyPatch applies a patch produced by DiffSnapshots to tree, whose root
+// must be the old root of the patch. The leaf nodes are written as they are
+// and are not recorded in the journal. The resulting root is computed from
+// the entries and the siblings on their paths before anything is written,
+// so that a patch that does not lead to its new root fails with
+// ErrPatchRootMismatch and leaves the tree untouched.
+func ApplyPatch(tree *Tree, patch []byte) error {
+	if uint64(len(patch)) < 2*tree.hashSize {
+		return ErrInvalidPatch
+	}
+	oldRoot, newRoot := Root(patch[:tree.hashSize]), Root(patch[tree.hashSize:2*tree.hashSize])
+	if !tree.equalRoots(tree.Root(), oldRoot) {
+		return ErrPatchRootMismatch
+	}
+
+	// check every entry before writing anything, so that a malformed patch
+	// leaves the tree untouched
+	type entry struct {
+		index uint64
+		node  []byte
+	}
+	var entries []entry
+
+	b := patch[2*tree.hashSize:]
+	for len(b) > 0 {
+		if len(b) < 9 {
+			return ErrInvalidPatch
+		}
+		e := entry{index: binary.BigEndian.Uint64(b[:8])}
+		if e.index > tree.indexMax {
+			return ErrTooLargeLeafIndex
+		}
+
+		switch b[8] {
+		case journalOpUpdate:
+			if uint64(len(b)) < 9+tree.hashSize {
+				return ErrInvalidPatch
+			}
+			e.node = append([]byte(nil), b[9:9+tree.hashSize]...)
+			b = b[9+tree.hashSize:]
+
+		case journalOpDelete:
+			b = b[9:]
+
+		default:
+			return ErrInvalidPatch
+		}
+
+		entries = append(entries, e)
+	}
+
+	if len(entries) == 0 {
+		if !tree.equalRoots(oldRoot, newRoot) {
+			return ErrPatchRootMismatch
+		}
+		return nil
+	}
+
+	leafNodes := make(map[uint64][]byte, len(entries))
+	indices := make([]uint64, 0, len(entries))
+	for _, e := range entries {
+		leafNodes[e.index] = e.node
+		if e.node == nil {
+			leafNodes[e.index] = tree.defaultNodes[tree.depth]
+		}
+		indices = append(indices, e.index)
+	}
+	positions := tree.batchSiblingPositions(indices)
+	siblings := make([][]byte, len(positions))
+	for i, pos := range positions {
+		siblings[i], _ = tree.levels[tree.depth-pos.height].get(pos.index)
+	}
+	root, err := tree.computeBatchRoot(leafNodes, siblings)
+	if err != nil {
+		return err
+	}
+	if !tree.equalRoots(root, newRoot) {
+		return ErrPatchRootMismatch
+	}
+
+	for _, e := range entries {
+		if err := tree.writeLeafNode(e.index, e.node, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package merkle
+
+import (
+	"crypto/sha256"
+	"testing"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	atree, err := NewAtomicTree(sha256.New, 3, map[uint64][]byte{
+		0: []byte{0x00},
+		3: []byte{0x03},
+		5: []byte{0x05},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldSnapshot := atree.Snapshot()
+
+	if err := atree.Update(3, []byte{0x04}); err != nil {
+		t.Fatal(err)
+	}
+	if err := atree.Delete(5); err != nil {
+		t.Fatal(err)
+	}
+	if err := atree.Update(6, []byte{0x06}); err != nil {
+		t.Fatal(err)
+	}
+	atree.Commit()
+	newSnapshot := atree.Snapshot()
+
+	other, err := NewTree(sha256.New(), 4, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DiffSnapshots(oldSnapshot, other); err != ErrIncompatibleTrees {
+		t.Errorf("expected: %v, actual: %v", ErrIncompatibleTrees, err)
+	}
+
+	patch, err := DiffSnapshots(oldSnapshot, newSnapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := 2*32 + 3*9 + 2*32; len(patch) != expected {
+		t.Errorf("expected: %d, actual: %d", expected, len(patch))
+	}
+
+	replica, err := oldSnapshot.Copy()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ApplyPatch(replica, patch[:len(patch)-1]); err != ErrInvalidPatch {
+		t.Errorf("expected: %v, actual: %v", ErrInvalidPatch, err)
+	}
+	if !replica.Root().Equal(oldSnapshot.Root()) {
+		t.Errorf("expected: %x, actual: %x", oldSnapshot.Root(), replica.Root())
+	}
+
+	corrupted := append([]byte(nil), patch...)
+	corrupted[len(corrupted)-1] ^= 0xff
+	if err := ApplyPatch(replica, corrupted); err != ErrPatchRootMismatch {
+		t.Errorf("expected: %v, actual: %v", ErrPatchRootMismatch, err)
+	}
+	if !replica.Root().Equal(oldSnapshot.Root()) {
+		t.Errorf("expected: %x, actual: %x", oldSnapshot.Root(), replica.Root())
+	}
+
+	if err := ApplyPatch(replica, patch); err != nil {
+		t.Fatal(err)
+	}
+	if !replica.Root().Equal(newSnapshot.Root()) {
+		t.Errorf("expected: %x, actual: %x", newSnapshot.Root(), replica.Root())
+	}
+
+	if err := ApplyPatch(replica, patch); err != ErrPatchRootMismatch {
+		t.Errorf("expected: %v, actual: %v", ErrPatchRootMismatch, err)
+	}
+}
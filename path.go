@@ -0,0 +1,88 @@
+package merkle
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
+)
+
+// Path addresses a leaf as a 256-bit big-endian integer, so that callers
+// can move to key spaces wider than 64 bits without another change of API.
+// Trees are at most DepthMax deep for now, so only paths fitting in a
+// uint64 address a leaf.
+type Path [32]byte
+
+func PathFromIndex(index uint64) Path {
+	var path Path
+	binary.BigEndian.PutUint64(path[24:], index)
+	return path
+}
+
+// PathFromBig returns the path of x, which must be non-negative and fit in
+// 256 bits.
+func PathFromBig(x *big.Int) (Path, error) {
+	var path Path
+	if x.Sign() < 0 || x.BitLen() > 256 {
+		return path, ErrTooLargeLeafIndex
+	}
+	x.FillBytes(path[:])
+	return path, nil
+}
+
+func (path Path) Big() *big.Int {
+	return new(big.Int).SetBytes(path[:])
+}
+
+func (path Path) Hex() string {
+	return hex.EncodeToString(path[:])
+}
+
+// Index returns the path as a uint64, if it fits in one.
+func (path Path) Index() (uint64, error) {
+	for _, b := range path[:24] {
+		if b != 0 {
+			return 0, ErrTooLargeLeafIndex
+		}
+	}
+	return binary.BigEndian.Uint64(path[24:]), nil
+}
+
+func (tree *Tree) UpdatePath(path Path, leaf []byte) error {
+	index, err := path.Index()
+	if err != nil {
+		return err
+	}
+	return tree.Update(index, leaf)
+}
+
+func (tree *Tree) DeletePath(path Path) error {
+	index, err := path.Index()
+	if err != nil {
+		return err
+	}
+	return tree.Delete(index)
+}
+
+func (tree *Tree) HasLeafPath(path Path) bool {
+	index, err := path.Index()
+	if err != nil {
+		return false
+	}
+	return tree.HasLeaf(index)
+}
+
+func (tree *Tree) CreateMembershipProofPath(path Path) ([]byte, error) {
+	index, err := path.Index()
+	if err != nil {
+		return nil, err
+	}
+	return tree.CreateMembershipProof(index)
+}
+
+func (tree *Tree) VerifyMembershipProofPath(path Path, proof []byte) (bool, error) {
+	index, err := path.Index()
+	if err != nil {
+		return false, err
+	}
+	return tree.VerifyMembershipProof(index, proof)
+}
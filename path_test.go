@@ -0,0 +1,66 @@
+package merkle
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestPath(t *testing.T) {
+	path := PathFromIndex(0x0102)
+	if path.Hex() != "0000000000000000000000000000000000000000000000000000000000000102" {
+		t.Errorf("expected: %s, actual: %s", "0000000000000000000000000000000000000000000000000000000000000102", path.Hex())
+	}
+	if index, err := path.Index(); err != nil || index != 0x0102 {
+		t.Errorf("expected: %d, actual: %d (%v)", 0x0102, index, err)
+	}
+	if path.Big().Uint64() != 0x0102 {
+		t.Errorf("expected: %d, actual: %d", 0x0102, path.Big().Uint64())
+	}
+
+	wide, err := PathFromBig(new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wide.Index(); err != ErrTooLargeLeafIndex {
+		t.Errorf("expected: %v, actual: %v", ErrTooLargeLeafIndex, err)
+	}
+
+	if _, err := PathFromBig(new(big.Int).Lsh(big.NewInt(1), 256)); err != ErrTooLargeLeafIndex {
+		t.Errorf("expected: %v, actual: %v", ErrTooLargeLeafIndex, err)
+	}
+	if _, err := PathFromBig(big.NewInt(-1)); err != ErrTooLargeLeafIndex {
+		t.Errorf("expected: %v, actual: %v", ErrTooLargeLeafIndex, err)
+	}
+}
+
+func TestTree_UpdatePath(t *testing.T) {
+	tree := newTestTree(t)
+
+	if err := tree.UpdatePath(PathFromIndex(5), []byte{0x05}); err != nil {
+		t.Fatal(err)
+	}
+	if !tree.HasLeafPath(PathFromIndex(5)) {
+		t.Errorf("expected: %t, actual: %t", true, false)
+	}
+
+	proof, err := tree.CreateMembershipProofPath(PathFromIndex(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := tree.VerifyMembershipProofPath(PathFromIndex(5), proof); err != nil || !ok {
+		t.Errorf("expected: %t, actual: %t (%v)", true, ok, err)
+	}
+
+	if err := tree.DeletePath(PathFromIndex(5)); err != nil {
+		t.Fatal(err)
+	}
+	if !tree.Root().Equal(newTestTree(t).Root()) {
+		t.Errorf("expected: %x, actual: %x", newTestTree(t).Root(), tree.Root())
+	}
+
+	var wide Path
+	wide[0] = 0x01
+	if err := tree.UpdatePath(wide, []byte{0x05}); err != ErrTooLargeLeafIndex {
+		t.Errorf("expected: %v, actual: %v", ErrTooLargeLeafIndex, err)
+	}
+}
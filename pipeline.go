@@ -0,0 +1,148 @@
+package merkle
+
+import (
+	"hash"
+)
+
+const (
+	// pipelineBuffer bounds the nodes waiting to be written to the node
+	// store while the tree is being built.
+	pipelineBuffer = 1024
+)
+
+type pipelinedPut struct {
+	depth uint64
+	index uint64
+	key   []byte
+	value []byte
+}
+
+// storePipeline writes nodes to the node store on a goroutine of its own,
+// so that hashing goes on while the store does I/O. The first failed write
+// stops the writes that follow, and is reported by wait.
+type storePipeline struct {
+	store NodeStore
+	puts  chan pipelinedPut
+	done  chan struct{}
+	err   error
+}
+
+func (tree *Tree) startStorePipeline() *storePipeline {
+	pipeline := &storePipeline{
+		store: tree.store,
+		puts:  make(chan pipelinedPut, pipelineBuffer),
+		done:  make(chan struct{}),
+	}
+
+	go func() {
+		defer close(pipeline.done)
+		for put := range pipeline.puts {
+			if pipeline.err != nil {
+				continue
+			}
+			if err := tree.traceStore("put", put.depth, put.index, func() error {
+				return pipeline.store.Put(put.key, put.value)
+			}); err != nil {
+				tree.logStoreError("put", put.depth, put.index, err)
+				pipeline.err = err
+			}
+		}
+	}()
+
+	return pipeline
+}
+
+func (pipeline *storePipeline) put(depth, index uint64, node []byte) {
+	pipeline.putKey(depth, index, nodeKey(depth, index), node)
+}
+
+// putKey writes value under key, which belongs to the node at depth and
+// index, such as the key of its salt.
+func (pipeline *storePipeline) putKey(depth, index uint64, key, value []byte) {
+	pipeline.puts <- pipelinedPut{depth, index, key, value}
+}
+
+// wait waits for the pending writes and returns the first error among them.
+func (pipeline *storePipeline) wait() error {
+	close(pipeline.puts)
+	<-pipeline.done
+	return pipeline.err
+}
+
+// buildPipelined builds the tree in stages: the leaves are hashed, on up to
+// the parallelism of the tree when it hashes with pooled hashers, unless they
+// are hashed already, then the levels are pair hashed from the leaves up,
+// while the nodes are written to the node store, if any, as they come.
+func (tree *Tree) buildPipelined(leaves map[uint64][]byte, hashed bool) (err error) {
+	if tree.store != nil {
+		tree.pipeline = tree.startStorePipeline()
+		defer func() {
+			if perr := tree.pipeline.wait(); err == nil {
+				err = perr
+			}
+			tree.pipeline = nil
+		}()
+	}
+
+	indices := make([]uint64, 0, len(leaves))
+	for index := range leaves {
+		indices = append(indices, index)
+	}
+
+	if hashed {
+		for _, index := range indices {
+			if err := tree.putNode(tree.depth, index, tree.ingest(leaves[index])); err != nil {
+				return err
+			}
+		}
+		return tree.buildInternalNodes()
+	}
+
+	nodes := make([][]byte, len(indices))
+	arena := tree.newNodeArena(len(nodes))
+	for i := range nodes {
+		nodes[i] = arena.alloc()
+	}
+
+	// salting records the salts, so it cannot run in parallel
+	workers := 1
+	if tree.hasherPool != nil && tree.salts == nil {
+		workers = tree.workers()
+	}
+	errs := make([]error, len(indices))
+	parallelFor(len(indices), workers, func(i int) {
+		nodes[i], errs[i] = tree.saltedLeafNode(nodes[i], indices[i], leaves[indices[i]], nil)
+	})
+
+	for i, index := range indices {
+		if errs[i] != nil {
+			return errs[i]
+		}
+		if err := tree.putNode(tree.depth, index, nodes[i]); err != nil {
+			return err
+		}
+	}
+
+	return tree.buildInternalNodes()
+}
+
+// NewTreeFromLeafHashes builds a tree from leaf nodes hashed beforehand,
+// such as by an earlier stage of a pipeline, instead of leaf values, so that
+// they are not hashed twice. The leaf nodes must be of the size of the
+// hasher. No salts are drawn for them, and they are not journaled, as the
+// journal records leaf values.
+func NewTreeFromLeafHashes(hasher hash.Hash, depth uint64, leafNodes map[uint64][]byte, opts ...Option) (*Tree, error) {
+	tree, err := NewTree(hasher, depth, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if maxIndex(leafNodes) > tree.indexMax {
+		return nil, ErrTooLargeLeafIndex
+	}
+
+	if err := tree.buildPipelined(leafNodes, true); err != nil {
+		return nil, err
+	}
+
+	return tree, nil
+}
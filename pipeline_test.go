@@ -0,0 +1,69 @@
+package merkle
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+func TestTree_BuildPipelined(t *testing.T) {
+	leaves := map[uint64][]byte{}
+	for i := uint64(0); i < 256; i++ {
+		leaves[i*3] = binary.BigEndian.AppendUint64(nil, i)
+	}
+
+	expected, err := NewTree(sha256.New(), 10, leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewFileStore(t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := NewTree(sha256.New(), 10, leaves, WithNodeStore(store), WithHasherPool(sha256.New))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tree.Root().Equal(expected.Root()) {
+		t.Errorf("expected: %x, actual: %x", expected.Root(), tree.Root())
+	}
+
+	// every node has reached the store by the time NewTree returns
+	loaded, err := LoadTree(sha256.New(), 10, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Root().Equal(expected.Root()) {
+		t.Errorf("expected: %x, actual: %x", expected.Root(), loaded.Root())
+	}
+
+	storeErr := errors.New("disk full")
+	if _, err := NewTree(sha256.New(), 10, leaves, WithNodeStore(&failingNodeStore{err: storeErr})); err != storeErr {
+		t.Errorf("expected: %v, actual: %v", storeErr, err)
+	}
+}
+
+func TestNewTreeFromLeafHashes(t *testing.T) {
+	expected := newTestTree(t)
+
+	tree, err := NewTreeFromLeafHashes(sha256.New(), 3, expected.Leaves())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tree.Root().Equal(expected.Root()) {
+		t.Errorf("expected: %x, actual: %x", expected.Root(), tree.Root())
+	}
+
+	if _, err := NewTreeFromLeafHashes(sha256.New(), 3, map[uint64][]byte{
+		8: make([]byte, sha256.Size),
+	}); err != ErrTooLargeLeafIndex {
+		t.Errorf("expected: %v, actual: %v", ErrTooLargeLeafIndex, err)
+	}
+	if _, err := NewTreeFromLeafHashes(sha256.New(), 3, map[uint64][]byte{
+		0: []byte{0x00},
+	}); err != ErrInvalidNodeSize {
+		t.Errorf("expected: %v, actual: %v", ErrInvalidNodeSize, err)
+	}
+}
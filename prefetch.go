@@ -0,0 +1,57 @@
+package merkle
+
+import (
+	"errors"
+	"sync"
+)
+
+// Prefetch reads the nodes on the paths of the leaves at indices, and their
+// siblings, from the node store on up to as many goroutines as
+// WithParallelism allows, so that a caching store such as CachedStore holds
+// them before a burst of reads. The tree itself serves its reads from
+// memory, so this is for the other readers of the store, e.g. services
+// creating proofs from the nodes of a tree loaded from a shared store. It
+// does nothing when the tree has no node store.
+func (tree *Tree) Prefetch(indices []uint64) error {
+	if tree.store == nil {
+		return nil
+	}
+
+	seen := map[nodePosition]struct{}{}
+	var keys [][]byte
+	for _, index := range indices {
+		if index > tree.indexMax {
+			return ErrTooLargeLeafIndex
+		}
+		for h := uint64(0); h <= tree.depth; h++ {
+			d := tree.depth - h
+			for _, i := range []uint64{index >> h, (index >> h) ^ 1} {
+				if d == 0 && i != 0 {
+					continue
+				}
+				pos := nodePosition{h, i}
+				if _, ok := seen[pos]; ok {
+					continue
+				}
+				seen[pos] = struct{}{}
+				if tree.levels[d].has(i) {
+					keys = append(keys, nodeKey(d, i))
+				}
+			}
+		}
+	}
+
+	var (
+		errOnce  sync.Once
+		firstErr error
+	)
+	parallelFor(len(keys), tree.workers(), func(i int) {
+		if _, err := tree.store.Get(keys[i]); err != nil && !errors.Is(err, ErrNodeNotFound) {
+			errOnce.Do(func() {
+				firstErr = err
+			})
+		}
+	})
+
+	return firstErr
+}
@@ -0,0 +1,78 @@
+package merkle
+
+import (
+	"crypto/sha256"
+	"sync"
+	"testing"
+)
+
+type recordingNodeStore struct {
+	NodeStore
+	mu   sync.Mutex
+	gets map[string]int
+}
+
+func (store *recordingNodeStore) Get(key []byte) ([]byte, error) {
+	store.mu.Lock()
+	store.gets[string(key)]++
+	store.mu.Unlock()
+	return store.NodeStore.Get(key)
+}
+
+func TestTree_Prefetch(t *testing.T) {
+	fileStore, err := NewFileStore(t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewTree(sha256.New(), 3, map[uint64][]byte{
+		0: []byte{0x00},
+		3: []byte{0x03},
+		4: []byte{0x04},
+	}, WithNodeStore(fileStore)); err != nil {
+		t.Fatal(err)
+	}
+
+	// a reader opening the store afresh, behind a cache
+	store := &recordingNodeStore{
+		NodeStore: fileStore,
+		gets:      map[string]int{},
+	}
+	cstore := NewCachedStore(store, CachePolicy{MaxCachedBytes: 1 << 20})
+	tree, err := LoadTree(sha256.New(), 3, cstore)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tree.Prefetch([]uint64{8}); err != ErrTooLargeLeafIndex {
+		t.Errorf("expected: %v, actual: %v", ErrTooLargeLeafIndex, err)
+	}
+	if err := tree.Prefetch([]uint64{3, 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []nodePosition{
+		{0, 3},
+		{1, 0},
+		{1, 1},
+		{2, 0},
+		{2, 1},
+		{3, 0},
+	}
+	if len(store.gets) != len(expected) {
+		t.Errorf("expected: %d, actual: %d", len(expected), len(store.gets))
+	}
+	for _, pos := range expected {
+		key := nodeKey(tree.depth-pos.height, pos.index)
+		if count := store.gets[string(key)]; count != 1 {
+			t.Errorf("%v: expected: %d, actual: %d", pos, 1, count)
+		}
+
+		// served from the cache from now on
+		if _, err := cstore.Get(key); err != nil {
+			t.Fatal(err)
+		}
+		if count := store.gets[string(key)]; count != 1 {
+			t.Errorf("%v: expected: %d, actual: %d", pos, 1, count)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package merkle
+
+import (
+	"encoding/binary"
+)
+
+// decodeProof returns the siblings of a proof from the leaf level up, with
+// nil in place of default nodes. The size of the proof must be checked
+// beforehand.
+func (tree *Tree) decodeProof(proof []byte) ([][]byte, error) {
+	proofHead := binary.BigEndian.Uint64(proof[:proofHeadSize])
+	proofIndex := proofHeadSize
+
+	siblings := make([][]byte, tree.depth)
+	for h := range siblings {
+		if proofHead&1 == 1 {
+			if proofIndex+tree.hashSize > uint64(len(proof)) {
+				return nil, ErrInvalidProofSize
+			}
+			siblings[h] = proof[proofIndex : proofIndex+tree.hashSize]
+			proofIndex += tree.hashSize
+		}
+		proofHead >>= 1
+	}
+	if proofHead != 0 || proofIndex != uint64(len(proof)) {
+		return nil, ErrInvalidProofSize
+	}
+
+	return siblings, nil
+}
+
+func encodeProof(siblings [][]byte) []byte {
+	var proofHead uint64
+
+	proof := make([]byte, proofHeadSize)
+	for h, siblingNode := range siblings {
+		if siblingNode != nil {
+			proof = append(proof, siblingNode...)
+			proofHead |= 1 << uint(h)
+		}
+	}
+	binary.BigEndian.PutUint64(proof, proofHead)
+
+	return proof
+}
+
+// siblingNode returns the sibling of the node at height h on the path of
+// the leaf at index, or nil if it is a default node.
+func (tree *Tree) siblingNode(index, h uint64) []byte {
+	node, _ := tree.levels[tree.depth-h].get((index >> h) ^ 1)
+	return node
+}
+
+// computeRoot folds the siblings into the root of the path from the given
+// leaf node, without reading the levels of the tree.
+func (tree *Tree) computeRoot(index uint64, node []byte, siblings [][]byte) ([]byte, error) {
+	var err error
+	for h, siblingNode := range siblings {
+		if siblingNode == nil {
+			siblingNode = tree.defaultNodes[tree.depth-uint64(h)]
+		}
+		if index%2 == 0 {
+			node, err = tree.pairHash(uint64(h)+1, node, siblingNode)
+		} else {
+			node, err = tree.pairHash(uint64(h)+1, siblingNode, node)
+		}
+		if err != nil {
+			return nil, err
+		}
+		index /= 2
+	}
+	return node, nil
+}
+
+// leafNode returns the node of a leaf value, where nil stands for an empty
+// leaf.
+func (tree *Tree) leafNode(leaf []byte) ([]byte, error) {
+	if leaf == nil {
+		return tree.defaultNodes[tree.depth], nil
+	}
+	return tree.hashLeaf(leaf)
+}
@@ -0,0 +1,121 @@
+package merkle
+
+import (
+	"container/list"
+	"math/bits"
+	"sync"
+)
+
+// proofCache keeps the siblings and the encoded proofs of recently proven
+// leaves in LRU order. The entries are indexed by the positions of their
+// siblings, so that a write to a leaf looks up, on every level of its path,
+// the entries whose sibling there contains the leaf and only marks that
+// sibling as stale. Stale siblings are read again from the tree, and the
+// proof encoded again, on the next hit.
+type proofCache struct {
+	mu         sync.Mutex
+	size       int
+	entries    map[uint64]*list.Element
+	lru        *list.List
+	dependents map[nodePosition]map[uint64]*proofCacheEntry
+}
+
+type proofCacheEntry struct {
+	index    uint64
+	siblings [][]byte
+	proof    []byte
+	stale    uint64
+}
+
+func newProofCache(size int) *proofCache {
+	return &proofCache{
+		size:       size,
+		entries:    map[uint64]*list.Element{},
+		lru:        list.New(),
+		dependents: map[nodePosition]map[uint64]*proofCacheEntry{},
+	}
+}
+
+func (cache *proofCache) get(tree *Tree, index uint64) ([]byte, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	elem, ok := cache.entries[index]
+	if !ok {
+		return nil, false
+	}
+	cache.lru.MoveToFront(elem)
+
+	entry := elem.Value.(*proofCacheEntry)
+	if entry.stale != 0 {
+		for entry.stale != 0 {
+			h := uint64(bits.TrailingZeros64(entry.stale))
+			entry.siblings[h] = tree.siblingNode(index, h)
+			entry.stale &^= 1 << h
+		}
+		entry.proof = encodeProof(entry.siblings)
+	}
+
+	return append([]byte(nil), entry.proof...), true
+}
+
+// add caches the siblings and the proof of the leaf at index. The proof is
+// copied, as the caller hands it out.
+func (cache *proofCache) add(index uint64, siblings [][]byte, proof []byte) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if cache.size <= 0 {
+		return
+	}
+	if elem, ok := cache.entries[index]; ok {
+		cache.remove(elem)
+	}
+
+	entry := &proofCacheEntry{
+		index:    index,
+		siblings: siblings,
+		proof:    append([]byte(nil), proof...),
+	}
+	cache.entries[index] = cache.lru.PushFront(entry)
+	for h := range siblings {
+		pos := nodePosition{uint64(h), (index >> uint(h)) ^ 1}
+		dependents, ok := cache.dependents[pos]
+		if !ok {
+			dependents = map[uint64]*proofCacheEntry{}
+			cache.dependents[pos] = dependents
+		}
+		dependents[index] = entry
+	}
+
+	if cache.lru.Len() > cache.size {
+		cache.remove(cache.lru.Back())
+	}
+}
+
+func (cache *proofCache) remove(elem *list.Element) {
+	entry := cache.lru.Remove(elem).(*proofCacheEntry)
+	delete(cache.entries, entry.index)
+
+	for h := range entry.siblings {
+		pos := nodePosition{uint64(h), (entry.index >> uint(h)) ^ 1}
+		delete(cache.dependents[pos], entry.index)
+		if len(cache.dependents[pos]) == 0 {
+			delete(cache.dependents, pos)
+		}
+	}
+}
+
+// invalidate marks as stale the siblings containing the leaf at index, which
+// was written, looking up the entries depending on each of the depth nodes
+// of its path below the root.
+func (cache *proofCache) invalidate(index, depth uint64) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	for h := uint64(0); h < depth; h++ {
+		for _, entry := range cache.dependents[nodePosition{h, index >> h}] {
+			entry.stale |= 1 << h
+		}
+	}
+}
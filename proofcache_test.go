@@ -0,0 +1,76 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestTree_WithProofCache(t *testing.T) {
+	leaves := map[uint64][]byte{
+		0: []byte{0x00},
+		3: []byte{0x03},
+	}
+
+	tree, err := NewTree(sha256.New(), 3, leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached, err := NewTree(sha256.New(), 3, leaves, WithProofCache(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func() {
+		for index := uint64(0); index <= tree.indexMax; index++ {
+			expected, err := tree.CreateMembershipProof(index)
+			if err != nil {
+				t.Fatal(err)
+			}
+			proof, err := cached.CreateMembershipProof(index)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(proof, expected) {
+				t.Errorf("index %d: expected: %x, actual: %x", index, expected, proof)
+			}
+		}
+	}
+
+	check()
+	if cached.proofCache.lru.Len() != 4 {
+		t.Errorf("expected: %d, actual: %d", 4, cached.proofCache.lru.Len())
+	}
+	// the siblings of leaves 4 to 7: 4 leaves, 2 nodes at height 1 and the
+	// left half of the tree, the evicted entries having been unindexed
+	if len(cached.proofCache.dependents) != 7 {
+		t.Errorf("expected: %d, actual: %d", 7, len(cached.proofCache.dependents))
+	}
+
+	// a returned proof does not alias the cached one
+	proof, err := cached.CreateMembershipProof(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := append([]byte(nil), proof...)
+	proof[0] ^= 0xff
+	if proof, _ := cached.CreateMembershipProof(7); !bytes.Equal(proof, expected) {
+		t.Errorf("expected: %x, actual: %x", expected, proof)
+	}
+
+	for _, tt := range []*Tree{tree, cached} {
+		if err := tt.Update(6, []byte{0x06}); err != nil {
+			t.Fatal(err)
+		}
+		if err := tt.Delete(0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, elem := range cached.proofCache.entries {
+		if entry := elem.Value.(*proofCacheEntry); entry.stale == 0 {
+			t.Errorf("index %d: expected: stale siblings", entry.index)
+		}
+	}
+
+	check()
+}
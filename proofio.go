@@ -0,0 +1,57 @@
+package merkle
+
+import (
+	"encoding/binary"
+	"io"
+	"math/bits"
+)
+
+// WriteProof writes proof to w. The proof must be well formed for some hash
+// size, i.e. its head must be followed by one sibling of the same size per
+// bit set in it.
+func WriteProof(w io.Writer, proof []byte) error {
+	if uint64(len(proof)) < proofHeadSize {
+		return ErrInvalidProofSize
+	}
+
+	siblingsSize := uint64(len(proof)) - proofHeadSize
+	n := uint64(bits.OnesCount64(binary.BigEndian.Uint64(proof[:proofHeadSize])))
+	if n == 0 && siblingsSize != 0 || n != 0 && (siblingsSize == 0 || siblingsSize%n != 0) {
+		return ErrInvalidProofSize
+	}
+
+	_, err := w.Write(proof)
+	return err
+}
+
+// ReadProof reads a proof of a tree of the given depth and hash size from r,
+// consuming exactly the bytes of the proof so that r can carry the rest of
+// a larger message.
+func ReadProof(r io.Reader, depth, hashSize uint64) ([]byte, error) {
+	if depth > DepthMax {
+		return nil, ErrTooLargeTreeDepth
+	}
+	if hashSize == 0 || hashSize > HashSizeMax {
+		return nil, ErrInvalidHashSize
+	}
+
+	proof := make([]byte, proofHeadSize, proofHeadSize+depth*hashSize)
+	if _, err := io.ReadFull(r, proof); err != nil {
+		return nil, err
+	}
+
+	proofHead := binary.BigEndian.Uint64(proof)
+	if depth < DepthMax && proofHead>>depth != 0 {
+		return nil, ErrInvalidProofHead
+	}
+
+	proof = proof[:proofHeadSize+uint64(bits.OnesCount64(proofHead))*hashSize]
+	if _, err := io.ReadFull(r, proof[proofHeadSize:]); err != nil {
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	return proof, nil
+}
@@ -0,0 +1,153 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"testing"
+)
+
+func TestWriteProof(t *testing.T) {
+	tree := newTestTree(t)
+
+	proof, err := tree.CreateMembershipProof(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := WriteProof(buf, proof); err != nil {
+		t.Fatal(err)
+	}
+	buf.WriteString("trailer")
+
+	read, err := ReadProof(buf, tree.depth, sha256.Size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(read, proof) {
+		t.Errorf("expected: %x, actual: %x", proof, read)
+	}
+	if buf.String() != "trailer" {
+		t.Errorf("expected: %s, actual: %s", "trailer", buf.String())
+	}
+
+	for _, invalid := range [][]byte{
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01},
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00},
+	} {
+		if err := WriteProof(io.Discard, invalid); err != ErrInvalidProofSize {
+			t.Errorf("expected: %v, actual: %v", ErrInvalidProofSize, err)
+		}
+	}
+}
+
+func TestReadProof(t *testing.T) {
+	type input struct {
+		b        []byte
+		depth    uint64
+		hashSize uint64
+	}
+	type output struct {
+		err error
+	}
+	testCases := []struct {
+		name string
+		in   input
+		out  output
+	}{
+		{
+			"failure: too large tree depth",
+			input{
+				nil,
+				65,
+				sha256.Size,
+			},
+			output{
+				ErrTooLargeTreeDepth,
+			},
+		},
+		{
+			"failure: invalid hash size",
+			input{
+				nil,
+				3,
+				0,
+			},
+			output{
+				ErrInvalidHashSize,
+			},
+		},
+		{
+			"failure: empty",
+			input{
+				nil,
+				3,
+				sha256.Size,
+			},
+			output{
+				io.EOF,
+			},
+		},
+		{
+			"failure: sibling beyond depth",
+			input{
+				[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x08},
+				3,
+				sha256.Size,
+			},
+			output{
+				ErrInvalidProofHead,
+			},
+		},
+		{
+			"failure: missing sibling",
+			input{
+				[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01},
+				3,
+				sha256.Size,
+			},
+			output{
+				io.ErrUnexpectedEOF,
+			},
+		},
+		{
+			"failure: partial sibling",
+			input{
+				append([]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01}, make([]byte, sha256.Size-1)...),
+				3,
+				sha256.Size,
+			},
+			output{
+				io.ErrUnexpectedEOF,
+			},
+		},
+		{
+			"success: no siblings",
+			input{
+				[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+				3,
+				sha256.Size,
+			},
+			output{
+				nil,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			in, out := tc.in, tc.out
+
+			proof, err := ReadProof(bytes.NewReader(in.b), in.depth, in.hashSize)
+			if err != out.err {
+				t.Errorf("expected: %v, actual: %v", out.err, err)
+			}
+			if err == nil && !bytes.Equal(proof, in.b) {
+				t.Errorf("expected: %x, actual: %x", in.b, proof)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package merkle
+
+import (
+	"errors"
+)
+
+const (
+	// ProofVersion1 is the version of proofs made of an 8-byte head followed
+	// by the included siblings from the leaf level up. The bits of a head
+	// above the depth are zero, so the head of a legacy proof cannot start
+	// with it below depth 64.
+	ProofVersion1 byte = 0xff
+)
+
+var (
+	ErrUnsupportedProofVersion = errors.New("unsupported proof version")
+)
+
+// MarshalProof prefixes proof with its version for the wire, so that later
+// proof formats can be told apart from it.
+func MarshalProof(proof []byte) []byte {
+	return append([]byte{ProofVersion1}, proof...)
+}
+
+// UnmarshalProof returns the proof carried by b, a proof marshaled with
+// MarshalProof or a legacy proof without a version. A legacy proof is told
+// apart by its first byte, which holds the top bits of its head and has the
+// bits above the depth zero. Only at depth 64 can it be any byte, and a
+// legacy proof starting with ProofVersion1 is then told apart by its size, a
+// whole number of siblings beyond its head.
+func (tree *Tree) UnmarshalProof(b []byte) ([]byte, error) {
+	if len(b) == 0 {
+		return nil, ErrInvalidProofSize
+	}
+
+	if tree.legacyProof(b) {
+		if err := tree.SanitizeProof(b); err != nil {
+			return nil, err
+		}
+		return b, nil
+	}
+
+	switch b[0] {
+	case ProofVersion1:
+		proof := b[1:]
+		if err := tree.SanitizeProof(proof); err != nil {
+			return nil, err
+		}
+		return proof, nil
+
+	default:
+		return nil, ErrUnsupportedProofVersion
+	}
+}
+
+func (tree *Tree) legacyProof(b []byte) bool {
+	if topBits := DepthMax - 8; tree.depth <= topBits {
+		return b[0] == 0
+	} else if tree.depth < DepthMax {
+		return b[0]>>(tree.depth-topBits) == 0
+	}
+	if b[0] != ProofVersion1 {
+		return true
+	}
+	return tree.hashSize > 1 && uint64(len(b)) >= proofHeadSize && (uint64(len(b))-proofHeadSize)%tree.hashSize == 0
+}
@@ -0,0 +1,99 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"testing"
+)
+
+func TestTree_UnmarshalProof(t *testing.T) {
+	tree := newTestTree(t)
+
+	proof, err := tree.CreateMembershipProof(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name string
+		b    []byte
+		err  error
+	}{
+		{
+			"failure: empty",
+			nil,
+			ErrInvalidProofSize,
+		},
+		{
+			"failure: unsupported proof version",
+			append([]byte{0x02}, proof...),
+			ErrUnsupportedProofVersion,
+		},
+		{
+			"failure: legacy proof with head bits above the depth",
+			append([]byte{0x01}, proof[1:]...),
+			ErrUnsupportedProofVersion,
+		},
+		{
+			"failure: invalid versioned proof",
+			MarshalProof(proof[:len(proof)-2]),
+			ErrInvalidProofSize,
+		},
+		{
+			"success: versioned",
+			MarshalProof(proof),
+			nil,
+		},
+		{
+			"success: legacy",
+			proof,
+			nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			unmarshaled, err := tree.UnmarshalProof(tc.b)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected: %v, actual: %v", tc.err, err)
+			}
+			if err == nil && !bytes.Equal(unmarshaled, proof) {
+				t.Errorf("expected: %x, actual: %x", proof, unmarshaled)
+			}
+		})
+	}
+}
+
+func TestTree_UnmarshalProof_depthMax(t *testing.T) {
+	// the siblings of leaf 0 at heights 56 to 63 fill the top byte of its
+	// head, which then reads as ProofVersion1
+	leaves := map[uint64][]byte{
+		0: []byte{0x00},
+	}
+	for h := 56; h < 64; h++ {
+		leaves[1<<h] = []byte{byte(h)}
+	}
+	tree, err := NewTree(sha256.New(), DepthMax, leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proof, err := tree.CreateMembershipProof(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proof[0] != ProofVersion1 {
+		t.Fatalf("expected: %x, actual: %x", ProofVersion1, proof[0])
+	}
+
+	for _, b := range [][]byte{proof, MarshalProof(proof)} {
+		unmarshaled, err := tree.UnmarshalProof(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(unmarshaled, proof) {
+			t.Errorf("expected: %x, actual: %x", proof, unmarshaled)
+		}
+	}
+}
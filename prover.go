@@ -0,0 +1,15 @@
+package merkle
+
+type Prover interface {
+	Root() Root
+	CreateMembershipProof(index uint64) ([]byte, error)
+}
+
+type Verifier interface {
+	VerifyMembershipProof(index uint64, proof []byte) (bool, error)
+}
+
+var (
+	_ Prover   = (*Tree)(nil)
+	_ Verifier = (*Tree)(nil)
+)
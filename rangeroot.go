@@ -0,0 +1,102 @@
+package merkle
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/bits"
+)
+
+var (
+	ErrInvalidRange = errors.New("invalid range")
+)
+
+// rangeHeight returns the height of the subtree spanning exactly the leaves
+// from start to end, both inclusive.
+func (tree *Tree) rangeHeight(start, end uint64) (uint64, error) {
+	if end > tree.indexMax {
+		return 0, ErrTooLargeLeafIndex
+	}
+	if start > end {
+		return 0, ErrInvalidRange
+	}
+
+	h := uint64(bits.Len64(start ^ end))
+	mask := uint64(1)<<h - 1
+	if start&mask != 0 || end&mask != mask {
+		return 0, ErrInvalidRange
+	}
+	return h, nil
+}
+
+// RangeRoot returns the root of the subtree spanning exactly the leaves from
+// start to end, both inclusive, and a proof linking it to the root of the
+// tree, so that the range can be handed out and checked on its own. The
+// range must be aligned to its size, which must be a power of two.
+func (tree *Tree) RangeRoot(start, end uint64) (Root, []byte, error) {
+	h, err := tree.rangeHeight(start, end)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	d := tree.depth - h
+	root, ok := tree.levels[d].get(start >> h)
+	if !ok {
+		root = tree.defaultNodes[d]
+	}
+
+	siblings := make([][]byte, d)
+	for i := range siblings {
+		siblings[i] = tree.siblingNode(start, h+uint64(i))
+	}
+
+	return append(Root(nil), root...), encodeProof(siblings), nil
+}
+
+// VerifyRangeRoot reports whether proof links root, as the root of the
+// leaves from start to end, to the root of the tree.
+func (tree *Tree) VerifyRangeRoot(start, end uint64, root Root, proof []byte) (bool, error) {
+	h, err := tree.rangeHeight(start, end)
+	if err != nil {
+		return false, err
+	}
+
+	d := tree.depth - h
+	if uint64(len(proof)) < proofHeadSize {
+		return false, ErrInvalidProofSize
+	}
+	proofHead := binary.BigEndian.Uint64(proof[:proofHeadSize])
+	if d < DepthMax && proofHead>>d != 0 {
+		return false, ErrInvalidProofSize
+	}
+	if uint64(len(proof)) != proofHeadSize+uint64(bits.OnesCount64(proofHead))*tree.hashSize {
+		return false, ErrInvalidProofSize
+	}
+
+	proofIndex := proofHeadSize
+	node := []byte(root)
+	index := start >> h
+
+	for ; d > 0; d-- {
+		var siblingNode []byte
+		if proofHead&1 == 0 {
+			siblingNode = tree.defaultNodes[d]
+		} else {
+			siblingNode = proof[proofIndex : proofIndex+tree.hashSize]
+			proofIndex += tree.hashSize
+		}
+
+		if index%2 == 0 {
+			node, err = tree.pairHash(tree.depth-d+1, node, siblingNode)
+		} else {
+			node, err = tree.pairHash(tree.depth-d+1, siblingNode, node)
+		}
+		if err != nil {
+			return false, err
+		}
+
+		proofHead >>= 1
+		index /= 2
+	}
+
+	return tree.equalRoots(node, tree.Root()), nil
+}
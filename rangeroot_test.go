@@ -0,0 +1,133 @@
+package merkle
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTree_RangeRoot(t *testing.T) {
+	tree := newTestTree(t)
+
+	type input struct {
+		start uint64
+		end   uint64
+	}
+	type output struct {
+		root []byte
+		err  error
+	}
+	testCases := []struct {
+		name string
+		in   input
+		out  output
+	}{
+		{
+			"failure: too large leaf index",
+			input{
+				0,
+				8,
+			},
+			output{
+				nil,
+				ErrTooLargeLeafIndex,
+			},
+		},
+		{
+			"failure: reversed",
+			input{
+				3,
+				2,
+			},
+			output{
+				nil,
+				ErrInvalidRange,
+			},
+		},
+		{
+			"failure: unaligned",
+			input{
+				1,
+				2,
+			},
+			output{
+				nil,
+				ErrInvalidRange,
+			},
+		},
+		{
+			"success: leaf",
+			input{
+				3,
+				3,
+			},
+			output{
+				testNode(tree, 3, 3),
+				nil,
+			},
+		},
+		{
+			"success: subtree",
+			input{
+				0,
+				3,
+			},
+			output{
+				testNode(tree, 1, 0),
+				nil,
+			},
+		},
+		{
+			"success: empty subtree",
+			input{
+				4,
+				7,
+			},
+			output{
+				tree.defaultNodes[1],
+				nil,
+			},
+		},
+		{
+			"success: whole tree",
+			input{
+				0,
+				7,
+			},
+			output{
+				tree.Root(),
+				nil,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			in, out := tc.in, tc.out
+
+			root, proof, err := tree.RangeRoot(in.start, in.end)
+			if err != out.err {
+				t.Fatalf("expected: %v, actual: %v", out.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if !bytes.Equal(root, out.root) {
+				t.Errorf("expected: %x, actual: %x", out.root, root)
+			}
+
+			ok, err := tree.VerifyRangeRoot(in.start, in.end, root, proof)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				t.Errorf("expected: %t, actual: %t", true, ok)
+			}
+
+			forged := append(Root(nil), root...)
+			forged[0] ^= 0xff
+			if ok, err := tree.VerifyRangeRoot(in.start, in.end, forged, proof); err != nil || ok {
+				t.Errorf("expected: %t, actual: %t (%v)", false, ok, err)
+			}
+		})
+	}
+}
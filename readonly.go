@@ -0,0 +1,62 @@
+package merkle
+
+import (
+	"errors"
+)
+
+var (
+	ErrReadOnlyTree = errors.New("read-only tree")
+)
+
+// ReadOnlyTree is a view of a tree exposing only its reads, for handing the
+// tree to subsystems that must not write to it. Nodes are returned as
+// copies, so they cannot be modified in place either. The view reflects the
+// writes made to the tree afterwards.
+type ReadOnlyTree struct {
+	tree *Tree
+}
+
+var (
+	_ Prover        = ReadOnlyTree{}
+	_ Verifier      = ReadOnlyTree{}
+	_ SyncTransport = ReadOnlyTree{}
+)
+
+func (tree *Tree) ReadOnly() ReadOnlyTree {
+	return ReadOnlyTree{tree}
+}
+
+func (view ReadOnlyTree) Root() Root {
+	return append(Root(nil), view.tree.Root()...)
+}
+
+func (view ReadOnlyTree) HasLeaf(index uint64) bool {
+	return view.tree.HasLeaf(index)
+}
+
+func (view ReadOnlyTree) Node(depth, index uint64) ([]byte, error) {
+	node, err := view.tree.Node(depth, index)
+	if err != nil || node == nil {
+		return nil, err
+	}
+	return append([]byte(nil), node...), nil
+}
+
+func (view ReadOnlyTree) CreateMembershipProof(index uint64) ([]byte, error) {
+	return view.tree.CreateMembershipProof(index)
+}
+
+func (view ReadOnlyTree) VerifyMembershipProof(index uint64, proof []byte) (bool, error) {
+	return view.tree.VerifyMembershipProof(index, proof)
+}
+
+// Update rejects the write with ErrReadOnlyTree, for callers holding the
+// view behind an interface that includes writes.
+func (view ReadOnlyTree) Update(index uint64, leaf []byte) error {
+	return ErrReadOnlyTree
+}
+
+// Delete rejects the write with ErrReadOnlyTree.
+func (view ReadOnlyTree) Delete(index uint64) error {
+	return ErrReadOnlyTree
+}
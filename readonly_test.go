@@ -0,0 +1,51 @@
+package merkle
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTree_ReadOnly(t *testing.T) {
+	tree := newTestTree(t)
+	view := tree.ReadOnly()
+
+	if !view.Root().Equal(tree.Root()) {
+		t.Errorf("expected: %x, actual: %x", tree.Root(), view.Root())
+	}
+
+	proof, err := view.CreateMembershipProof(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := view.VerifyMembershipProof(3, proof); err != nil || !ok {
+		t.Errorf("expected: %t, actual: %t (%v)", true, ok, err)
+	}
+
+	if err := view.Update(3, []byte{0x04}); err != ErrReadOnlyTree {
+		t.Errorf("expected: %v, actual: %v", ErrReadOnlyTree, err)
+	}
+	if err := view.Delete(3); err != ErrReadOnlyTree {
+		t.Errorf("expected: %v, actual: %v", ErrReadOnlyTree, err)
+	}
+
+	// modifying what the view returns leaves the tree untouched
+	root := tree.Root()
+	expected := append([]byte(nil), root...)
+	view.Root()[0] ^= 0xff
+	node, err := view.Node(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node[0] ^= 0xff
+	if !bytes.Equal(tree.Root(), expected) {
+		t.Errorf("expected: %x, actual: %x", expected, tree.Root())
+	}
+
+	// writes to the tree show through the view
+	if err := tree.Delete(3); err != nil {
+		t.Fatal(err)
+	}
+	if view.HasLeaf(3) {
+		t.Errorf("expected: %t, actual: %t", false, true)
+	}
+}
@@ -0,0 +1,312 @@
+package merkle
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash"
+	"io"
+	"strconv"
+)
+
+// RecordFormat is the encoding of a stream of (index, value) records.
+type RecordFormat int
+
+const (
+	// RecordFormatBinary encodes each record as index (8 bytes) || value
+	// size (4 bytes) || value, like a journal entry without its op.
+	RecordFormatBinary RecordFormat = iota
+	// RecordFormatCSV encodes each record as a line "index,value" with the
+	// index in decimal and the value in hex.
+	RecordFormatCSV
+	// RecordFormatJSONL encodes each record as a line
+	// {"index":index,"value":"value"} with the value in hex.
+	RecordFormatJSONL
+)
+
+const (
+	// RecordValueSizeMax bounds the size of the value of a record read from
+	// a stream, so that a corrupted or hostile stream cannot have huge
+	// buffers allocated for it.
+	RecordValueSizeMax = 1 << 20
+
+	// recordLineSizeMax bounds a line of a text format: the value in hex
+	// and room for the index and the syntax around them.
+	recordLineSizeMax = 2*RecordValueSizeMax + 64
+)
+
+var (
+	ErrUnknownRecordFormat = errors.New("unknown record format")
+	ErrInvalidRecord       = errors.New("invalid record")
+	ErrTooLargeRecord      = errors.New("too large record")
+)
+
+type jsonRecord struct {
+	Index uint64 `json:"index"`
+	Value string `json:"value"`
+}
+
+// recordReader returns the next record of a stream, or io.EOF after the
+// last one.
+type recordReader func() (uint64, []byte, error)
+
+func newRecordReader(r io.Reader, format RecordFormat) (recordReader, error) {
+	switch format {
+	case RecordFormatBinary:
+		br := bufio.NewReader(r)
+		head := make([]byte, 12)
+		return func() (uint64, []byte, error) {
+			if _, err := io.ReadFull(br, head); err != nil {
+				return 0, nil, err
+			}
+			size := binary.BigEndian.Uint32(head[8:12])
+			if size > RecordValueSizeMax {
+				return 0, nil, ErrTooLargeRecord
+			}
+			value := make([]byte, size)
+			if _, err := io.ReadFull(br, value); err != nil {
+				if err == io.EOF {
+					err = io.ErrUnexpectedEOF
+				}
+				return 0, nil, err
+			}
+			return binary.BigEndian.Uint64(head[0:8]), value, nil
+		}, nil
+
+	case RecordFormatCSV:
+		br := bufio.NewReader(r)
+		return func() (uint64, []byte, error) {
+			line, err := readRecordLine(br)
+			if err != nil {
+				return 0, nil, err
+			}
+			cr := csv.NewReader(bytes.NewReader(line))
+			cr.FieldsPerRecord = 2
+			fields, err := cr.Read()
+			if err != nil {
+				return 0, nil, ErrInvalidRecord
+			}
+			return parseRecord(fields[0], fields[1])
+		}, nil
+
+	case RecordFormatJSONL:
+		br := bufio.NewReader(r)
+		return func() (uint64, []byte, error) {
+			line, err := readRecordLine(br)
+			if err != nil {
+				return 0, nil, err
+			}
+			var record jsonRecord
+			if err := json.Unmarshal(line, &record); err != nil {
+				return 0, nil, ErrInvalidRecord
+			}
+			value, err := hex.DecodeString(record.Value)
+			if err != nil {
+				return 0, nil, ErrInvalidRecord
+			}
+			return record.Index, value, nil
+		}, nil
+	}
+
+	return nil, ErrUnknownRecordFormat
+}
+
+// readRecordLine returns the next non-empty line of a text stream without
+// its line ending, failing on lines too long to hold a value of up to
+// RecordValueSizeMax bytes in hex.
+func readRecordLine(br *bufio.Reader) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := br.ReadSlice('\n')
+		line = append(line, chunk...)
+		if len(line) > recordLineSizeMax {
+			return nil, ErrTooLargeRecord
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			return nil, err
+		}
+
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) > 0 {
+			return line, nil
+		}
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		line = line[:0]
+	}
+}
+
+func parseRecord(indexText, valueText string) (uint64, []byte, error) {
+	index, err := strconv.ParseUint(indexText, 10, 64)
+	if err != nil {
+		return 0, nil, ErrInvalidRecord
+	}
+	value, err := hex.DecodeString(valueText)
+	if err != nil {
+		return 0, nil, ErrInvalidRecord
+	}
+	return index, value, nil
+}
+
+// NewTreeFromReader builds a tree from a stream of (index, leaf) records,
+// where a later record for the same index wins. Leaves are hashed as they
+// are read and never retained, so memory is bounded by the nodes of the
+// tree rather than by the size of the stream; with WithNodeStore, the
+// nodes are written through to the store as they are built. Trees larger
+// than memory are built into a store with BuildStoreFromReader instead.
+func NewTreeFromReader(hasher hash.Hash, depth uint64, r io.Reader, format RecordFormat, opts ...Option) (*Tree, error) {
+	return newTreeFromRecords(hasher, depth, r, format, false, opts...)
+}
+
+// NewTreeFromLeafHashReader builds a tree from a stream of (index, leaf
+// node) records, such as one written by ExportLeaves, the way
+// NewTreeFromReader does from leaf values. With WithSaltedLeaves, every
+// record carries the salt of the leaf after its node, as ExportLeaves
+// writes it. Like NewTreeFromLeafHashes, the leaves are not journaled.
+func NewTreeFromLeafHashReader(hasher hash.Hash, depth uint64, r io.Reader, format RecordFormat, opts ...Option) (*Tree, error) {
+	return newTreeFromRecords(hasher, depth, r, format, true, opts...)
+}
+
+func newTreeFromRecords(hasher hash.Hash, depth uint64, r io.Reader, format RecordFormat, hashed bool, opts ...Option) (*Tree, error) {
+	next, err := newRecordReader(r, format)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := NewTree(hasher, depth, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	arena := tree.newNodeArena(0)
+	for {
+		index, value, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if index > tree.indexMax {
+			return nil, ErrTooLargeLeafIndex
+		}
+
+		var node []byte
+		if hashed {
+			node, err = tree.leafHashRecord(index, value)
+		} else {
+			node, err = tree.saltedLeafNode(arena.alloc(), index, value, nil)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := tree.putNode(tree.depth, index, node); err != nil {
+			return nil, err
+		}
+
+		if tree.journal != nil && !hashed {
+			tree.journal.record(journalOpUpdate, index, value, tree.salts[index])
+		}
+	}
+
+	if err := tree.buildInternalNodes(); err != nil {
+		return nil, err
+	}
+
+	return tree, nil
+}
+
+// leafHashRecord returns the leaf node of the value of a record written by
+// ExportLeaves for the leaf at index, recording its salt, if any.
+func (tree *Tree) leafHashRecord(index uint64, value []byte) ([]byte, error) {
+	size := tree.hashSize
+	if tree.salts != nil {
+		size += SaltSize
+	}
+	if uint64(len(value)) != size {
+		return nil, ErrInvalidRecord
+	}
+
+	if tree.salts != nil {
+		if err := tree.putSalt(index, value[tree.hashSize:]); err != nil {
+			return nil, err
+		}
+	}
+	return value[:tree.hashSize:tree.hashSize], nil
+}
+
+// recordWriter writes a record to a stream. The stream must be flushed with
+// the returned flush function after the last record.
+type recordWriter func(index uint64, value []byte) error
+
+func newRecordWriter(w io.Writer, format RecordFormat) (recordWriter, func() error, error) {
+	switch format {
+	case RecordFormatBinary:
+		bw := bufio.NewWriter(w)
+		head := make([]byte, 12)
+		return func(index uint64, value []byte) error {
+			binary.BigEndian.PutUint64(head[0:8], index)
+			binary.BigEndian.PutUint32(head[8:12], uint32(len(value)))
+			if _, err := bw.Write(head); err != nil {
+				return err
+			}
+			_, err := bw.Write(value)
+			return err
+		}, bw.Flush, nil
+
+	case RecordFormatCSV:
+		cw := csv.NewWriter(w)
+		flush := func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+		return func(index uint64, value []byte) error {
+			return cw.Write([]string{strconv.FormatUint(index, 10), hex.EncodeToString(value)})
+		}, flush, nil
+
+	case RecordFormatJSONL:
+		bw := bufio.NewWriter(w)
+		enc := json.NewEncoder(bw)
+		return func(index uint64, value []byte) error {
+			return enc.Encode(jsonRecord{
+				Index: index,
+				Value: hex.EncodeToString(value),
+			})
+		}, bw.Flush, nil
+	}
+
+	return nil, nil, ErrUnknownRecordFormat
+}
+
+// ExportLeaves writes the occupied leaves in index order as records of the
+// given format. Leaf values are not retained, so the records carry the leaf
+// nodes, i.e. the hashes of the values, each followed by the salt of the
+// leaf when the tree salts its leaves; NewTreeFromLeafHashReader builds the
+// tree back from them.
+func (tree *Tree) ExportLeaves(w io.Writer, format RecordFormat) error {
+	write, flush, err := newRecordWriter(w, format)
+	if err != nil {
+		return err
+	}
+
+	level := tree.levels[tree.depth]
+	for _, index := range level.indices() {
+		node, _ := level.get(index)
+		if tree.salts != nil {
+			node = append(node[:len(node):len(node)], tree.salts[index]...)
+		}
+		if err := write(index, node); err != nil {
+			return err
+		}
+	}
+
+	return flush()
+}
@@ -0,0 +1,223 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestNewTreeFromReader(t *testing.T) {
+	expected := newTestTree(t)
+
+	binary := []byte{
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, 0xff,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x08,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x08,
+		0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03,
+	}
+
+	type input struct {
+		s      string
+		format RecordFormat
+	}
+	type output struct {
+		root Root
+		err  error
+	}
+	testCases := []struct {
+		name string
+		in   input
+		out  output
+	}{
+		{
+			"failure: unknown record format",
+			input{
+				"",
+				RecordFormat(-1),
+			},
+			output{
+				nil,
+				ErrUnknownRecordFormat,
+			},
+		},
+		{
+			"failure: invalid csv record",
+			input{
+				"0,zz\n",
+				RecordFormatCSV,
+			},
+			output{
+				nil,
+				ErrInvalidRecord,
+			},
+		},
+		{
+			"failure: invalid jsonl record",
+			input{
+				`{"index":0,"value":0}` + "\n",
+				RecordFormatJSONL,
+			},
+			output{
+				nil,
+				ErrInvalidRecord,
+			},
+		},
+		{
+			"failure: too large record",
+			input{
+				string([]byte{
+					0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xff, 0xff, 0xff, 0xff,
+				}),
+				RecordFormatBinary,
+			},
+			output{
+				nil,
+				ErrTooLargeRecord,
+			},
+		},
+		{
+			"failure: too large leaf index",
+			input{
+				"8,00\n",
+				RecordFormatCSV,
+			},
+			output{
+				nil,
+				ErrTooLargeLeafIndex,
+			},
+		},
+		{
+			"success: binary",
+			input{
+				string(binary),
+				RecordFormatBinary,
+			},
+			output{
+				expected.Root(),
+				nil,
+			},
+		},
+		{
+			"success: csv",
+			input{
+				"3,ff\n0,0000000000000000\n3,0303030303030303\n",
+				RecordFormatCSV,
+			},
+			output{
+				expected.Root(),
+				nil,
+			},
+		},
+		{
+			"success: jsonl",
+			input{
+				`{"index":0,"value":"0000000000000000"}` + "\n" + `{"index":3,"value":"0303030303030303"}` + "\n",
+				RecordFormatJSONL,
+			},
+			output{
+				expected.Root(),
+				nil,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			in, out := tc.in, tc.out
+
+			tree, err := NewTreeFromReader(sha256.New(), 3, strings.NewReader(in.s), in.format)
+			if err != out.err {
+				t.Errorf("expected: %v, actual: %v", out.err, err)
+			}
+			if err == nil && !bytes.Equal(tree.Root(), out.root) {
+				t.Errorf("expected: %x, actual: %x", out.root, tree.Root())
+			}
+		})
+	}
+}
+
+func TestTree_ExportLeaves(t *testing.T) {
+	tree, err := NewTree(sha256.New(), 3, map[uint64][]byte{
+		5: []byte{0x05},
+		0: []byte{0x00},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	node0, node5 := testNode(tree, 3, 0), testNode(tree, 3, 5)
+
+	buf := new(bytes.Buffer)
+	if err := tree.ExportLeaves(buf, RecordFormat(-1)); err != ErrUnknownRecordFormat {
+		t.Errorf("expected: %v, actual: %v", ErrUnknownRecordFormat, err)
+	}
+
+	for _, format := range []RecordFormat{RecordFormatBinary, RecordFormatCSV, RecordFormatJSONL} {
+		buf.Reset()
+		if err := tree.ExportLeaves(buf, format); err != nil {
+			t.Fatal(err)
+		}
+
+		next, err := newRecordReader(buf, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, expected := range []struct {
+			index uint64
+			node  []byte
+		}{
+			{0, node0},
+			{5, node5},
+		} {
+			index, node, err := next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if index != expected.index || !bytes.Equal(node, expected.node) {
+				t.Errorf("expected: %d %x, actual: %d %x", expected.index, expected.node, index, node)
+			}
+		}
+		if _, _, err := next(); err != io.EOF {
+			t.Errorf("expected: %v, actual: %v", io.EOF, err)
+		}
+	}
+}
+
+func TestNewTreeFromLeafHashReader(t *testing.T) {
+	for _, opts := range [][]Option{
+		nil,
+		{WithSaltedLeaves()},
+	} {
+		tree, err := NewTree(sha256.New(), 3, map[uint64][]byte{
+			5: []byte{0x05},
+			0: []byte{0x00},
+		}, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, format := range []RecordFormat{RecordFormatBinary, RecordFormatCSV, RecordFormatJSONL} {
+			buf := new(bytes.Buffer)
+			if err := tree.ExportLeaves(buf, format); err != nil {
+				t.Fatal(err)
+			}
+
+			imported, err := NewTreeFromLeafHashReader(sha256.New(), 3, buf, format, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !imported.Root().Equal(tree.Root()) {
+				t.Errorf("expected: %x, actual: %x", tree.Root(), imported.Root())
+			}
+			if salt, err := imported.Salt(5); len(opts) > 0 && (err != nil || !bytes.Equal(salt, tree.salts[5])) {
+				t.Errorf("expected: %x, actual: %x (%v)", tree.salts[5], salt, err)
+			}
+		}
+	}
+
+	if _, err := NewTreeFromLeafHashReader(sha256.New(), 3, strings.NewReader("0,00\n"), RecordFormatCSV); err != ErrInvalidRecord {
+		t.Errorf("expected: %v, actual: %v", ErrInvalidRecord, err)
+	}
+}
@@ -0,0 +1,36 @@
+package merkle
+
+import (
+	"math/bits"
+)
+
+// RefreshProof brings a proof of the leaf at index up to date after the
+// leaves at changedIndices were written, replacing only the siblings whose
+// subtrees contain one of them.
+func (tree *Tree) RefreshProof(index uint64, proof []byte, changedIndices []uint64) ([]byte, error) {
+	if index > tree.indexMax {
+		return nil, ErrTooLargeLeafIndex
+	}
+	if err := tree.SanitizeProof(proof); err != nil {
+		return nil, err
+	}
+
+	siblings, err := tree.decodeProof(proof)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, changedIndex := range changedIndices {
+		if changedIndex > tree.indexMax {
+			return nil, ErrTooLargeLeafIndex
+		}
+		if changedIndex == index {
+			continue
+		}
+
+		h := uint64(bits.Len64(changedIndex^index) - 1)
+		siblings[h] = tree.siblingNode(index, h)
+	}
+
+	return encodeProof(siblings), nil
+}
@@ -0,0 +1,52 @@
+package merkle
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestTree_RefreshProof(t *testing.T) {
+	tree := newTestTree(t)
+
+	proofs := make([][]byte, tree.indexMax+1)
+	for index := range proofs {
+		proof, err := tree.CreateMembershipProof(uint64(index))
+		if err != nil {
+			t.Fatal(err)
+		}
+		proofs[index] = proof
+	}
+
+	changedIndices := []uint64{1, 3, 6}
+	if err := tree.Update(1, []byte{0x01}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Delete(3); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Update(6, []byte{0x06}); err != nil {
+		t.Fatal(err)
+	}
+
+	for index, proof := range proofs {
+		refreshed, err := tree.RefreshProof(uint64(index), proof, changedIndices)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := tree.CreateMembershipProof(uint64(index))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(refreshed, expected) {
+			t.Errorf("index %d: expected: %x, actual: %x", index, expected, refreshed)
+		}
+	}
+
+	if _, err := tree.RefreshProof(0, proofs[0], []uint64{8}); err != ErrTooLargeLeafIndex {
+		t.Errorf("expected: %v, actual: %v", ErrTooLargeLeafIndex, err)
+	}
+	if _, err := tree.RefreshProof(0, proofs[0][:proofHeadSize], nil); !errors.Is(err, ErrInvalidProofSize) {
+		t.Errorf("expected: %v, actual: %v", ErrInvalidProofSize, err)
+	}
+}
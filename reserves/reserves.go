@@ -0,0 +1,328 @@
+// Package reserves packages the proof-of-reserves flow of an exchange: the
+// balances of the users are committed to a salted sum-tree whose root also
+// commits to the total liabilities, the root is published along with that
+// total, and every user receives a proof that their balance is included.
+//
+// Every node of the sum-tree is a SHA-256 digest followed by the sum of the
+// balances below it (8 bytes, big endian), so the proof of a user reveals the
+// sums of the sibling subtrees but neither their users nor their balances
+// one by one. Each leaf is salted with 32 random bytes, so the balance of a
+// user cannot be guessed from the node of their leaf.
+package reserves
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash"
+	"math"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"sort"
+
+	merkle "github.com/m0t0k1ch1/sparse-merkle-tree"
+)
+
+const (
+	// Depth is the depth of the sum-tree, which holds up to 2^32 users.
+	Depth = 32
+
+	saltSize = 32
+	sumSize  = 8
+	nodeSize = sha256.Size + sumSize
+	leafSize = saltSize + sha256.Size + sumSize
+
+	proofHeadSize = 8
+)
+
+var (
+	ErrTooManyUsers     = errors.New("too many users")
+	ErrTotalOverflow    = errors.New("total balance overflows")
+	ErrUnknownUser      = errors.New("unknown user")
+	ErrInvalidUserProof = errors.New("invalid user proof")
+)
+
+// Summary is the publishable commitment to the liabilities.
+type Summary struct {
+	Root  string `json:"root"`
+	Total uint64 `json:"total"`
+	Depth uint64 `json:"depth"`
+}
+
+// UserProof is what a user needs to check that their balance is included in
+// the published root.
+type UserProof struct {
+	UserID  string `json:"user_id"`
+	Balance uint64 `json:"balance"`
+	Salt    string `json:"salt"`
+	Index   uint64 `json:"index"`
+	Proof   string `json:"proof"`
+	Root    string `json:"root"`
+}
+
+type account struct {
+	index   uint64
+	balance uint64
+	salt    []byte
+}
+
+// Reserves is the sum-tree of the balances of the users.
+type Reserves struct {
+	tree     *merkle.Tree
+	accounts map[string]*account
+	total    uint64
+}
+
+// New builds the sum-tree of balances, placing the users in the order of
+// their IDs and salting each leaf with fresh random bytes.
+func New(balances map[string]uint64) (*Reserves, error) {
+	if uint64(len(balances)) > 1<<Depth {
+		return nil, ErrTooManyUsers
+	}
+
+	userIDs := make([]string, 0, len(balances))
+	for userID := range balances {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Strings(userIDs)
+
+	r := &Reserves{
+		accounts: make(map[string]*account, len(balances)),
+	}
+
+	leaves := make(map[uint64][]byte, len(balances))
+	for i, userID := range userIDs {
+		balance := balances[userID]
+
+		var carry uint64
+		if r.total, carry = bits.Add64(r.total, balance, 0); carry != 0 {
+			return nil, ErrTotalOverflow
+		}
+
+		salt := make([]byte, saltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+
+		acc := &account{
+			index:   uint64(i),
+			balance: balance,
+			salt:    salt,
+		}
+		r.accounts[userID] = acc
+		leaves[acc.index] = encodeLeaf(userID, balance, salt)
+	}
+
+	tree, err := merkle.NewTree(newSumHasher(), Depth, leaves)
+	if err != nil {
+		return nil, err
+	}
+	r.tree = tree
+
+	return r, nil
+}
+
+func (r *Reserves) Summary() Summary {
+	return Summary{
+		Root:  hex.EncodeToString(r.tree.Root()),
+		Total: r.total,
+		Depth: Depth,
+	}
+}
+
+func (r *Reserves) Proof(userID string) (*UserProof, error) {
+	acc, ok := r.accounts[userID]
+	if !ok {
+		return nil, ErrUnknownUser
+	}
+
+	proof, err := r.tree.CreateMembershipProof(acc.index)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserProof{
+		UserID:  userID,
+		Balance: acc.balance,
+		Salt:    hex.EncodeToString(acc.salt),
+		Index:   acc.index,
+		Proof:   hex.EncodeToString(proof),
+		Root:    hex.EncodeToString(r.tree.Root()),
+	}, nil
+}
+
+// WriteFiles writes the summary to summary.json in dir and the proof of
+// every user to a file named after the hex encoded SHA-256 of their ID, so
+// that the names of the files do not reveal the IDs.
+func (r *Reserves) WriteFiles(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	if err := writeJSON(filepath.Join(dir, "summary.json"), r.Summary()); err != nil {
+		return err
+	}
+
+	for userID := range r.accounts {
+		proof, err := r.Proof(userID)
+		if err != nil {
+			return err
+		}
+		if err := writeJSON(filepath.Join(dir, ProofFileName(userID)), proof); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ProofFileName returns the name of the file WriteFiles writes the proof of
+// the user to.
+func ProofFileName(userID string) string {
+	h := sha256.Sum256([]byte(userID))
+	return hex.EncodeToString(h[:]) + ".json"
+}
+
+func writeJSON(name string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, append(b, '\n'), 0644)
+}
+
+// Verify checks that the proof of a user leads to the root of the summary
+// and that the total committed to by the root is the published one.
+func Verify(proof *UserProof, summary Summary) (bool, error) {
+	if summary.Depth != Depth || proof.Index > math.MaxUint32 {
+		return false, ErrInvalidUserProof
+	}
+
+	root, err := hex.DecodeString(summary.Root)
+	if err != nil || len(root) != nodeSize {
+		return false, ErrInvalidUserProof
+	}
+	if proof.Root != summary.Root || nodeSum(root) != summary.Total {
+		return false, nil
+	}
+
+	salt, err := hex.DecodeString(proof.Salt)
+	if err != nil || len(salt) != saltSize {
+		return false, ErrInvalidUserProof
+	}
+	b, err := hex.DecodeString(proof.Proof)
+	if err != nil || len(b) < proofHeadSize {
+		return false, ErrInvalidUserProof
+	}
+
+	defaultNodes, err := merkle.DefaultNodes(newSumHasher(), Depth)
+	if err != nil {
+		return false, err
+	}
+
+	hasher := newSumHasher()
+	node := sumHash(hasher, encodeLeaf(proof.UserID, proof.Balance, salt))
+
+	head := binary.BigEndian.Uint64(b[:proofHeadSize])
+	b = b[proofHeadSize:]
+	index := proof.Index
+
+	for d := uint64(Depth); d > 0; d-- {
+		sibling := defaultNodes[d]
+		if head&1 == 1 {
+			if len(b) < nodeSize {
+				return false, ErrInvalidUserProof
+			}
+			sibling, b = b[:nodeSize], b[nodeSize:]
+		}
+		// a sum wrapping around would hide liabilities from the total
+		if _, carry := bits.Add64(nodeSum(node), nodeSum(sibling), 0); carry != 0 {
+			return false, nil
+		}
+
+		if index%2 == 0 {
+			node = sumHash(hasher, node, sibling)
+		} else {
+			node = sumHash(hasher, sibling, node)
+		}
+
+		head >>= 1
+		index /= 2
+	}
+	if head != 0 || len(b) != 0 {
+		return false, ErrInvalidUserProof
+	}
+
+	return hex.EncodeToString(node) == summary.Root, nil
+}
+
+// encodeLeaf encodes a leaf as salt || SHA-256 of the user ID || balance.
+func encodeLeaf(userID string, balance uint64, salt []byte) []byte {
+	h := sha256.Sum256([]byte(userID))
+
+	leaf := make([]byte, 0, leafSize)
+	leaf = append(leaf, salt...)
+	leaf = append(leaf, h[:]...)
+	return binary.BigEndian.AppendUint64(leaf, balance)
+}
+
+func nodeSum(node []byte) uint64 {
+	return binary.BigEndian.Uint64(node[sha256.Size:])
+}
+
+func sumHash(hasher hash.Hash, bs ...[]byte) []byte {
+	hasher.Reset()
+	for _, b := range bs {
+		hasher.Write(b)
+	}
+	return hasher.Sum(nil)
+}
+
+// sumHasher hashes the nodes of the sum-tree. The tree hashes leaves and
+// pairs of nodes with the same hasher, so the input is told apart by its
+// size: a leaf carries its balance in its last 8 bytes, a pair of nodes the
+// sums of both, and anything else, such as the input of the default leaf, has
+// a sum of 0.
+type sumHasher struct {
+	buf []byte
+}
+
+func newSumHasher() hash.Hash {
+	return &sumHasher{}
+}
+
+func (hasher *sumHasher) Write(b []byte) (int, error) {
+	hasher.buf = append(hasher.buf, b...)
+	return len(b), nil
+}
+
+func (hasher *sumHasher) Sum(b []byte) []byte {
+	var sum uint64
+	switch len(hasher.buf) {
+	case leafSize:
+		sum = binary.BigEndian.Uint64(hasher.buf[leafSize-sumSize:])
+	case 2 * nodeSize:
+		// New rejects totals that overflow, so sums of subtrees never do
+		sum = nodeSum(hasher.buf[:nodeSize]) + nodeSum(hasher.buf[nodeSize:])
+	}
+
+	digest := sha256.Sum256(hasher.buf)
+	b = append(b, digest[:]...)
+	return binary.BigEndian.AppendUint64(b, sum)
+}
+
+func (hasher *sumHasher) Reset() {
+	hasher.buf = hasher.buf[:0]
+}
+
+func (hasher *sumHasher) Size() int {
+	return nodeSize
+}
+
+func (hasher *sumHasher) BlockSize() int {
+	return sha256.BlockSize
+}
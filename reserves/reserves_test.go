@@ -0,0 +1,89 @@
+package reserves
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReserves(t *testing.T) {
+	balances := map[string]uint64{
+		"alice": 100,
+		"bob":   0,
+		"carol": 250,
+	}
+
+	r, err := New(balances)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summary := r.Summary()
+	if summary.Total != 350 {
+		t.Errorf("expected: %d, actual: %d", 350, summary.Total)
+	}
+
+	dir := t.TempDir()
+	if err := r.WriteFiles(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	var published Summary
+	readJSON(t, filepath.Join(dir, "summary.json"), &published)
+	if published != summary {
+		t.Errorf("expected: %v, actual: %v", summary, published)
+	}
+
+	for userID, balance := range balances {
+		var proof UserProof
+		readJSON(t, filepath.Join(dir, ProofFileName(userID)), &proof)
+		if proof.Balance != balance {
+			t.Errorf("expected: %d, actual: %d", balance, proof.Balance)
+		}
+		if ok, err := Verify(&proof, published); err != nil || !ok {
+			t.Errorf("%s: expected: %t, actual: %t (%v)", userID, true, ok, err)
+		}
+
+		proof.Balance++
+		if ok, err := Verify(&proof, published); err != nil || ok {
+			t.Errorf("%s: expected: %t, actual: %t (%v)", userID, false, ok, err)
+		}
+	}
+
+	proof, err := r.Proof("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	understated := summary
+	understated.Total--
+	if ok, err := Verify(proof, understated); err != nil || ok {
+		t.Errorf("expected: %t, actual: %t (%v)", false, ok, err)
+	}
+
+	if _, err := r.Proof("dave"); err != ErrUnknownUser {
+		t.Errorf("expected: %v, actual: %v", ErrUnknownUser, err)
+	}
+}
+
+func TestNew_Overflow(t *testing.T) {
+	if _, err := New(map[string]uint64{
+		"alice": math.MaxUint64,
+		"bob":   1,
+	}); err != ErrTotalOverflow {
+		t.Errorf("expected: %v, actual: %v", ErrTotalOverflow, err)
+	}
+}
+
+func readJSON(t *testing.T, name string, v any) {
+	t.Helper()
+
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		t.Fatal(err)
+	}
+}
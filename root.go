@@ -0,0 +1,42 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/hex"
+)
+
+// Root is the root node of a tree. It marshals to and from hex text, and so
+// to a JSON string.
+type Root []byte
+
+func (root Root) Equal(other Root) bool {
+	return bytes.Equal(root, other)
+}
+
+// ConstantTimeEqual is Equal in time independent of the contents of the
+// roots.
+func (root Root) ConstantTimeEqual(other Root) bool {
+	return subtle.ConstantTimeCompare(root, other) == 1
+}
+
+func (root Root) Hex() string {
+	return hex.EncodeToString(root)
+}
+
+func (root Root) String() string {
+	return root.Hex()
+}
+
+func (root Root) MarshalText() ([]byte, error) {
+	return []byte(root.Hex()), nil
+}
+
+func (root *Root) UnmarshalText(text []byte) error {
+	b, err := hex.DecodeString(string(text))
+	if err != nil {
+		return err
+	}
+	*root = b
+	return nil
+}
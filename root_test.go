@@ -0,0 +1,59 @@
+package merkle
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRoot(t *testing.T) {
+	root := newTestTree(t).Root()
+
+	rootHex := "096222fdaf653d68d1c7e4d90d91c253444e18eb9ab4be4940dd1ea2f0eb8d22"
+	if root.Hex() != rootHex {
+		t.Errorf("expected: %s, actual: %s", rootHex, root.Hex())
+	}
+
+	b, err := json.Marshal(struct {
+		Root Root `json:"root"`
+	}{root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"root":"`+rootHex+`"}` {
+		t.Errorf("expected: %s, actual: %s", `{"root":"`+rootHex+`"}`, b)
+	}
+
+	var decoded struct {
+		Root Root `json:"root"`
+	}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Root.Equal(root) {
+		t.Errorf("expected: %s, actual: %s", root, decoded.Root)
+	}
+
+	if err := json.Unmarshal([]byte(`{"root":"0x00"}`), &decoded); err == nil {
+		t.Errorf("expected: error, actual: %v", err)
+	}
+	if root.Equal(Root(root[1:])) {
+		t.Errorf("expected: %t, actual: %t", false, true)
+	}
+}
+
+func TestRoot_ConstantTimeEqual(t *testing.T) {
+	root := newTestTree(t).Root()
+
+	if !root.ConstantTimeEqual(append(Root(nil), root...)) {
+		t.Errorf("expected: %t, actual: %t", true, false)
+	}
+	if root.ConstantTimeEqual(root[1:]) {
+		t.Errorf("expected: %t, actual: %t", false, true)
+	}
+
+	other := append(Root(nil), root...)
+	other[len(other)-1] ^= 0x01
+	if root.ConstantTimeEqual(other) {
+		t.Errorf("expected: %t, actual: %t", false, true)
+	}
+}
@@ -0,0 +1,39 @@
+package merkle
+
+// VerifyAgainstRoots checks that leaf is at index, according to proof, in a
+// tree of the parameters of this one whose root is any of roots, such as the
+// last few roots a bridge has checkpointed. The root the proof leads to is
+// computed once and then looked up among roots. A nil leaf stands for an
+// empty one.
+func (tree *Tree) VerifyAgainstRoots(index uint64, leaf, proof []byte, roots [][]byte) (bool, error) {
+	if index > tree.indexMax {
+		return false, ErrTooLargeLeafIndex
+	}
+	if err := tree.SanitizeProof(proof); err != nil {
+		return false, err
+	}
+
+	siblings, err := tree.decodeProof(proof)
+	if err != nil {
+		return false, err
+	}
+	node, err := tree.leafNode(leaf)
+	if err != nil {
+		return false, err
+	}
+	computed, err := tree.computeRoot(index, node, siblings)
+	if err != nil {
+		return false, err
+	}
+
+	// every root is compared, so that with WithConstantTimeCompare the time
+	// taken does not tell which one matched
+	var ok bool
+	for _, root := range roots {
+		if tree.equalRoots(computed, root) {
+			ok = true
+		}
+	}
+
+	return ok, nil
+}
@@ -0,0 +1,78 @@
+package merkle
+
+import (
+	"testing"
+)
+
+func TestTree_VerifyAgainstRoots(t *testing.T) {
+	tree := newTestTree(t)
+	leaf := []byte{0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03}
+
+	oldRoot := tree.Root()
+	oldProof, err := tree.CreateMembershipProof(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tree.Update(5, []byte{0x05}); err != nil {
+		t.Fatal(err)
+	}
+	newProof, err := tree.CreateMembershipProof(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type input struct {
+		index uint64
+		leaf  []byte
+		proof []byte
+		roots [][]byte
+	}
+	type output struct {
+		ok  bool
+		err error
+	}
+	testCases := []struct {
+		name string
+		in   input
+		out  output
+	}{
+		{
+			"failure: too large leaf index",
+			input{8, leaf, newProof, [][]byte{tree.Root()}},
+			output{false, ErrTooLargeLeafIndex},
+		},
+		{
+			"failure: no accepted root",
+			input{3, leaf, oldProof, [][]byte{tree.Root()}},
+			output{false, nil},
+		},
+		{
+			"failure: wrong leaf",
+			input{3, []byte{0x04}, newProof, [][]byte{oldRoot, tree.Root()}},
+			output{false, nil},
+		},
+		{
+			"success: old root",
+			input{3, leaf, oldProof, [][]byte{oldRoot, tree.Root()}},
+			output{true, nil},
+		},
+		{
+			"success: new root",
+			input{3, leaf, newProof, [][]byte{oldRoot, tree.Root()}},
+			output{true, nil},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ok, err := tree.VerifyAgainstRoots(tc.in.index, tc.in.leaf, tc.in.proof, tc.in.roots)
+			if err != tc.out.err {
+				t.Fatalf("expected: %v, actual: %v", tc.out.err, err)
+			}
+			if ok != tc.out.ok {
+				t.Errorf("expected: %t, actual: %t", tc.out.ok, ok)
+			}
+		})
+	}
+}
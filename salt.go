@@ -0,0 +1,172 @@
+package merkle
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	SaltSize = 32
+
+	saltKeyTag byte = 0xff
+)
+
+var (
+	ErrSaltedLeavesDisabled = errors.New("salted leaves disabled")
+	ErrSaltNotFound         = errors.New("salt not found")
+	ErrInvalidSaltSize      = errors.New("invalid salt size")
+)
+
+// saltedLeafNode appends to dst the node of the leaf value written at index.
+// With WithSaltedLeaves it salts the leaf with salt, or a fresh one if salt
+// is nil, records the salt and hashes salt || leaf.
+func (tree *Tree) saltedLeafNode(dst []byte, index uint64, leaf, salt []byte) ([]byte, error) {
+	if tree.salts == nil {
+		if salt != nil {
+			return nil, ErrSaltedLeavesDisabled
+		}
+		return tree.hashLeafTo(dst, leaf)
+	}
+
+	if salt == nil {
+		var err error
+		if salt, err = newSalt(); err != nil {
+			return nil, err
+		}
+	} else if len(salt) != SaltSize {
+		return nil, ErrInvalidSaltSize
+	}
+	node, err := tree.hashTo(dst, append(salt[:SaltSize:SaltSize], leaf...))
+	if err != nil {
+		return nil, err
+	}
+	if err := tree.putSalt(index, salt); err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+func newSalt() ([]byte, error) {
+	salt := make([]byte, SaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// saltKey encodes the key the salt of the leaf at index is stored under as
+// 0xff || index (8 bytes), which no node key starts with as depths do not
+// exceed DepthMax.
+func saltKey(index uint64) []byte {
+	key := make([]byte, nodeKeySize)
+	key[0] = saltKeyTag
+	binary.BigEndian.PutUint64(key[1:], index)
+	return key
+}
+
+func parseSaltKey(key []byte) (uint64, bool) {
+	if len(key) != nodeKeySize || key[0] != saltKeyTag {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(key[1:]), true
+}
+
+// putSalt records the salt of the leaf at index and writes it to the node
+// store, if any.
+func (tree *Tree) putSalt(index uint64, salt []byte) error {
+	tree.salts[index] = salt
+
+	if tree.pipeline != nil {
+		tree.pipeline.putKey(tree.depth, index, saltKey(index), salt)
+	} else if tree.store != nil {
+		if err := tree.traceStore("put", tree.depth, index, func() error {
+			return tree.store.Put(saltKey(index), salt)
+		}); err != nil {
+			tree.logStoreError("put", tree.depth, index, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteSalt forgets the salt of the leaf at index, if any.
+func (tree *Tree) deleteSalt(index uint64) error {
+	if tree.salts == nil {
+		return nil
+	}
+	delete(tree.salts, index)
+
+	if tree.store != nil {
+		if err := tree.traceStore("delete", tree.depth, index, func() error {
+			return tree.store.Delete(saltKey(index))
+		}); err != nil {
+			tree.logStoreError("delete", tree.depth, index, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// Salt returns the salt of the leaf at index.
+func (tree *Tree) Salt(index uint64) ([]byte, error) {
+	if tree.salts == nil {
+		return nil, ErrSaltedLeavesDisabled
+	}
+	if index > tree.indexMax {
+		return nil, ErrTooLargeLeafIndex
+	}
+
+	salt, ok := tree.salts[index]
+	if !ok {
+		return nil, ErrSaltNotFound
+	}
+	return append([]byte(nil), salt...), nil
+}
+
+// CreateSaltedMembershipProof returns the membership proof of the leaf at
+// index along with its salt, both of which the verifier needs.
+func (tree *Tree) CreateSaltedMembershipProof(index uint64) ([]byte, []byte, error) {
+	salt, err := tree.Salt(index)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	proof, err := tree.CreateMembershipProof(index)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return salt, proof, nil
+}
+
+// VerifySaltedMembershipProof checks that the leaf value at index, salted
+// with salt, is included in the tree according to proof.
+func (tree *Tree) VerifySaltedMembershipProof(index uint64, leaf, salt, proof []byte) (bool, error) {
+	if index > tree.indexMax {
+		return false, ErrTooLargeLeafIndex
+	}
+	if len(salt) != SaltSize {
+		return false, ErrInvalidSaltSize
+	}
+	if err := tree.SanitizeProof(proof); err != nil {
+		return false, err
+	}
+
+	siblings, err := tree.decodeProof(proof)
+	if err != nil {
+		return false, err
+	}
+
+	node, err := tree.hash(append(salt[:SaltSize:SaltSize], leaf...))
+	if err != nil {
+		return false, err
+	}
+	root, err := tree.computeRoot(index, node, siblings)
+	if err != nil {
+		return false, err
+	}
+
+	return tree.equalRoots(root, tree.Root()), nil
+}
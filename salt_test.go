@@ -0,0 +1,126 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestTree_SaltedLeaves(t *testing.T) {
+	leaf := []byte{0x03}
+
+	plain := newTestTree(t)
+	if _, err := plain.Salt(3); err != ErrSaltedLeavesDisabled {
+		t.Errorf("expected: %v, actual: %v", ErrSaltedLeavesDisabled, err)
+	}
+
+	tree, err := NewTree(sha256.New(), 3, map[uint64][]byte{
+		3: leaf,
+	}, WithSaltedLeaves())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	salt, proof, err := tree.CreateSaltedMembershipProof(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(salt) != SaltSize {
+		t.Errorf("expected: %d, actual: %d", SaltSize, len(salt))
+	}
+
+	node, _ := tree.Node(3, 3)
+	expected := sha256.Sum256(append(append([]byte(nil), salt...), leaf...))
+	if string(node) != string(expected[:]) {
+		t.Errorf("expected: %x, actual: %x", expected, node)
+	}
+
+	if ok, err := tree.VerifySaltedMembershipProof(3, leaf, salt, proof); err != nil || !ok {
+		t.Errorf("expected: %t, actual: %t (%v)", true, ok, err)
+	}
+	if ok, err := tree.VerifySaltedMembershipProof(3, []byte{0x04}, salt, proof); err != nil || ok {
+		t.Errorf("expected: %t, actual: %t (%v)", false, ok, err)
+	}
+	if _, err := tree.VerifySaltedMembershipProof(3, leaf, salt[1:], proof); err != ErrInvalidSaltSize {
+		t.Errorf("expected: %v, actual: %v", ErrInvalidSaltSize, err)
+	}
+
+	// every write draws a fresh salt
+	if err := tree.Update(3, leaf); err != nil {
+		t.Fatal(err)
+	}
+	if newSalt, _ := tree.Salt(3); string(newSalt) == string(salt) {
+		t.Errorf("expected a fresh salt, actual: %x", newSalt)
+	}
+
+	if err := tree.Delete(3); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tree.Salt(3); err != ErrSaltNotFound {
+		t.Errorf("expected: %v, actual: %v", ErrSaltNotFound, err)
+	}
+}
+
+func TestTree_SaltedLeaves_persistence(t *testing.T) {
+	store, err := NewFileStore(t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tree, err := NewTree(sha256.New(), 3, map[uint64][]byte{
+		1: {0x01},
+	}, WithSaltedLeaves(), WithJournal(), WithNodeStore(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Update(3, []byte{0x03}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Update(5, []byte{0x05}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Delete(5); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadTree(sha256.New(), 3, store, WithSaltedLeaves())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Root().Equal(tree.Root()) {
+		t.Errorf("expected: %x, actual: %x", tree.Root(), loaded.Root())
+	}
+	for _, index := range []uint64{1, 3} {
+		salt, proof, err := loaded.CreateSaltedMembershipProof(index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := tree.VerifySaltedMembershipProof(index, []byte{byte(index)}, salt, proof); err != nil || !ok {
+			t.Errorf("expected: %t, actual: %t (%v)", true, ok, err)
+		}
+	}
+	if _, err := loaded.Salt(5); err != ErrSaltNotFound {
+		t.Errorf("expected: %v, actual: %v", ErrSaltNotFound, err)
+	}
+	if _, err := LoadTree(sha256.New(), 3, store); err != ErrSaltedLeavesDisabled {
+		t.Errorf("expected: %v, actual: %v", ErrSaltedLeavesDisabled, err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := tree.ExportJournal(buf); err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := NewTree(sha256.New(), 3, nil, WithSaltedLeaves())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := replayed.ReplayJournal(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if !replayed.Root().Equal(tree.Root()) {
+		t.Errorf("expected: %x, actual: %x", tree.Root(), replayed.Root())
+	}
+	if err := newTestTree(t).ReplayJournal(bytes.NewReader(buf.Bytes())); err != ErrSaltedLeavesDisabled {
+		t.Errorf("expected: %v, actual: %v", ErrSaltedLeavesDisabled, err)
+	}
+}
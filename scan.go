@@ -0,0 +1,57 @@
+package merkle
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	resumeTokenVersion byte = 0x01
+	resumeTokenSize         = 9
+)
+
+var (
+	ErrInvalidResumeToken = errors.New("invalid resume token")
+	ErrInvalidPageLimit   = errors.New("invalid page limit")
+)
+
+type LeafEntry struct {
+	Index uint64
+	Node  []byte
+}
+
+// ScanLeaves returns up to limit leaf nodes in ascending order of their
+// indices, starting from the beginning of the tree when token is nil, along
+// with an opaque token to pass to the next call, or nil after the last leaf.
+// The token only records where to resume, so no snapshot is held between
+// pages: leaves written behind the token since are not returned, and those
+// written ahead of it are.
+func (tree *Tree) ScanLeaves(token []byte, limit int) ([]LeafEntry, []byte, error) {
+	if limit <= 0 {
+		return nil, nil, ErrInvalidPageLimit
+	}
+
+	var from uint64
+	if token != nil {
+		if len(token) != resumeTokenSize || token[0] != resumeTokenVersion {
+			return nil, nil, ErrInvalidResumeToken
+		}
+		from = binary.BigEndian.Uint64(token[1:])
+		if from > tree.indexMax {
+			return nil, nil, ErrInvalidResumeToken
+		}
+	}
+
+	var entries []LeafEntry
+	index, ok := tree.firstOccupied(0, 0, from)
+	for ok && len(entries) < limit {
+		node, _ := tree.levels[tree.depth].get(index)
+		entries = append(entries, LeafEntry{index, append([]byte(nil), node...)})
+		index, ok = tree.NextOccupied(index)
+	}
+	if !ok {
+		return entries, nil, nil
+	}
+
+	return entries, binary.BigEndian.AppendUint64([]byte{resumeTokenVersion}, index), nil
+}
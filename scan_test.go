@@ -0,0 +1,97 @@
+package merkle
+
+import (
+	"crypto/sha256"
+	"slices"
+	"testing"
+)
+
+func TestTree_ScanLeaves(t *testing.T) {
+	tree, err := NewTree(sha256.New(), 4, map[uint64][]byte{
+		1:  []byte{0x01},
+		2:  []byte{0x02},
+		7:  []byte{0x07},
+		15: []byte{0x0f},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pages [][]uint64
+	var token []byte
+	for {
+		entries, next, err := tree.ScanLeaves(token, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var indices []uint64
+		for _, entry := range entries {
+			if !slices.Equal(entry.Node, testNode(tree, 4, entry.Index)) {
+				t.Errorf("expected: %x, actual: %x", testNode(tree, 4, entry.Index), entry.Node)
+			}
+			indices = append(indices, entry.Index)
+		}
+		pages = append(pages, indices)
+
+		if next == nil {
+			break
+		}
+		token = next
+
+		// a write behind the token is not seen, one ahead of it is
+		if len(pages) == 1 {
+			if err := tree.Update(0, []byte{0x00}); err != nil {
+				t.Fatal(err)
+			}
+			if err := tree.Update(8, []byte{0x08}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	expected := [][]uint64{{1, 2}, {7, 8}, {15}}
+	if !slices.EqualFunc(pages, expected, slices.Equal) {
+		t.Errorf("expected: %v, actual: %v", expected, pages)
+	}
+
+	testCases := []struct {
+		name  string
+		token []byte
+		limit int
+		err   error
+	}{
+		{
+			"failure: zero limit",
+			nil,
+			0,
+			ErrInvalidPageLimit,
+		},
+		{
+			"failure: unknown version",
+			[]byte{0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			1,
+			ErrInvalidResumeToken,
+		},
+		{
+			"failure: index out of range",
+			[]byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10},
+			1,
+			ErrInvalidResumeToken,
+		},
+		{
+			"failure: truncated",
+			[]byte{0x01, 0x00},
+			1,
+			ErrInvalidResumeToken,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, _, err := tree.ScanLeaves(tc.token, tc.limit); err != tc.err {
+				t.Errorf("expected: %v, actual: %v", tc.err, err)
+			}
+		})
+	}
+}
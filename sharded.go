@@ -0,0 +1,379 @@
+package merkle
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash"
+	"sync"
+)
+
+const (
+	// ShardDepthMax bounds the shard depth, as every shard is allocated up
+	// front.
+	ShardDepthMax = 16
+)
+
+var (
+	ErrInvalidShardDepth = errors.New("invalid shard depth")
+)
+
+// ShardedTree splits the leaf space into 2^shardDepth subtrees keyed by the
+// top bits of the index, each guarded by its own lock, so that writers to
+// disjoint shards only contend on the small top tree over the shard roots.
+// Its root and proofs are identical to those of a Tree of the same depth.
+type ShardedTree struct {
+	depth      uint64
+	shardDepth uint64
+	indexMax   uint64
+	shards     []*Tree
+	shardMus   []sync.Mutex
+	top        *Tree
+	topMu      sync.RWMutex
+}
+
+// NewShardedTree returns a sharded tree of leaves whose shards are built
+// with opts. With WithNodeStore, the nodes of the i-th shard are written
+// under the prefix i (2 bytes, big endian), for LoadShardedTree.
+func NewShardedTree(newHasher func() hash.Hash, depth, shardDepth uint64, leaves map[uint64][]byte, opts ...Option) (*ShardedTree, error) {
+	stree, err := newShardedTree(depth, shardDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	subDepth := depth - shardDepth
+
+	shardLeaves := make([]map[uint64][]byte, 1<<shardDepth)
+	for index, leaf := range leaves {
+		if index > stree.indexMax {
+			return nil, ErrTooLargeLeafIndex
+		}
+		i := index >> subDepth
+		if shardLeaves[i] == nil {
+			shardLeaves[i] = map[uint64][]byte{}
+		}
+		shardLeaves[i][index&(1<<subDepth-1)] = leaf
+	}
+
+	store := optionStore(opts)
+	for i := range stree.shards {
+		shardOpts := opts
+		if store != nil {
+			shardOpts = append(opts[:len(opts):len(opts)], WithNodeStore(&prefixedStore{store, shardPrefix(uint64(i))}))
+		}
+		shard, err := NewTree(newHasher(), subDepth, shardLeaves[i], shardOpts...)
+		if err != nil {
+			return nil, err
+		}
+		stree.shards[i] = shard
+	}
+
+	if err := stree.buildTop(newHasher()); err != nil {
+		return nil, err
+	}
+	return stree, nil
+}
+
+// LoadShardedTree restores a sharded tree whose shards were written to
+// store by NewShardedTree with WithNodeStore, each shard loaded with opts
+// the way LoadTree does.
+func LoadShardedTree(newHasher func() hash.Hash, depth, shardDepth uint64, store NodeStore, opts ...Option) (*ShardedTree, error) {
+	stree, err := newShardedTree(depth, shardDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range stree.shards {
+		shard, err := LoadTree(newHasher(), depth-shardDepth, &prefixedStore{store, shardPrefix(uint64(i))}, opts...)
+		if err != nil {
+			return nil, err
+		}
+		stree.shards[i] = shard
+	}
+
+	if err := stree.buildTop(newHasher()); err != nil {
+		return nil, err
+	}
+	return stree, nil
+}
+
+func newShardedTree(depth, shardDepth uint64) (*ShardedTree, error) {
+	if depth > DepthMax {
+		return nil, ErrTooLargeTreeDepth
+	}
+	if shardDepth == 0 || shardDepth >= depth || shardDepth > ShardDepthMax {
+		return nil, ErrInvalidShardDepth
+	}
+
+	return &ShardedTree{
+		depth:      depth,
+		shardDepth: shardDepth,
+		indexMax:   ^uint64(0) >> (DepthMax - depth),
+		shards:     make([]*Tree, 1<<shardDepth),
+		shardMus:   make([]sync.Mutex, 1<<shardDepth),
+	}, nil
+}
+
+// buildTop builds the top tree over the roots of the shards.
+func (stree *ShardedTree) buildTop(hasher hash.Hash) error {
+	top, err := newTopTree(hasher, stree.shardDepth, stree.shards[0])
+	if err != nil {
+		return err
+	}
+	for i, shard := range stree.shards {
+		if root, ok := shard.levels[0].get(0); ok {
+			if err := top.setLeafNode(uint64(i), root); err != nil {
+				return err
+			}
+		}
+	}
+	stree.top = top
+
+	return nil
+}
+
+func (stree *ShardedTree) Root() Root {
+	stree.topMu.RLock()
+	defer stree.topMu.RUnlock()
+
+	return stree.top.Root()
+}
+
+func (stree *ShardedTree) Update(index uint64, leaf []byte) error {
+	return stree.write(index, func(shard *Tree, subIndex uint64) error {
+		return shard.Update(subIndex, leaf)
+	})
+}
+
+func (stree *ShardedTree) Delete(index uint64) error {
+	return stree.write(index, func(shard *Tree, subIndex uint64) error {
+		return shard.Delete(subIndex)
+	})
+}
+
+func (stree *ShardedTree) write(index uint64, f func(*Tree, uint64) error) error {
+	if index > stree.indexMax {
+		return ErrTooLargeLeafIndex
+	}
+
+	i, subIndex := stree.split(index)
+
+	stree.shardMus[i].Lock()
+	defer stree.shardMus[i].Unlock()
+
+	shard := stree.shards[i]
+	if err := f(shard, subIndex); err != nil {
+		return err
+	}
+
+	stree.topMu.Lock()
+	defer stree.topMu.Unlock()
+
+	root, _ := shard.levels[0].get(0)
+	return stree.top.setLeafNode(i, root)
+}
+
+func (stree *ShardedTree) CreateMembershipProof(index uint64) ([]byte, error) {
+	if index > stree.indexMax {
+		return nil, ErrTooLargeLeafIndex
+	}
+
+	i, subIndex := stree.split(index)
+
+	stree.shardMus[i].Lock()
+	defer stree.shardMus[i].Unlock()
+
+	shardProof, err := stree.shards[i].CreateMembershipProof(subIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	stree.topMu.RLock()
+	defer stree.topMu.RUnlock()
+
+	topProof, err := stree.top.CreateMembershipProof(i)
+	if err != nil {
+		return nil, err
+	}
+
+	return concatProofs(shardProof, topProof, stree.depth-stree.shardDepth), nil
+}
+
+// CreateSortedPairProof is Tree.CreateSortedPairProof for a sharded tree
+// built with WithSortedPairs.
+func (stree *ShardedTree) CreateSortedPairProof(index uint64) ([][]byte, error) {
+	if index > stree.indexMax {
+		return nil, ErrTooLargeLeafIndex
+	}
+
+	i, subIndex := stree.split(index)
+
+	stree.shardMus[i].Lock()
+	defer stree.shardMus[i].Unlock()
+
+	proof, err := stree.shards[i].CreateSortedPairProof(subIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	stree.topMu.RLock()
+	defer stree.topMu.RUnlock()
+
+	topProof, err := stree.top.CreateSortedPairProof(i)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(proof, topProof...), nil
+}
+
+// ShardRoot returns the root of the i-th shard.
+func (stree *ShardedTree) ShardRoot(i uint64) (Root, error) {
+	if i >= uint64(len(stree.shards)) {
+		return nil, ErrTooLargeNodeIndex
+	}
+
+	stree.shardMus[i].Lock()
+	defer stree.shardMus[i].Unlock()
+
+	return stree.shards[i].Root(), nil
+}
+
+func (stree *ShardedTree) split(index uint64) (uint64, uint64) {
+	subDepth := stree.depth - stree.shardDepth
+	return index >> subDepth, index & (1<<subDepth - 1)
+}
+
+// concatProofs joins a proof of a subtree of the given depth with a proof of
+// the subtree root in the tree above it.
+func concatProofs(lower, upper []byte, lowerDepth uint64) []byte {
+	proofHead := binary.BigEndian.Uint64(lower[:proofHeadSize]) |
+		binary.BigEndian.Uint64(upper[:proofHeadSize])<<lowerDepth
+
+	proof := make([]byte, proofHeadSize, uint64(len(lower)+len(upper))-proofHeadSize)
+	binary.BigEndian.PutUint64(proof, proofHead)
+	proof = append(proof, lower[proofHeadSize:]...)
+	proof = append(proof, upper[proofHeadSize:]...)
+
+	return proof
+}
+
+// shardPrefix returns the prefix of the keys of the i-th shard in the store
+// of a sharded tree.
+func shardPrefix(i uint64) []byte {
+	return binary.BigEndian.AppendUint16(nil, uint16(i))
+}
+
+// optionStore returns the store that opts attach a tree to, if any.
+func optionStore(opts []Option) NodeStore {
+	var tree Tree
+	for _, opt := range opts {
+		opt(&tree)
+	}
+	return tree.store
+}
+
+// newTopTree returns a tree over the roots of shards like shard, whose leaf
+// level holds the roots themselves with the root of an empty shard as the
+// default, and whose nodes are hashed as those of shard at the heights they
+// have in the whole tree.
+func newTopTree(hasher hash.Hash, shardDepth uint64, shard *Tree) (*Tree, error) {
+	top, err := NewTree(hasher, shardDepth, nil)
+	if err != nil {
+		return nil, err
+	}
+	top.levelTweak = shard.levelTweak
+	top.sortedPairs = shard.sortedPairs
+	top.heightOffset = shard.depth
+
+	top.defaultNodes[shardDepth] = shard.defaultNodes[0]
+	for d := shardDepth; d > 0; d-- {
+		node, err := top.pairHash(shardDepth-d+1, top.defaultNodes[d], top.defaultNodes[d])
+		if err != nil {
+			return nil, err
+		}
+		top.defaultNodes[d-1] = node
+	}
+
+	return top, nil
+}
+
+// ShardTop is the top tree of a sharded tree on its own, over the roots of
+// 2^shardDepth shards owned independently, e.g. by separate services each
+// keeping a Tree of depth depth-shardDepth. Its root, and the proofs
+// composed with ComposeShardProof, are identical to those of a Tree of the
+// given depth holding all the leaves, built with the same options as the
+// trees of the shards.
+type ShardTop struct {
+	tree     *Tree
+	subDepth uint64
+}
+
+// NewShardTop returns the top tree over shardRoots of shards built with
+// opts, of which only those changing how the nodes are hashed matter.
+func NewShardTop(hasher hash.Hash, depth, shardDepth uint64, shardRoots map[uint64][]byte, opts ...Option) (*ShardTop, error) {
+	if depth > DepthMax {
+		return nil, ErrTooLargeTreeDepth
+	}
+	if shardDepth == 0 || shardDepth >= depth {
+		return nil, ErrInvalidShardDepth
+	}
+
+	shard, err := NewTree(hasher, depth-shardDepth, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := newTopTree(hasher, shardDepth, shard)
+	if err != nil {
+		return nil, err
+	}
+
+	top := &ShardTop{
+		tree:     tree,
+		subDepth: depth - shardDepth,
+	}
+	for _, i := range sortedIndices(shardRoots) {
+		if err := top.SetShardRoot(i, shardRoots[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return top, nil
+}
+
+// SetShardRoot records the root of the i-th shard, where nil stands for an
+// empty shard.
+func (top *ShardTop) SetShardRoot(i uint64, root Root) error {
+	if i > top.tree.indexMax {
+		return ErrTooLargeLeafIndex
+	}
+	if root != nil && uint64(len(root)) != top.tree.hashSize {
+		return ErrInvalidHashSize
+	}
+	if root.Equal(top.tree.defaultNodes[top.tree.depth]) {
+		root = nil
+	}
+	return top.tree.writeLeafNode(i, top.tree.ingest(root), nil)
+}
+
+func (top *ShardTop) Root() Root {
+	return top.tree.Root()
+}
+
+// CreateShardProof returns the proof of the root of the i-th shard in the
+// top tree, to be composed with proofs created by the owner of the shard.
+func (top *ShardTop) CreateShardProof(i uint64) ([]byte, error) {
+	return top.tree.CreateMembershipProof(i)
+}
+
+// ComposeShardProof joins a proof created by the owner of a shard with the
+// proof of the shard root from CreateShardProof into a proof of the whole
+// tree.
+func (top *ShardTop) ComposeShardProof(shardProof, topProof []byte) ([]byte, error) {
+	if uint64(len(shardProof)) < proofHeadSize || binary.BigEndian.Uint64(shardProof[:proofHeadSize])>>top.subDepth != 0 {
+		return nil, ErrInvalidProofSize
+	}
+	if err := top.tree.SanitizeProof(topProof); err != nil {
+		return nil, err
+	}
+	return concatProofs(shardProof, topProof, top.subDepth), nil
+}
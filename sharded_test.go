@@ -0,0 +1,308 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestShardedTree(t *testing.T) {
+	leaves := map[uint64][]byte{
+		0:  []byte{0x00},
+		3:  []byte{0x03},
+		9:  []byte{0x09},
+		15: []byte{0x0f},
+	}
+
+	if _, err := NewShardedTree(sha256.New, 4, 4, leaves); err != ErrInvalidShardDepth {
+		t.Errorf("expected: %v, actual: %v", ErrInvalidShardDepth, err)
+	}
+	if _, err := NewShardedTree(sha256.New, 64, 40, nil); err != ErrInvalidShardDepth {
+		t.Errorf("expected: %v, actual: %v", ErrInvalidShardDepth, err)
+	}
+	if _, err := NewShardedTree(sha256.New, 4, 2, map[uint64][]byte{16: nil}); err != ErrTooLargeLeafIndex {
+		t.Errorf("expected: %v, actual: %v", ErrTooLargeLeafIndex, err)
+	}
+
+	stree, err := NewShardedTree(sha256.New, 4, 2, leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := NewTree(sha256.New(), 4, leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func() {
+		if !bytes.Equal(stree.Root(), tree.Root()) {
+			t.Errorf("expected: %x, actual: %x", tree.Root(), stree.Root())
+		}
+		for index := uint64(0); index <= tree.indexMax; index++ {
+			expected, err := tree.CreateMembershipProof(index)
+			if err != nil {
+				t.Fatal(err)
+			}
+			proof, err := stree.CreateMembershipProof(index)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(proof, expected) {
+				t.Errorf("index %d: expected: %x, actual: %x", index, expected, proof)
+			}
+		}
+	}
+	check()
+
+	var wg sync.WaitGroup
+	for index := uint64(0); index <= tree.indexMax; index += 2 {
+		wg.Add(1)
+		go func(index uint64) {
+			defer wg.Done()
+			if err := stree.Update(index, []byte{byte(index)}); err != nil {
+				t.Error(err)
+			}
+		}(index)
+		if err := tree.Update(index, []byte{byte(index)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+	check()
+
+	for _, index := range []uint64{9, 15} {
+		if err := stree.Delete(index); err != nil {
+			t.Fatal(err)
+		}
+		if err := tree.Delete(index); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check()
+}
+
+func TestShardTop(t *testing.T) {
+	leaves := map[uint64][]byte{
+		0:  []byte{0x00},
+		3:  []byte{0x03},
+		9:  []byte{0x09},
+		15: []byte{0x0f},
+	}
+
+	tree, err := NewTree(sha256.New(), 4, leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stree, err := NewShardedTree(sha256.New, 4, 2, leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every shard is owned by a tree of its own
+	shards := make([]*Tree, 4)
+	shardRoots := map[uint64][]byte{}
+	for i := range shards {
+		shardLeaves := map[uint64][]byte{}
+		for index, leaf := range leaves {
+			if index>>2 == uint64(i) {
+				shardLeaves[index&3] = leaf
+			}
+		}
+		if shards[i], err = NewTree(sha256.New(), 2, shardLeaves); err != nil {
+			t.Fatal(err)
+		}
+		shardRoots[uint64(i)] = shards[i].Root()
+
+		root, err := stree.ShardRoot(uint64(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !root.Equal(shards[i].Root()) {
+			t.Errorf("shard %d: expected: %x, actual: %x", i, shards[i].Root(), root)
+		}
+	}
+
+	if _, err := NewShardTop(sha256.New(), 4, 4, nil); err != ErrInvalidShardDepth {
+		t.Errorf("expected: %v, actual: %v", ErrInvalidShardDepth, err)
+	}
+
+	top, err := NewShardTop(sha256.New(), 4, 2, shardRoots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !top.Root().Equal(tree.Root()) {
+		t.Errorf("expected: %x, actual: %x", tree.Root(), top.Root())
+	}
+
+	for index := uint64(0); index <= tree.indexMax; index++ {
+		expected, err := tree.CreateMembershipProof(index)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		shardProof, err := shards[index>>2].CreateMembershipProof(index & 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		topProof, err := top.CreateShardProof(index >> 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		proof, err := top.ComposeShardProof(shardProof, topProof)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(proof, expected) {
+			t.Errorf("index %d: expected: %x, actual: %x", index, expected, proof)
+		}
+	}
+
+	if err := shards[2].Delete(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := top.SetShardRoot(2, shards[2].Root()); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Delete(9); err != nil {
+		t.Fatal(err)
+	}
+	if !top.Root().Equal(tree.Root()) {
+		t.Errorf("expected: %x, actual: %x", tree.Root(), top.Root())
+	}
+}
+
+func TestShardedTree_hashingOptions(t *testing.T) {
+	leaves := map[uint64][]byte{
+		0:  bytes.Repeat([]byte{0x00}, 32),
+		3:  bytes.Repeat([]byte{0x03}, 32),
+		9:  bytes.Repeat([]byte{0x09}, 32),
+		15: bytes.Repeat([]byte{0x0f}, 32),
+	}
+
+	testCases := []struct {
+		name string
+		opt  Option
+	}{
+		{"success: ssz", WithSSZ()},
+		{"success: level tweak", WithLevelTweak()},
+		{"success: sorted pairs", WithSortedPairs()},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tree, err := NewTree(sha256.New(), 4, leaves, tc.opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stree, err := NewShardedTree(sha256.New, 4, 2, leaves, tc.opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !stree.Root().Equal(tree.Root()) {
+				t.Errorf("expected: %x, actual: %x", tree.Root(), stree.Root())
+			}
+
+			shardRoots := map[uint64][]byte{}
+			for i := uint64(0); i < 4; i++ {
+				root, err := stree.ShardRoot(i)
+				if err != nil {
+					t.Fatal(err)
+				}
+				shardRoots[i] = root
+			}
+			top, err := NewShardTop(sha256.New(), 4, 2, shardRoots, tc.opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !top.Root().Equal(tree.Root()) {
+				t.Errorf("expected: %x, actual: %x", tree.Root(), top.Root())
+			}
+
+			for index := uint64(0); index <= tree.indexMax; index++ {
+				expected, err := tree.CreateMembershipProof(index)
+				if err != nil {
+					t.Fatal(err)
+				}
+				proof, err := stree.CreateMembershipProof(index)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(proof, expected) {
+					t.Errorf("index %d: expected: %x, actual: %x", index, expected, proof)
+				}
+
+				expectedSiblings, expectedErr := tree.CreateSortedPairProof(index)
+				siblings, err := stree.CreateSortedPairProof(index)
+				if err != expectedErr {
+					t.Errorf("index %d: expected: %v, actual: %v", index, expectedErr, err)
+				}
+				if !reflect.DeepEqual(siblings, expectedSiblings) {
+					t.Errorf("index %d: expected: %x, actual: %x", index, expectedSiblings, siblings)
+				}
+			}
+
+			if err := stree.Delete(9); err != nil {
+				t.Fatal(err)
+			}
+			if err := tree.Delete(9); err != nil {
+				t.Fatal(err)
+			}
+			if !stree.Root().Equal(tree.Root()) {
+				t.Errorf("expected: %x, actual: %x", tree.Root(), stree.Root())
+			}
+		})
+	}
+}
+
+func TestLoadShardedTree(t *testing.T) {
+	leaves := map[uint64][]byte{
+		0:  []byte{0x00},
+		3:  []byte{0x03},
+		9:  []byte{0x09},
+		15: []byte{0x0f},
+	}
+
+	store, err := NewFileStore(t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stree, err := NewShardedTree(sha256.New, 4, 2, leaves, WithNodeStore(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stree.Update(6, []byte{0x06}); err != nil {
+		t.Fatal(err)
+	}
+	if err := stree.Delete(9); err != nil {
+		t.Fatal(err)
+	}
+
+	leaves[6] = []byte{0x06}
+	delete(leaves, 9)
+	tree, err := NewTree(sha256.New(), 4, leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadShardedTree(sha256.New, 4, 2, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Root().Equal(tree.Root()) {
+		t.Errorf("expected: %x, actual: %x", tree.Root(), loaded.Root())
+	}
+	for i := uint64(0); i < 4; i++ {
+		expected, err := stree.ShardRoot(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		root, err := loaded.ShardRoot(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !root.Equal(expected) {
+			t.Errorf("shard %d: expected: %x, actual: %x", i, expected, root)
+		}
+	}
+}
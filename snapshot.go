@@ -0,0 +1,29 @@
+package merkle
+
+import (
+	"hash"
+)
+
+// Snapshot returns a read-only copy of the tree as it is now, so that a
+// long-running reader such as an export sees a consistent tree while
+// writers go on. Taking it copies the levels of the tree, the nodes
+// themselves being shared as they are never modified in place: it costs
+// time and memory proportional to the number of nodes, and must not be
+// concurrent with writes. Once taken, it may be read concurrently with
+// writes to the tree. Like ReadOnly, it is not attached to the node store.
+//
+// The snapshot hashes with hashers from the pool of the tree if it has one
+// (see WithHasherPool), and with a copy of the hasher of the tree otherwise,
+// which fails with ErrUncopyableHasher as Copy does.
+func (tree *Tree) Snapshot() (ReadOnlyTree, error) {
+	var hasher hash.Hash
+	if tree.hasherPool != nil {
+		hasher = tree.hasherPool.Get().(hash.Hash)
+	} else {
+		var err error
+		if hasher, err = tree.copyHasher(); err != nil {
+			return ReadOnlyTree{}, err
+		}
+	}
+	return tree.clone(hasher).ReadOnly(), nil
+}
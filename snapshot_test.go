@@ -0,0 +1,88 @@
+package merkle
+
+import (
+	"crypto/sha256"
+	"hash"
+	"sync"
+	"testing"
+)
+
+func TestTree_Snapshot(t *testing.T) {
+	tree := newTestTree(t)
+	root := tree.Root()
+
+	snapshot, err := tree.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := uint64(0); i < 8; i++ {
+			if err := tree.Update(i, []byte{byte(i)}); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		if !snapshot.Root().Equal(root) {
+			t.Fatalf("expected: %x, actual: %x", root, snapshot.Root())
+		}
+		proof, err := snapshot.CreateMembershipProof(3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := snapshot.VerifyMembershipProof(3, proof); err != nil || !ok {
+			t.Fatalf("expected: %t, actual: %t (%v)", true, ok, err)
+		}
+	}
+	wg.Wait()
+
+	if snapshot.HasLeaf(1) {
+		t.Errorf("expected: %t, actual: %t", false, true)
+	}
+	if tree.Root().Equal(root) {
+		t.Errorf("expected the tree to move on from %x", root)
+	}
+}
+
+// uncopyableHasher hides the Clone method of the hasher it wraps.
+type uncopyableHasher struct {
+	hash.Hash
+}
+
+func TestTree_Snapshot_hasherPool(t *testing.T) {
+	// the hasher cannot be copied, but the pool provides the snapshot with
+	// hashers of its own
+	leaves := map[uint64][]byte{
+		0: []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		3: []byte{0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03},
+	}
+	uncopyable, err := NewTree(uncopyableHasher{sha256.New()}, 3, leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uncopyable.Snapshot(); err != ErrUncopyableHasher {
+		t.Errorf("expected: %v, actual: %v", ErrUncopyableHasher, err)
+	}
+
+	tree, err := NewTree(uncopyableHasher{sha256.New()}, 3, leaves, WithHasherPool(sha256.New))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := tree.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := snapshot.CreateMembershipProof(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := snapshot.VerifyMembershipProof(3, proof); err != nil || !ok {
+		t.Errorf("expected: %t, actual: %t (%v)", true, ok, err)
+	}
+}
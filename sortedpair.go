@@ -0,0 +1,68 @@
+package merkle
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash"
+)
+
+var (
+	ErrNotSortedPairTree = errors.New("not a sorted pair tree")
+)
+
+// CreateSortedPairProof returns the siblings on the path of the leaf at
+// index from the leaf level up, default nodes included, as the proof taken
+// by MerkleProof of OpenZeppelin, which needs no index as every pair is
+// hashed in sorted order. The leaf it takes is the leaf node, i.e. the hash
+// of the leaf, so that hashing abi.encode of a claim with keccak256 before
+// updating the tree gives the double-hashed leaves of StandardMerkleTree.
+//
+// The tree must be built with WithSortedPairs and without WithLevelTweak.
+func (tree *Tree) CreateSortedPairProof(index uint64) ([][]byte, error) {
+	if !tree.sortedPairs || tree.levelTweak {
+		return nil, ErrNotSortedPairTree
+	}
+	return tree.CreateFixedLengthProof(index)
+}
+
+// VerifySortedPairProof reports whether proof links the leaf node to root,
+// following processProof of MerkleProof of OpenZeppelin. It needs no tree,
+// so that proofs produced by other tooling can be checked as well.
+func VerifySortedPairProof(hasher hash.Hash, leafNode []byte, proof [][]byte, root Root) (bool, error) {
+	node := leafNode
+	for _, siblingNode := range proof {
+		left, right := node, siblingNode
+		if bytes.Compare(left, right) > 0 {
+			left, right = right, left
+		}
+
+		hasher.Reset()
+		if _, err := hasher.Write(left); err != nil {
+			return false, err
+		}
+		if _, err := hasher.Write(right); err != nil {
+			return false, err
+		}
+		node = hasher.Sum(nil)
+	}
+
+	return root.Equal(node), nil
+}
+
+// EncodeSortedPairProof converts a sorted pair proof of 32 byte nodes into
+// the ABI encoding of bytes32[], the argument of MerkleProof.verify.
+func EncodeSortedPairProof(proof [][]byte) ([]byte, error) {
+	b := make([]byte, 2*evmWordSize, (2+len(proof))*evmWordSize)
+	b[evmWordSize-1] = evmWordSize
+	binary.BigEndian.PutUint64(b[2*evmWordSize-8:], uint64(len(proof)))
+
+	for _, siblingNode := range proof {
+		if len(siblingNode) != evmWordSize {
+			return nil, ErrUnsupportedHashSize
+		}
+		b = append(b, siblingNode...)
+	}
+
+	return b, nil
+}
@@ -0,0 +1,130 @@
+package merkle
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestTree_WithSortedPairs(t *testing.T) {
+	tree, err := NewTree(sha256.New(), 4, nil, WithSortedPairs())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaves := map[uint64][]byte{
+		0:  {0x00},
+		3:  {0x03},
+		6:  {0x06},
+		15: {0x0f},
+	}
+	for index, leaf := range leaves {
+		if err := tree.Update(index, leaf); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for index, leaf := range leaves {
+		proof, err := tree.CreateMembershipProof(index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := tree.VerifyMembershipProof(index, proof); err != nil || !ok {
+			t.Errorf("expected: %t, actual: %t (%v)", true, ok, err)
+		}
+
+		siblings, err := tree.CreateSortedPairProof(index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		leafNode := sha256.Sum256(leaf)
+		if ok, err := VerifySortedPairProof(sha256.New(), leafNode[:], siblings, tree.Root()); err != nil || !ok {
+			t.Errorf("expected: %t, actual: %t (%v)", true, ok, err)
+		}
+		if ok, err := VerifySortedPairProof(sha256.New(), leafNode[:], siblings[1:], tree.Root()); err != nil || ok {
+			t.Errorf("expected: %t, actual: %t (%v)", false, ok, err)
+		}
+	}
+
+	plain, err := NewTree(sha256.New(), 4, leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain.Root().Equal(tree.Root()) {
+		t.Errorf("expected roots to differ: %s", tree.Root().Hex())
+	}
+	if _, err := plain.CreateSortedPairProof(0); err != ErrNotSortedPairTree {
+		t.Errorf("expected: %v, actual: %v", ErrNotSortedPairTree, err)
+	}
+
+	tweaked, err := NewTree(sha256.New(), 4, leaves, WithSortedPairs(), WithLevelTweak())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tweaked.CreateSortedPairProof(0); err != ErrNotSortedPairTree {
+		t.Errorf("expected: %v, actual: %v", ErrNotSortedPairTree, err)
+	}
+}
+
+func TestEncodeSortedPairProof(t *testing.T) {
+	type input struct {
+		proofHexes []string
+	}
+	type output struct {
+		encodedHex string
+		err        error
+	}
+	testCases := []struct {
+		name string
+		in   input
+		out  output
+	}{
+		{
+			"failure: unsupported hash size",
+			input{
+				[]string{"00000000000000000000000000000000000000000000000000000000000000"},
+			},
+			output{
+				"",
+				ErrUnsupportedHashSize,
+			},
+		},
+		{
+			"success",
+			input{
+				[]string{
+					"af5570f5a1810b7af78caf4bc70a660f0df51e42baf91d4de5b2328de0e83dfc",
+					"1b6d2a8dca8d96e6dfa28a826037521bb587d3cb435c44c90139e87a7a4fa164",
+				},
+			},
+			output{
+				"0000000000000000000000000000000000000000000000000000000000000020" +
+					"0000000000000000000000000000000000000000000000000000000000000002" +
+					"af5570f5a1810b7af78caf4bc70a660f0df51e42baf91d4de5b2328de0e83dfc" +
+					"1b6d2a8dca8d96e6dfa28a826037521bb587d3cb435c44c90139e87a7a4fa164",
+				nil,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			proof := make([][]byte, len(tc.in.proofHexes))
+			for i, proofHex := range tc.in.proofHexes {
+				b, err := hex.DecodeString(proofHex)
+				if err != nil {
+					t.Fatal(err)
+				}
+				proof[i] = b
+			}
+
+			encoded, err := EncodeSortedPairProof(proof)
+			if err != tc.out.err {
+				t.Fatalf("expected: %v, actual: %v", tc.out.err, err)
+			}
+			if encodedHex := hex.EncodeToString(encoded); encodedHex != tc.out.encodedHex {
+				t.Errorf("expected: %s, actual: %s", tc.out.encodedHex, encodedHex)
+			}
+		})
+	}
+}
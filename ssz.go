@@ -0,0 +1,55 @@
+package merkle
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash"
+)
+
+var (
+	ErrInvalidChunkSize = errors.New("invalid chunk size")
+)
+
+// MixInLength returns the root of an SSZ list, i.e. the root of the tree
+// hashed together with the length of the list as a little-endian integer of
+// the hash size.
+func (tree *Tree) MixInLength(length uint64) (Root, error) {
+	lengthChunk := make([]byte, tree.hashSize)
+	binary.LittleEndian.PutUint64(lengthChunk, length)
+
+	// the mix-in sits right above the root
+	return tree.pairHash(tree.depth+1, tree.Root(), lengthChunk)
+}
+
+// SSZBranch returns the siblings on the path of the leaf at index from the
+// leaf level up, default nodes included, as expected by the
+// is_valid_merkle_branch function of the Ethereum consensus specs.
+func (tree *Tree) SSZBranch(index uint64) ([][]byte, error) {
+	return tree.CreateFixedLengthProof(index)
+}
+
+// VerifySSZBranch reports whether branch links the 32-byte chunk leaf at
+// index to root, following is_valid_merkle_branch of the Ethereum consensus
+// specs with the depth given by the length of branch. It needs no tree, so
+// that SSZ proofs produced by other tooling can be checked as well.
+func VerifySSZBranch(hasher hash.Hash, leaf []byte, branch [][]byte, index uint64, root Root) (bool, error) {
+	node := leaf
+	for _, siblingNode := range branch {
+		left, right := node, siblingNode
+		if index%2 == 1 {
+			left, right = siblingNode, node
+		}
+
+		hasher.Reset()
+		if _, err := hasher.Write(left); err != nil {
+			return false, err
+		}
+		if _, err := hasher.Write(right); err != nil {
+			return false, err
+		}
+		node = hasher.Sum(nil)
+		index /= 2
+	}
+
+	return root.Equal(node), nil
+}
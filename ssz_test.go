@@ -0,0 +1,69 @@
+package merkle
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+)
+
+func TestTree_WithSSZ(t *testing.T) {
+	tree, err := NewTree(sha256.New(), 3, nil, WithSSZ())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// zero hashes of the deposit contract
+	if rootHex := tree.Root().Hex(); rootHex != "c78009fdf07fc56a11f122370658a353aaa542ed63e44c4bc15ff4cd105ab33c" {
+		t.Errorf("expected: %s, actual: %s", "c78009fdf07fc56a11f122370658a353aaa542ed63e44c4bc15ff4cd105ab33c", rootHex)
+	}
+
+	if err := tree.Update(0, []byte{0x01}); err != ErrInvalidChunkSize {
+		t.Errorf("expected: %v, actual: %v", ErrInvalidChunkSize, err)
+	}
+
+	chunk := make([]byte, 32)
+	chunk[0] = 0x05
+	if err := tree.Update(5, chunk); err != nil {
+		t.Fatal(err)
+	}
+	node, err := tree.Node(3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(node) != string(chunk) {
+		t.Errorf("expected: %x, actual: %x", chunk, node)
+	}
+
+	branch, err := tree.SSZBranch(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(branch) != 3 {
+		t.Fatalf("expected: %d, actual: %d", 3, len(branch))
+	}
+	if ok, err := VerifySSZBranch(sha256.New(), chunk, branch, 5, tree.Root()); err != nil || !ok {
+		t.Errorf("expected: %t, actual: %t (%v)", true, ok, err)
+	}
+	if ok, err := VerifySSZBranch(sha256.New(), chunk, branch, 4, tree.Root()); err != nil || ok {
+		t.Errorf("expected: %t, actual: %t (%v)", false, ok, err)
+	}
+}
+
+func TestTree_MixInLength(t *testing.T) {
+	tree, err := NewTree(sha256.New(), 3, nil, WithSSZ())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := tree.MixInLength(258)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lengthChunk := make([]byte, 32)
+	binary.LittleEndian.PutUint16(lengthChunk, 258)
+	expected := sha256.Sum256(append(append([]byte(nil), tree.Root()...), lengthChunk...))
+	if !root.Equal(expected[:]) {
+		t.Errorf("expected: %x, actual: %x", expected, root)
+	}
+}
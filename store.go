@@ -0,0 +1,94 @@
+package merkle
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash"
+)
+
+const (
+	nodeKeySize = 9
+)
+
+var (
+	ErrNodeNotFound   = errors.New("node not found")
+	ErrInvalidNodeKey = errors.New("invalid node key")
+)
+
+// NodeStore persists the nodes of a tree. Iterate must visit the keys in
+// ascending byte order and stop at the first error returned by f.
+type NodeStore interface {
+	Get(key []byte) ([]byte, error)
+	Put(key, value []byte) error
+	Delete(key []byte) error
+	Iterate(f func(key, value []byte) error) error
+}
+
+// nodeKey encodes a node position as depth (1 byte) || index (8 bytes).
+func nodeKey(depth, index uint64) []byte {
+	key := make([]byte, nodeKeySize)
+	key[0] = byte(depth)
+	binary.BigEndian.PutUint64(key[1:], index)
+	return key
+}
+
+func parseNodeKey(key []byte) (uint64, uint64, error) {
+	if len(key) != nodeKeySize {
+		return 0, 0, ErrInvalidNodeKey
+	}
+	return uint64(key[0]), binary.BigEndian.Uint64(key[1:]), nil
+}
+
+// LoadTree restores a tree whose nodes were written to store, along with
+// the salts of its leaves if it salts them. The store keeps receiving the
+// writes made to the returned tree.
+func LoadTree(hasher hash.Hash, depth uint64, store NodeStore, opts ...Option) (*Tree, error) {
+	tree, err := NewTree(hasher, depth, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var span Span
+	if tree.tracer != nil {
+		span = tree.tracer.Start("merkle.store.iterate")
+	}
+	err = store.Iterate(func(key, value []byte) error {
+		if index, ok := parseSaltKey(key); ok {
+			if tree.salts == nil {
+				return ErrSaltedLeavesDisabled
+			}
+			if index > tree.indexMax {
+				return ErrInvalidNodeKey
+			}
+			if len(value) != SaltSize {
+				return ErrInvalidSaltSize
+			}
+			tree.salts[index] = append([]byte(nil), value...)
+			return nil
+		}
+
+		d, index, err := parseNodeKey(key)
+		if err != nil {
+			return err
+		}
+		if d > tree.depth || index > tree.indexMax>>(tree.depth-d) {
+			return ErrInvalidNodeKey
+		}
+
+		if uint64(len(value)) != tree.hashSize {
+			return ErrInvalidNodeSize
+		}
+		tree.levels[d].put(index, tree.ingest(value))
+		return nil
+	})
+	if span != nil {
+		span.End(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tree.store = store
+
+	return tree, nil
+}
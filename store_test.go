@@ -0,0 +1,95 @@
+package merkle_test
+
+import (
+	"compress/flate"
+	"testing"
+
+	merkle "github.com/m0t0k1ch1/sparse-merkle-tree"
+	"github.com/m0t0k1ch1/sparse-merkle-tree/storetest"
+)
+
+func newTestFileStore(t *testing.T, dir string) merkle.NodeStore {
+	store, err := merkle.NewFileStore(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestFileStore_NodeStore(t *testing.T) {
+	storetest.TestNodeStore(t, func(t *testing.T) (merkle.NodeStore, func() merkle.NodeStore) {
+		dir := t.TempDir()
+		return newTestFileStore(t, dir), func() merkle.NodeStore {
+			return newTestFileStore(t, dir)
+		}
+	})
+}
+
+func TestEncryptedStore_NodeStore(t *testing.T) {
+	newStore := func(t *testing.T, dir string) merkle.NodeStore {
+		store, err := merkle.NewEncryptedStore(newTestFileStore(t, dir), make([]byte, 32))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return store
+	}
+
+	storetest.TestNodeStore(t, func(t *testing.T) (merkle.NodeStore, func() merkle.NodeStore) {
+		dir := t.TempDir()
+		return newStore(t, dir), func() merkle.NodeStore {
+			return newStore(t, dir)
+		}
+	})
+}
+
+func TestCompressedStore_NodeStore(t *testing.T) {
+	newStore := func(t *testing.T, dir string) merkle.NodeStore {
+		store, err := merkle.NewCompressedStore(newTestFileStore(t, dir), flate.DefaultCompression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return store
+	}
+
+	storetest.TestNodeStore(t, func(t *testing.T) (merkle.NodeStore, func() merkle.NodeStore) {
+		dir := t.TempDir()
+		return newStore(t, dir), func() merkle.NodeStore {
+			return newStore(t, dir)
+		}
+	})
+}
+
+func TestChecksumStore_NodeStore(t *testing.T) {
+	storetest.TestNodeStore(t, func(t *testing.T) (merkle.NodeStore, func() merkle.NodeStore) {
+		dir := t.TempDir()
+		return merkle.NewChecksumStore(newTestFileStore(t, dir)), func() merkle.NodeStore {
+			return merkle.NewChecksumStore(newTestFileStore(t, dir))
+		}
+	})
+}
+
+func TestCachedStore_NodeStore(t *testing.T) {
+	for _, policy := range []merkle.CachePolicy{
+		{MaxCachedBytes: 1 << 10},
+		{WriteBack: true, MaxDirtyBytes: 64, MaxCachedBytes: 64},
+	} {
+		newStore := func(t *testing.T, dir string) merkle.NodeStore {
+			store := merkle.NewCachedStore(newTestFileStore(t, dir), policy)
+			t.Cleanup(func() {
+				store.Close()
+			})
+			return store
+		}
+
+		storetest.TestNodeStore(t, func(t *testing.T) (merkle.NodeStore, func() merkle.NodeStore) {
+			dir := t.TempDir()
+			store := newStore(t, dir).(*merkle.CachedStore)
+			return store, func() merkle.NodeStore {
+				if err := store.Flush(); err != nil {
+					t.Fatal(err)
+				}
+				return newStore(t, dir)
+			}
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package merkle
+
+import (
+	"container/heap"
+	"hash"
+	"io"
+	"os"
+	"sort"
+)
+
+const (
+	// DefaultRunRecords is the number of records BuildStoreFromReader holds
+	// in memory at once unless told otherwise.
+	DefaultRunRecords = 1 << 20
+)
+
+// BuildStoreFromReader builds the tree of a stream of (index, leaf) records
+// into store without holding the tree in memory, for trees larger than RAM,
+// and returns its root. The tree can be opened with LoadTree afterwards, or
+// served from the store by other means. Like NewTreeFromReader, a later
+// record for the same index wins.
+//
+// The leaves are hashed as they are read and sorted by index in runs of up
+// to runRecords records, DefaultRunRecords if it is 0, spilled to temporary
+// files. The runs are then merged in index order, and the nodes are computed
+// bottom-up along the merged leaves, subtree after subtree, keeping one
+// pending node per level. Memory is thus bounded by runRecords and the
+// depth, whatever the size of the stream or the tree.
+//
+// The store must hold no other nodes, and opts apply as they do to NewTree
+// but must not include WithNodeStore. With WithSaltedLeaves, the salts are
+// written to store as well.
+func BuildStoreFromReader(hasher hash.Hash, depth uint64, r io.Reader, format RecordFormat, store NodeStore, runRecords int, opts ...Option) (Root, error) {
+	next, err := newRecordReader(r, format)
+	if err != nil {
+		return nil, err
+	}
+	if runRecords <= 0 {
+		runRecords = DefaultRunRecords
+	}
+
+	tree, err := NewTree(hasher, depth, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []*os.File
+	defer func() {
+		for _, run := range runs {
+			run.Close()
+			os.Remove(run.Name())
+		}
+	}()
+
+	entries := make([]LeafEntry, 0, runRecords)
+	for {
+		index, leaf, err := next()
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if err == nil {
+			if index > tree.indexMax {
+				return nil, ErrTooLargeLeafIndex
+			}
+			node, err := tree.runNode(leaf)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, LeafEntry{index, node})
+		}
+
+		if len(entries) == runRecords || (err == io.EOF && len(entries) > 0) {
+			run, werr := writeRun(entries)
+			if run != nil {
+				runs = append(runs, run)
+			}
+			if werr != nil {
+				return nil, werr
+			}
+			entries = entries[:0]
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+
+	builder := &storeBuilder{
+		tree:    tree,
+		store:   store,
+		pending: make([]*pendingNode, depth+1),
+	}
+	if err := mergeRuns(runs, func(index uint64, value []byte) error {
+		return builder.addLeaf(index, value)
+	}); err != nil {
+		return nil, err
+	}
+
+	return builder.finish()
+}
+
+// runNode returns the value a leaf is spilled to a run as: its node, or
+// its node followed by a fresh salt when the tree salts its leaves.
+func (tree *Tree) runNode(leaf []byte) ([]byte, error) {
+	if tree.salts == nil {
+		return tree.hashLeaf(leaf)
+	}
+
+	salt, err := newSalt()
+	if err != nil {
+		return nil, err
+	}
+	node, err := tree.hashTo(nil, append(salt[:SaltSize:SaltSize], leaf...))
+	if err != nil {
+		return nil, err
+	}
+
+	return append(node, salt...), nil
+}
+
+// writeRun sorts entries by index, keeping the last of the entries of an
+// index, and writes them to a temporary file in the binary record format,
+// rewound for reading.
+func writeRun(entries []LeafEntry) (*os.File, error) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Index < entries[j].Index
+	})
+
+	f, err := os.CreateTemp("", "merkle-run-*")
+	if err != nil {
+		return nil, err
+	}
+
+	write, flush, err := newRecordWriter(f, RecordFormatBinary)
+	if err != nil {
+		return f, err
+	}
+	for i, entry := range entries {
+		if i+1 < len(entries) && entries[i+1].Index == entry.Index {
+			continue
+		}
+		if err := write(entry.Index, entry.Node); err != nil {
+			return f, err
+		}
+	}
+	if err := flush(); err != nil {
+		return f, err
+	}
+
+	_, err = f.Seek(0, io.SeekStart)
+	return f, err
+}
+
+// runHead is the next record of a run being merged.
+type runHead struct {
+	run   int
+	index uint64
+	value []byte
+	next  recordReader
+}
+
+// runHeap orders the heads of the runs by index, and the later run first
+// among the heads of an index, as it wins.
+type runHeap []*runHead
+
+func (h runHeap) Len() int {
+	return len(h)
+}
+
+func (h runHeap) Less(i, j int) bool {
+	if h[i].index != h[j].index {
+		return h[i].index < h[j].index
+	}
+	return h[i].run > h[j].run
+}
+
+func (h runHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *runHeap) Push(x any) {
+	*h = append(*h, x.(*runHead))
+}
+
+func (h *runHeap) Pop() any {
+	old := *h
+	head := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return head
+}
+
+// mergeRuns calls f with the records of the runs in ascending order of
+// their indices, once per index with the record of the latest run.
+func mergeRuns(runs []*os.File, f func(index uint64, value []byte) error) error {
+	h := make(runHeap, 0, len(runs))
+	for i, run := range runs {
+		next, err := newRecordReader(run, RecordFormatBinary)
+		if err != nil {
+			return err
+		}
+		index, value, err := next()
+		if err == io.EOF {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		h = append(h, &runHead{i, index, value, next})
+	}
+	heap.Init(&h)
+
+	last, started := uint64(0), false
+	for h.Len() > 0 {
+		head := h[0]
+		if !started || head.index != last {
+			if err := f(head.index, head.value); err != nil {
+				return err
+			}
+			last, started = head.index, true
+		}
+
+		index, value, err := head.next()
+		if err == io.EOF {
+			heap.Pop(&h)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		head.index, head.value = index, value
+		heap.Fix(&h, 0)
+	}
+
+	return nil
+}
+
+// storeBuilder computes the nodes of a tree from its leaves given in
+// ascending order of their indices, writing every node to the store once it
+// is computed. It keeps, for every depth, the node whose sibling has not
+// been seen yet.
+type storeBuilder struct {
+	tree    *Tree
+	store   NodeStore
+	pending []*pendingNode
+}
+
+type pendingNode struct {
+	index uint64
+	node  []byte
+}
+
+func (builder *storeBuilder) addLeaf(index uint64, value []byte) error {
+	tree := builder.tree
+
+	node := value
+	if tree.salts != nil {
+		node = value[:tree.hashSize:tree.hashSize]
+		if err := builder.store.Put(saltKey(index), value[tree.hashSize:]); err != nil {
+			return err
+		}
+	}
+	return builder.add(tree.depth, index, node)
+}
+
+// add places the node at depth and index, which follows the nodes placed at
+// depth before, hashing it with its pending sibling, if any, and lifting a
+// pending node whose sibling will never come.
+func (builder *storeBuilder) add(d, index uint64, node []byte) error {
+	tree := builder.tree
+
+	if err := builder.store.Put(nodeKey(d, index), node); err != nil {
+		return err
+	}
+
+	for d > 0 {
+		pending := builder.pending[d]
+		if pending == nil {
+			builder.pending[d] = &pendingNode{index, node}
+			return nil
+		}
+		builder.pending[d] = nil
+
+		if pending.index == index^1 {
+			parentNode, err := tree.pairHash(tree.depth-d+1, pending.node, node)
+			if err != nil {
+				return err
+			}
+			d, index, node = d-1, index/2, parentNode
+			if err := builder.store.Put(nodeKey(d, index), node); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// the sibling of the pending node is past it, hence default
+		if err := builder.lift(d, pending); err != nil {
+			return err
+		}
+		builder.pending[d] = &pendingNode{index, node}
+		return nil
+	}
+
+	builder.pending[0] = &pendingNode{index, node}
+	return nil
+}
+
+// lift hashes the pending node at depth d with its default sibling and
+// places the parent.
+func (builder *storeBuilder) lift(d uint64, pending *pendingNode) error {
+	tree := builder.tree
+
+	leftNode, rightNode := pending.node, tree.defaultNodes[d]
+	if pending.index%2 == 1 {
+		leftNode, rightNode = rightNode, leftNode
+	}
+	parentNode, err := tree.pairHash(tree.depth-d+1, leftNode, rightNode)
+	if err != nil {
+		return err
+	}
+	return builder.add(d-1, pending.index/2, parentNode)
+}
+
+// finish lifts the pending nodes up to the root and returns it.
+func (builder *storeBuilder) finish() (Root, error) {
+	for d := builder.tree.depth; d > 0; d-- {
+		if pending := builder.pending[d]; pending != nil {
+			builder.pending[d] = nil
+			if err := builder.lift(d, pending); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if root := builder.pending[0]; root != nil {
+		return append(Root(nil), root.node...), nil
+	}
+	return builder.tree.Root(), nil
+}
@@ -0,0 +1,87 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestBuildStoreFromReader(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	buf := new(bytes.Buffer)
+	write, flush, err := newRecordWriter(buf, RecordFormatBinary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 200; i++ {
+		// indices repeat, so that later records override earlier ones
+		// across runs
+		if err := write(uint64(r.Intn(100)), []byte{byte(i), byte(i >> 8)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, opts := range [][]Option{
+		nil,
+		{WithLevelTweak()},
+		{WithSaltedLeaves()},
+	} {
+		store, err := NewFileStore(t.TempDir(), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		root, err := BuildStoreFromReader(sha256.New(), 8, bytes.NewReader(buf.Bytes()), RecordFormatBinary, store, 16, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		loaded, err := LoadTree(sha256.New(), 8, store, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !loaded.Root().Equal(root) {
+			t.Errorf("expected: %x, actual: %x", root, loaded.Root())
+		}
+		if mismatches, err := loaded.Audit(); err != nil || len(mismatches) != 0 {
+			t.Errorf("expected no mismatches, actual: %v (%v)", mismatches, err)
+		}
+
+		// salts are drawn afresh, but every leaf must have one
+		if loaded.salts != nil {
+			if _, err := loaded.StateHash(); err != nil {
+				t.Error(err)
+			}
+			continue
+		}
+		expected, err := NewTreeFromReader(sha256.New(), 8, bytes.NewReader(buf.Bytes()), RecordFormatBinary, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !expected.Root().Equal(root) {
+			t.Errorf("expected: %x, actual: %x", expected.Root(), root)
+		}
+	}
+
+	store, err := NewFileStore(t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := BuildStoreFromReader(sha256.New(), 8, strings.NewReader(""), RecordFormatCSV, store, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := EmptyRoot(sha256.New(), 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !root.Equal(expected) {
+		t.Errorf("expected: %x, actual: %x", expected, root)
+	}
+}
@@ -0,0 +1,114 @@
+package merkle
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+)
+
+var (
+	ErrStoreStatsUnavailable = errors.New("store stats unavailable")
+)
+
+// StoreStats describes the contents and the traffic of a node store. Keys and
+// Bytes are what the store holds, Reads, Writes and Deletes count the
+// operations that reached it, and CacheHits and CacheMisses count the reads
+// a caching layer answered from memory or passed through.
+type StoreStats struct {
+	Keys        uint64
+	Bytes       uint64
+	Reads       uint64
+	Writes      uint64
+	Deletes     uint64
+	CacheHits   uint64
+	CacheMisses uint64
+}
+
+// CacheHitRate returns the share of the reads answered from the cache, or 0
+// when nothing has been read through one.
+func (stats StoreStats) CacheHitRate() float64 {
+	lookups := stats.CacheHits + stats.CacheMisses
+	if lookups == 0 {
+		return 0
+	}
+	return float64(stats.CacheHits) / float64(lookups)
+}
+
+// StatsStore is a NodeStore able to report its StoreStats. The stores of
+// this package implement it, the decorators as long as the store they wrap
+// does.
+type StatsStore interface {
+	NodeStore
+	Stats() (StoreStats, error)
+}
+
+// StoreStats returns the stats of the node store of the tree, or
+// ErrStoreStatsUnavailable when the tree has no node store or the store does
+// not implement StatsStore.
+func (tree *Tree) StoreStats() (StoreStats, error) {
+	return storeStats(tree.store)
+}
+
+func storeStats(store NodeStore) (StoreStats, error) {
+	sstore, ok := store.(StatsStore)
+	if !ok {
+		return StoreStats{}, ErrStoreStatsUnavailable
+	}
+	return sstore.Stats()
+}
+
+// Stats counts the nodes of the store and the bytes of their files. It walks
+// the directory, so it is meant to be polled, not called on every operation.
+func (store *FileStore) Stats() (StoreStats, error) {
+	stats := StoreStats{
+		Reads:   store.reads.Load(),
+		Writes:  store.writes.Load(),
+		Deletes: store.deletes.Load(),
+	}
+
+	entries, err := fs.ReadDir(store.fsys, ".")
+	if err != nil {
+		return StoreStats{}, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return StoreStats{}, err
+		}
+		stats.Keys++
+		stats.Bytes += uint64(info.Size())
+	}
+
+	return stats, nil
+}
+
+func (store *EncryptedStore) Stats() (StoreStats, error) {
+	return storeStats(store.store)
+}
+
+func (store *CompressedStore) Stats() (StoreStats, error) {
+	return storeStats(store.store)
+}
+
+func (store *ChecksumStore) Stats() (StoreStats, error) {
+	return storeStats(store.store)
+}
+
+// Stats adds the hits and misses of the cache to the stats of the wrapped
+// store. Buffered writes are not reflected in Keys and Bytes until they are
+// flushed.
+func (cstore *CachedStore) Stats() (StoreStats, error) {
+	stats, err := storeStats(cstore.store)
+	if err != nil {
+		return StoreStats{}, err
+	}
+	stats.CacheHits = cstore.hits.Load()
+	stats.CacheMisses = cstore.misses.Load()
+	return stats, nil
+}
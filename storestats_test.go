@@ -0,0 +1,63 @@
+package merkle
+
+import (
+	"crypto/sha256"
+	"testing"
+)
+
+func TestTree_StoreStats(t *testing.T) {
+	tree := newTestTree(t)
+	if _, err := tree.StoreStats(); err != ErrStoreStatsUnavailable {
+		t.Errorf("expected: %v, actual: %v", ErrStoreStatsUnavailable, err)
+	}
+
+	fileStore, err := NewFileStore(t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewCachedStore(NewChecksumStore(fileStore), CachePolicy{
+		MaxCachedBytes: 1 << 10,
+	})
+	defer store.Close()
+
+	tree, err = NewTree(sha256.New(), 3, map[uint64][]byte{
+		0: []byte{0x00},
+		3: []byte{0x03},
+	}, WithNodeStore(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Delete(3); err != nil {
+		t.Fatal(err)
+	}
+
+	// two reads of a cached node and one of a node that is not stored
+	for _, key := range [][]byte{nodeKey(3, 0), nodeKey(3, 0), nodeKey(3, 3)} {
+		store.Get(key)
+	}
+
+	stats, err := tree.StoreStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var nodes uint64
+	for _, level := range tree.MemStats().Levels {
+		nodes += level.Nodes
+	}
+	if stats.Keys != nodes {
+		t.Errorf("expected: %d, actual: %d", nodes, stats.Keys)
+	}
+	if expected := nodes * (uint64(sha256.Size) + 2*4); stats.Bytes != expected {
+		t.Errorf("expected: %d, actual: %d", expected, stats.Bytes)
+	}
+	if stats.Reads != 1 {
+		t.Errorf("expected: %d, actual: %d", 1, stats.Reads)
+	}
+	if stats.Writes == 0 || stats.Deletes == 0 {
+		t.Errorf("expected: writes and deletes, actual: %d, %d", stats.Writes, stats.Deletes)
+	}
+	if rate := stats.CacheHitRate(); rate != 2.0/3 {
+		t.Errorf("expected: %v, actual: %v", 2.0/3, rate)
+	}
+}
@@ -0,0 +1,176 @@
+// Package storetest checks that a merkle.NodeStore behaves the way trees
+// expect it to.
+package storetest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	merkle "github.com/m0t0k1ch1/sparse-merkle-tree"
+)
+
+// Factory returns an empty store, and a function reopening the storage
+// behind it as a new store, or nil if nothing outlives the store.
+type Factory func(t *testing.T) (merkle.NodeStore, func() merkle.NodeStore)
+
+func TestNodeStore(t *testing.T, factory Factory) {
+	t.Run("Get", func(t *testing.T) { testGet(t, factory) })
+	t.Run("Delete", func(t *testing.T) { testDelete(t, factory) })
+	t.Run("Iterate", func(t *testing.T) { testIterate(t, factory) })
+	t.Run("Persistence", func(t *testing.T) { testPersistence(t, factory) })
+	t.Run("Concurrency", func(t *testing.T) { testConcurrency(t, factory) })
+}
+
+func testGet(t *testing.T, factory Factory) {
+	store, _ := factory(t)
+
+	if _, err := store.Get(key(0)); err != merkle.ErrNodeNotFound {
+		t.Errorf("expected: %v, actual: %v", merkle.ErrNodeNotFound, err)
+	}
+
+	mustPut(t, store, key(0), []byte{0x00})
+	mustPut(t, store, key(0), []byte{0x01, 0x01})
+	mustPut(t, store, key(1), []byte{})
+
+	mustGet(t, store, key(0), []byte{0x01, 0x01})
+	mustGet(t, store, key(1), []byte{})
+}
+
+func testDelete(t *testing.T, factory Factory) {
+	store, _ := factory(t)
+
+	if err := store.Delete(key(0)); err != nil {
+		t.Errorf("expected: %v, actual: %v", nil, err)
+	}
+
+	mustPut(t, store, key(0), []byte{0x00})
+	if err := store.Delete(key(0)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(key(0)); err != merkle.ErrNodeNotFound {
+		t.Errorf("expected: %v, actual: %v", merkle.ErrNodeNotFound, err)
+	}
+}
+
+func testIterate(t *testing.T, factory Factory) {
+	store, _ := factory(t)
+
+	indices := []uint64{256, 0, 1, 0xff, 1 << 40}
+	for _, index := range indices {
+		mustPut(t, store, key(index), value(index))
+	}
+
+	var visited []uint64
+	if err := store.Iterate(func(k, v []byte) error {
+		index := binary.BigEndian.Uint64(k)
+		if !bytes.Equal(v, value(index)) {
+			t.Errorf("expected: %x, actual: %x", value(index), v)
+		}
+		visited = append(visited, index)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []uint64{0, 1, 0xff, 256, 1 << 40}
+	if fmt.Sprint(visited) != fmt.Sprint(expected) {
+		t.Errorf("expected: %v, actual: %v", expected, visited)
+	}
+
+	errStop := errors.New("stop")
+	count := 0
+	if err := store.Iterate(func(k, v []byte) error {
+		count++
+		return errStop
+	}); err != errStop {
+		t.Errorf("expected: %v, actual: %v", errStop, err)
+	}
+	if count != 1 {
+		t.Errorf("expected: %d, actual: %d", 1, count)
+	}
+}
+
+func testPersistence(t *testing.T, factory Factory) {
+	store, reopen := factory(t)
+	if reopen == nil {
+		t.Skip("store is not persistent")
+	}
+
+	mustPut(t, store, key(0), []byte{0x00})
+	mustPut(t, store, key(1), []byte{0x01})
+	if err := store.Delete(key(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	store = reopen()
+
+	mustGet(t, store, key(0), []byte{0x00})
+	if _, err := store.Get(key(1)); err != merkle.ErrNodeNotFound {
+		t.Errorf("expected: %v, actual: %v", merkle.ErrNodeNotFound, err)
+	}
+}
+
+func testConcurrency(t *testing.T, factory Factory) {
+	store, _ := factory(t)
+
+	var wg sync.WaitGroup
+	for i := uint64(0); i < 8; i++ {
+		wg.Add(1)
+		go func(i uint64) {
+			defer wg.Done()
+			for j := uint64(0); j < 16; j++ {
+				index := i<<8 | j
+				if err := store.Put(key(index), value(index)); err != nil {
+					t.Error(err)
+					return
+				}
+				if v, err := store.Get(key(index)); err != nil || !bytes.Equal(v, value(index)) {
+					t.Errorf("expected: %x, actual: %x (%v)", value(index), v, err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	count := 0
+	if err := store.Iterate(func(k, v []byte) error {
+		count++
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if count != 8*16 {
+		t.Errorf("expected: %d, actual: %d", 8*16, count)
+	}
+}
+
+func key(index uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, index)
+}
+
+func value(index uint64) []byte {
+	return binary.BigEndian.AppendUint64([]byte{0xff}, index)
+}
+
+func mustPut(t *testing.T, store merkle.NodeStore, k, v []byte) {
+	t.Helper()
+	if err := store.Put(k, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mustGet(t *testing.T, store merkle.NodeStore, k, expected []byte) {
+	t.Helper()
+	v, err := store.Get(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(v, expected) {
+		t.Errorf("expected: %x, actual: %x", expected, v)
+	}
+}
@@ -0,0 +1,105 @@
+package merkle
+
+import (
+	"bytes"
+	"errors"
+)
+
+var (
+	ErrSyncRootMismatch = errors.New("sync root mismatch")
+)
+
+// SyncTransport returns the node at (depth, index) of a tree, remote or
+// not, or nil if the subtree under it is empty. It is what Sync and
+// DiffStream read the other tree through. *Tree and ReadOnlyTree satisfy
+// it.
+type SyncTransport interface {
+	Node(depth, index uint64) ([]byte, error)
+}
+
+// Sync makes the tree equal to remote, descending only into the subtrees
+// whose nodes differ. The leaf nodes that differ are gathered first and
+// checked to lead to the root of remote, as ApplyPatch does, so that a
+// remote changing or lying midway fails with ErrSyncRootMismatch and leaves
+// the tree untouched; they are then written like those of ApplyPatch,
+// journaled, with their salts, expiries and metadata forgotten.
+func (tree *Tree) Sync(remote SyncTransport) error {
+	if tree.frozen {
+		return ErrReadOnlyTree
+	}
+
+	remoteRoot, err := remote.Node(0, 0)
+	if err != nil {
+		return err
+	}
+
+	leafNodes := map[uint64][]byte{}
+	if err := tree.syncDiff(remote, 0, 0, remoteRoot, leafNodes); err != nil {
+		return err
+	}
+	if remoteRoot == nil {
+		remoteRoot = tree.defaultNodes[0]
+	}
+	if len(leafNodes) == 0 {
+		return nil
+	}
+
+	indices := sortedIndices(leafNodes)
+	batchNodes := make(map[uint64][]byte, len(leafNodes))
+	for _, index := range indices {
+		batchNodes[index] = leafNodes[index]
+		if batchNodes[index] == nil {
+			batchNodes[index] = tree.defaultNodes[tree.depth]
+		}
+	}
+	positions := tree.batchSiblingPositions(indices)
+	siblings := make([][]byte, len(positions))
+	for i, pos := range positions {
+		siblings[i], _ = tree.levels[tree.depth-pos.height].get(pos.index)
+	}
+	root, err := tree.computeBatchRoot(batchNodes, siblings)
+	if err != nil {
+		return err
+	}
+	if !tree.equalRoots(root, remoteRoot) {
+		return ErrSyncRootMismatch
+	}
+
+	for _, index := range indices {
+		if err := tree.writeLeafNode(index, tree.ingest(leafNodes[index]), nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// syncDiff adds to leafNodes the leaf nodes of remote, nil for empty ones,
+// that differ from those of the tree under (depth, index), whose node in
+// remote is remoteNode.
+func (tree *Tree) syncDiff(remote SyncTransport, depth, index uint64, remoteNode []byte, leafNodes map[uint64][]byte) error {
+	if remoteNode != nil && uint64(len(remoteNode)) != tree.hashSize {
+		return ErrInvalidNodeSize
+	}
+
+	localNode, ok := tree.levels[depth].get(index)
+	if ok == (remoteNode != nil) && bytes.Equal(localNode, remoteNode) {
+		return nil
+	}
+
+	if depth == tree.depth {
+		leafNodes[index] = remoteNode
+		return nil
+	}
+
+	for _, child := range []uint64{index * 2, index*2 + 1} {
+		childNode, err := remote.Node(depth+1, child)
+		if err != nil {
+			return err
+		}
+		if err := tree.syncDiff(remote, depth+1, child, childNode, leafNodes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+)
+
+type countingSyncTransport struct {
+	tree  *Tree
+	count int
+}
+
+func (transport *countingSyncTransport) Node(depth, index uint64) ([]byte, error) {
+	transport.count++
+	return transport.tree.Node(depth, index)
+}
+
+func TestTree_Sync(t *testing.T) {
+	type input struct {
+		localLeaves  map[uint64][]byte
+		remoteLeaves map[uint64][]byte
+	}
+	type output struct {
+		count int
+	}
+	testCases := []struct {
+		name string
+		in   input
+		out  output
+	}{
+		{
+			"success: same",
+			input{
+				map[uint64][]byte{
+					0: []byte{0x00},
+					3: []byte{0x03},
+				},
+				map[uint64][]byte{
+					0: []byte{0x00},
+					3: []byte{0x03},
+				},
+			},
+			output{
+				1,
+			},
+		},
+		{
+			"success: empty local",
+			input{
+				nil,
+				map[uint64][]byte{
+					0: []byte{0x00},
+					3: []byte{0x03},
+				},
+			},
+			output{
+				9,
+			},
+		},
+		{
+			"success: empty remote",
+			input{
+				map[uint64][]byte{
+					0: []byte{0x00},
+					3: []byte{0x03},
+				},
+				nil,
+			},
+			output{
+				9,
+			},
+		},
+		{
+			"success: one leaf differs",
+			input{
+				map[uint64][]byte{
+					0: []byte{0x00},
+					3: []byte{0x03},
+				},
+				map[uint64][]byte{
+					0: []byte{0x00},
+					3: []byte{0x04},
+				},
+			},
+			output{
+				7,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			in, out := tc.in, tc.out
+
+			local, err := NewTree(sha256.New(), 3, in.localLeaves)
+			if err != nil {
+				t.Fatal(err)
+			}
+			remote, err := NewTree(sha256.New(), 3, in.remoteLeaves)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			transport := &countingSyncTransport{tree: remote}
+			if err := local.Sync(transport); err != nil {
+				t.Fatal(err)
+			}
+			if transport.count != out.count {
+				t.Errorf("expected: %d, actual: %d", out.count, transport.count)
+			}
+			if !bytes.Equal(local.Root(), remote.Root()) {
+				t.Errorf("expected: %x, actual: %x", remote.Root(), local.Root())
+			}
+			for d := range local.levels {
+				if local.levels[d].len() != remote.levels[d].len() {
+					t.Errorf("expected: %d, actual: %d", remote.levels[d].len(), local.levels[d].len())
+				}
+			}
+		})
+	}
+}
+
+// tamperingSyncTransport serves the nodes of tree, except for the leaf node
+// at index, which it replaces with node.
+type tamperingSyncTransport struct {
+	tree  *Tree
+	index uint64
+	node  []byte
+}
+
+func (transport *tamperingSyncTransport) Node(depth, index uint64) ([]byte, error) {
+	if depth == transport.tree.depth && index == transport.index {
+		return transport.node, nil
+	}
+	return transport.tree.Node(depth, index)
+}
+
+func TestTree_Sync_rootMismatch(t *testing.T) {
+	local, err := NewTree(sha256.New(), 3, map[uint64][]byte{
+		0: []byte{0x00},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	localRoot := local.Root()
+	remote, err := NewTree(sha256.New(), 3, map[uint64][]byte{
+		0: []byte{0x00},
+		3: []byte{0x03},
+		6: []byte{0x06},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transport := &tamperingSyncTransport{remote, 6, bytes.Repeat([]byte{0xff}, sha256.Size)}
+	if err := local.Sync(transport); err != ErrSyncRootMismatch {
+		t.Errorf("expected: %v, actual: %v", ErrSyncRootMismatch, err)
+	}
+	if !local.Root().Equal(localRoot) {
+		t.Errorf("expected: %x, actual: %x", localRoot, local.Root())
+	}
+	if local.HasLeaf(3) {
+		t.Errorf("expected: %t, actual: %t", false, true)
+	}
+
+	transport.node = []byte{0xff}
+	if err := local.Sync(transport); err != ErrInvalidNodeSize {
+		t.Errorf("expected: %v, actual: %v", ErrInvalidNodeSize, err)
+	}
+}
+
+func TestTree_Sync_saltedLeaves(t *testing.T) {
+	local, err := NewTree(sha256.New(), 3, map[uint64][]byte{
+		0: []byte{0x00},
+		3: []byte{0x03},
+	}, WithSaltedLeaves())
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote, err := NewTree(sha256.New(), 3, map[uint64][]byte{
+		3: []byte{0x04},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := local.Sync(remote); err != nil {
+		t.Fatal(err)
+	}
+	if !local.Root().Equal(remote.Root()) {
+		t.Errorf("expected: %x, actual: %x", remote.Root(), local.Root())
+	}
+	// the salts of the replaced leaves are of no use anymore
+	for _, index := range []uint64{0, 3} {
+		if _, err := local.Salt(index); err != ErrSaltNotFound {
+			t.Errorf("index %d: expected: %v, actual: %v", index, ErrSaltNotFound, err)
+		}
+	}
+}
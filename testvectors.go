@@ -0,0 +1,86 @@
+package merkle
+
+import (
+	"encoding/hex"
+	"math/rand"
+)
+
+// TestVectorMatrix describes the cases to generate: one per combination of
+// hasher, depth and leaf count, with leaves drawn from a PRNG seeded with
+// Seed so that the same matrix always yields the same vectors.
+type TestVectorMatrix struct {
+	Hashers    []string
+	Depths     []uint64
+	LeafCounts []int
+	Seed       int64
+}
+
+// TestVector is a fixture other implementations can check byte for byte
+// against. Leaves and proofs are hex encoded, and proofs are given for every
+// leaf as well as for the first and last indices.
+type TestVector struct {
+	Hasher string            `json:"hasher"`
+	Depth  uint64            `json:"depth"`
+	Leaves map[uint64]string `json:"leaves"`
+	Root   Root              `json:"root"`
+	Proofs map[uint64]string `json:"proofs"`
+}
+
+func GenerateTestVectors(matrix TestVectorMatrix) ([]TestVector, error) {
+	r := rand.New(rand.NewSource(matrix.Seed))
+
+	var vectors []TestVector
+	for _, hasherName := range matrix.Hashers {
+		for _, depth := range matrix.Depths {
+			for _, leafCount := range matrix.LeafCounts {
+				vector, err := generateTestVector(r, hasherName, depth, leafCount)
+				if err != nil {
+					return nil, err
+				}
+				vectors = append(vectors, vector)
+			}
+		}
+	}
+
+	return vectors, nil
+}
+
+func generateTestVector(r *rand.Rand, hasherName string, depth uint64, leafCount int) (TestVector, error) {
+	indexMax := ^uint64(0) >> (DepthMax - depth)
+
+	leaves := map[uint64][]byte{}
+	for uint64(len(leaves)) < uint64(leafCount) && uint64(len(leaves)) <= indexMax {
+		index := r.Uint64() & indexMax
+		if _, ok := leaves[index]; ok {
+			continue
+		}
+		leaf := make([]byte, 32)
+		r.Read(leaf)
+		leaves[index] = leaf
+	}
+
+	tree, err := NewTreeByName(hasherName, depth, leaves)
+	if err != nil {
+		return TestVector{}, err
+	}
+
+	vector := TestVector{
+		Hasher: hasherName,
+		Depth:  depth,
+		Leaves: map[uint64]string{},
+		Root:   tree.Root(),
+		Proofs: map[uint64]string{},
+	}
+	for index, leaf := range leaves {
+		vector.Leaves[index] = hex.EncodeToString(leaf)
+	}
+	for _, index := range append(sortedIndices(leaves), 0, indexMax) {
+		proof, err := tree.CreateMembershipProof(index)
+		if err != nil {
+			return TestVector{}, err
+		}
+		vector.Proofs[index] = hex.EncodeToString(proof)
+	}
+
+	return vector, nil
+}
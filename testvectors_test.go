@@ -0,0 +1,62 @@
+package merkle
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+)
+
+func TestGenerateTestVectors(t *testing.T) {
+	matrix := TestVectorMatrix{
+		Hashers:    []string{"sha256", "sha512"},
+		Depths:     []uint64{1, 3, 64},
+		LeafCounts: []int{0, 1, 5},
+		Seed:       1,
+	}
+
+	vectors, err := GenerateTestVectors(matrix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vectors) != 2*3*3 {
+		t.Fatalf("expected: %d, actual: %d", 2*3*3, len(vectors))
+	}
+
+	again, err := GenerateTestVectors(matrix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b1, _ := json.Marshal(vectors)
+	b2, _ := json.Marshal(again)
+	if string(b1) != string(b2) {
+		t.Errorf("expected: deterministic vectors")
+	}
+
+	for _, vector := range vectors {
+		leaves := map[uint64][]byte{}
+		for index, leafHex := range vector.Leaves {
+			leaf, err := hex.DecodeString(leafHex)
+			if err != nil {
+				t.Fatal(err)
+			}
+			leaves[index] = leaf
+		}
+
+		tree, err := NewTreeByName(vector.Hasher, vector.Depth, leaves)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !tree.Root().Equal(vector.Root) {
+			t.Errorf("expected: %s, actual: %s", vector.Root, tree.Root())
+		}
+		for index, proofHex := range vector.Proofs {
+			proof, err := hex.DecodeString(proofHex)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := tree.VerifyMembershipProof(index, proof); err != nil || !ok {
+				t.Errorf("expected: %t, actual: %t (%v)", true, ok, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package merkle
+
+import (
+	"log/slog"
+)
+
+// Tracer starts a span for each traced operation of a tree: building it
+// ("merkle.build"), applying a batch of writes ("merkle.apply"), creating a
+// proof ("merkle.proof") and every node store call ("merkle.store.put",
+// "merkle.store.delete" and "merkle.store.iterate"), so that the latency of
+// a store-backed tree can be attributed to hashing or to I/O. Adapting an
+// OpenTelemetry tracer takes a Start turning the attributes into
+// attribute.KeyValues and an End recording the error, if any.
+//
+// Store calls made while a tree is being built come from a goroutine of
+// their own, so a Tracer must be safe for concurrent use.
+type Tracer interface {
+	Start(name string, attrs ...slog.Attr) Span
+}
+
+type Span interface {
+	// End ends the span of an operation that returned err.
+	End(err error)
+}
+
+// traceStore runs call, the node store call op, in a span when the tree is
+// traced.
+func (tree *Tree) traceStore(op string, depth, index uint64, call func() error) error {
+	if tree.tracer == nil {
+		return call()
+	}
+
+	span := tree.tracer.Start("merkle.store."+op,
+		slog.Uint64("depth", depth),
+		slog.Uint64("index", index),
+	)
+	err := call()
+	span.End(err)
+
+	return err
+}
@@ -0,0 +1,82 @@
+package merkle
+
+import (
+	"crypto/sha256"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+)
+
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans map[string]int
+	errs  []error
+}
+
+func (tracer *recordingTracer) Start(name string, attrs ...slog.Attr) Span {
+	return &recordingSpan{tracer, name}
+}
+
+type recordingSpan struct {
+	tracer *recordingTracer
+	name   string
+}
+
+func (span *recordingSpan) End(err error) {
+	span.tracer.mu.Lock()
+	defer span.tracer.mu.Unlock()
+
+	span.tracer.spans[span.name]++
+	if err != nil {
+		span.tracer.errs = append(span.tracer.errs, err)
+	}
+}
+
+func TestTree_WithTracer(t *testing.T) {
+	store, err := NewFileStore(t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracer := &recordingTracer{
+		spans: map[string]int{},
+	}
+
+	tree, err := NewTree(sha256.New(), 3, map[uint64][]byte{
+		0: []byte{0x00},
+	}, WithNodeStore(store), WithTracer(tracer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd, err := EncodeCommand([]LeafWrite{{0, nil}, {3, []byte{0x03}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tree.Apply(cmd); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tree.CreateMembershipProof(8); err != ErrTooLargeLeafIndex {
+		t.Errorf("expected: %v, actual: %v", ErrTooLargeLeafIndex, err)
+	}
+	if _, err := LoadTree(sha256.New(), 3, store, WithTracer(tracer)); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, count := range map[string]int{
+		"merkle.build": 2,
+		"merkle.apply": 1,
+		"merkle.proof": 1,
+		// 4 nodes built, 4 deleted and 4 put by the batch
+		"merkle.store.put":     8,
+		"merkle.store.delete":  4,
+		"merkle.store.iterate": 1,
+	} {
+		if tracer.spans[name] != count {
+			t.Errorf("%s: expected: %d, actual: %d", name, count, tracer.spans[name])
+		}
+	}
+	if len(tracer.errs) != 1 || !errors.Is(tracer.errs[0], ErrTooLargeLeafIndex) {
+		t.Errorf("expected: %v, actual: %v", []error{ErrTooLargeLeafIndex}, tracer.errs)
+	}
+}
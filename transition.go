@@ -0,0 +1,61 @@
+package merkle
+
+// ProveTransition writes leaf at index, deleting it if leaf is nil, and
+// returns the siblings of its path, which are the same before and after the
+// write and so link the old root to the new one.
+func (tree *Tree) ProveTransition(index uint64, leaf []byte) ([]byte, error) {
+	proof, err := tree.CreateMembershipProof(index)
+	if err != nil {
+		return nil, err
+	}
+
+	if leaf == nil {
+		err = tree.Delete(index)
+	} else {
+		err = tree.Update(index, leaf)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return proof, nil
+}
+
+// VerifyTransitionProof checks that replacing oldLeaf with newLeaf at index
+// turns oldRoot into newRoot, where a nil leaf stands for an empty one. It
+// only uses the hasher and the depth of the tree, not its leaves.
+func (tree *Tree) VerifyTransitionProof(index uint64, oldLeaf, newLeaf []byte, oldRoot, newRoot Root, proof []byte) (bool, error) {
+	if index > tree.indexMax {
+		return false, ErrTooLargeLeafIndex
+	}
+	if err := tree.SanitizeProof(proof); err != nil {
+		return false, err
+	}
+
+	siblings, err := tree.decodeProof(proof)
+	if err != nil {
+		return false, err
+	}
+
+	for _, t := range []struct {
+		leaf []byte
+		root Root
+	}{
+		{oldLeaf, oldRoot},
+		{newLeaf, newRoot},
+	} {
+		node, err := tree.leafNode(t.leaf)
+		if err != nil {
+			return false, err
+		}
+		root, err := tree.computeRoot(index, node, siblings)
+		if err != nil {
+			return false, err
+		}
+		if !tree.equalRoots(root, t.root) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,62 @@
+package merkle
+
+import (
+	"testing"
+)
+
+func TestTree_VerifyTransitionProof(t *testing.T) {
+	tree := newTestTree(t)
+	oldLeaf := []byte{0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03}
+
+	root0 := tree.Root()
+	proof1, err := tree.ProveTransition(3, []byte{0x04})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root1 := tree.Root()
+	proof2, err := tree.ProveTransition(3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root2 := tree.Root()
+	proof3, err := tree.ProveTransition(5, []byte{0x05})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root3 := tree.Root()
+
+	type input struct {
+		index   uint64
+		oldLeaf []byte
+		newLeaf []byte
+		oldRoot Root
+		newRoot Root
+		proof   []byte
+	}
+	testCases := []struct {
+		name string
+		in   input
+		ok   bool
+	}{
+		{"failure: wrong old leaf", input{3, []byte{0x03}, []byte{0x04}, root0, root1, proof1}, false},
+		{"failure: wrong new root", input{3, oldLeaf, []byte{0x04}, root0, root2, proof1}, false},
+		{"failure: wrong index", input{2, oldLeaf, []byte{0x04}, root0, root1, proof1}, false},
+		{"success: update", input{3, oldLeaf, []byte{0x04}, root0, root1, proof1}, true},
+		{"success: delete", input{3, []byte{0x04}, nil, root1, root2, proof2}, true},
+		{"success: insert", input{5, nil, []byte{0x05}, root2, root3, proof3}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			in := tc.in
+
+			ok, err := tree.VerifyTransitionProof(in.index, in.oldLeaf, in.newLeaf, in.oldRoot, in.newRoot, in.proof)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tc.ok {
+				t.Errorf("expected: %t, actual: %t", tc.ok, ok)
+			}
+		})
+	}
+}
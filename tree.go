@@ -4,12 +4,18 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"hash"
+	"log/slog"
 	"math/big"
+	"math/bits"
+	"sync"
+	"time"
 )
 
 const (
-	DepthMax uint64 = 64
+	DepthMax    uint64 = 64
+	HashSizeMax uint64 = 64
 )
 
 var (
@@ -21,20 +27,49 @@ var (
 	ErrTooLargeLeafIndex = errors.New("too large leaf index")
 	ErrTooLargeProofSize = errors.New("too large proof size")
 	ErrInvalidProofSize  = errors.New("invalid proof size")
+	ErrInvalidProofHead  = errors.New("invalid proof head")
+	ErrTooLargeNodeIndex = errors.New("too large node index")
+	ErrUncopyableHasher  = errors.New("uncopyable hasher")
+	ErrInvalidHashSize   = errors.New("invalid hash size")
+	ErrInvalidNodeSize   = errors.New("invalid node size")
 )
 
 type Tree struct {
 	hasher       hash.Hash
+	hasherName   string
 	hashSize     uint64
 	depth        uint64
 	indexMax     uint64
 	defaultNodes [][]byte
-	levels       []map[uint64][]byte
+	levels       []*nodeLevel
+	journal      *journal
+	store        NodeStore
+	proofCache   *proofCache
+	logger       *slog.Logger
+	tracer       Tracer
+	noInputCopy  bool
+	constantTime bool
+	hasherPool   *sync.Pool
+	ssz          bool
+	levelTweak   bool
+	sortedPairs  bool
+	// heightOffset is added to the heights tweaked into the nodes of the
+	// top tree of a sharded tree, which sits above the shards.
+	heightOffset uint64
+	salts        map[uint64][]byte
+	pipeline     *storePipeline
+	parallelism  int
+	expiries     map[uint64]time.Time
+	metadata     map[uint64][]byte
+	keyMapper    KeyMapper
+	keyBuckets   bool
+	frozen       bool
+	uniqueWrites bool
 }
 
-func NewTree(hasher hash.Hash, depth uint64, leaves map[uint64][]byte) (*Tree, error) {
-	if depth > DepthMax {
-		return nil, ErrTooLargeTreeDepth
+func NewTree(hasher hash.Hash, depth uint64, leaves map[uint64][]byte, opts ...Option) (*Tree, error) {
+	if err := checkTreeParams(hasher, depth); err != nil {
+		return nil, err
 	}
 
 	indexMax := new(big.Int).Lsh(big.NewInt(2), uint(depth-1)).Uint64() - 1
@@ -48,50 +83,256 @@ func NewTree(hasher hash.Hash, depth uint64, leaves map[uint64][]byte) (*Tree, e
 		depth:        depth,
 		indexMax:     indexMax,
 		defaultNodes: make([][]byte, depth+1),
-		levels:       make([]map[uint64][]byte, depth+1),
+		levels:       make([]*nodeLevel, depth+1),
 	}
 	for i, _ := range tree.levels {
-		tree.levels[i] = map[uint64][]byte{}
+		tree.levels[i] = newNodeLevel(tree.hashSize)
+	}
+	for _, opt := range opts {
+		opt(tree)
 	}
 
 	if err := tree.buildDefaultNodes(); err != nil {
 		return nil, err
 	}
-	if err := tree.build(leaves); err != nil {
+	start := time.Now()
+	var span Span
+	if tree.tracer != nil {
+		span = tree.tracer.Start("merkle.build",
+			slog.Uint64("depth", depth),
+			slog.Int("leaves", len(leaves)),
+		)
+	}
+	err := tree.buildPipelined(leaves, false)
+	if span != nil {
+		span.End(err)
+	}
+	if err != nil {
 		return nil, err
 	}
+	if tree.logger != nil {
+		tree.logger.Debug("built tree",
+			slog.Uint64("depth", depth),
+			slog.Int("leaves", len(leaves)),
+			slog.Duration("elapsed", time.Since(start)),
+		)
+	}
+	if tree.journal != nil {
+		for _, index := range sortedIndices(leaves) {
+			tree.journal.record(journalOpUpdate, index, tree.ingest(leaves[index]), tree.salts[index])
+		}
+	}
 
 	return tree, nil
 }
 
+func checkTreeParams(hasher hash.Hash, depth uint64) error {
+	if depth > DepthMax {
+		return ErrTooLargeTreeDepth
+	}
+	if size := hasher.Size(); size <= 0 || uint64(size) > HashSizeMax {
+		return ErrInvalidHashSize
+	}
+	return nil
+}
+
+// clone copies the levels of the tree so that writes to either tree are not
+// visible to the other. Nodes themselves are never modified in place and
+// are shared.
+func (tree *Tree) clone(hasher hash.Hash) *Tree {
+	clone := &Tree{
+		hasher:       hasher,
+		hasherName:   tree.hasherName,
+		hashSize:     tree.hashSize,
+		depth:        tree.depth,
+		indexMax:     tree.indexMax,
+		defaultNodes: tree.defaultNodes,
+		levels:       make([]*nodeLevel, len(tree.levels)),
+		logger:       tree.logger,
+		tracer:       tree.tracer,
+		noInputCopy:  tree.noInputCopy,
+		constantTime: tree.constantTime,
+		hasherPool:   tree.hasherPool,
+		ssz:          tree.ssz,
+		levelTweak:   tree.levelTweak,
+		sortedPairs:  tree.sortedPairs,
+		heightOffset: tree.heightOffset,
+		keyMapper:    tree.keyMapper,
+		keyBuckets:   tree.keyBuckets,
+		parallelism:  tree.parallelism,
+		uniqueWrites: tree.uniqueWrites,
+	}
+	if tree.salts != nil {
+		clone.salts = make(map[uint64][]byte, len(tree.salts))
+		for index, salt := range tree.salts {
+			clone.salts[index] = salt
+		}
+	}
+	if tree.expiries != nil {
+		clone.expiries = make(map[uint64]time.Time, len(tree.expiries))
+		for index, expiry := range tree.expiries {
+			clone.expiries[index] = expiry
+		}
+	}
+	if tree.metadata != nil {
+		clone.metadata = make(map[uint64][]byte, len(tree.metadata))
+		for index, leaf := range tree.metadata {
+			clone.metadata[index] = leaf
+		}
+	}
+	for d, level := range tree.levels {
+		clone.levels[d] = level.clone()
+	}
+	return clone
+}
+
+// Copy returns a deep copy of the tree with a hasher of its own, obtained
+// from the hasher registry or by cloning the hasher of the tree. The copy
+// keeps the journal but is not attached to the node store of the tree.
+func (tree *Tree) Copy() (*Tree, error) {
+	hasher, err := tree.copyHasher()
+	if err != nil {
+		return nil, err
+	}
+
+	copied := tree.clone(hasher)
+	// inline nodes are copied by clone already
+	for _, level := range copied.levels {
+		for index, node := range level.nodes {
+			level.nodes[index] = append([]byte(nil), node...)
+		}
+	}
+	if tree.journal != nil {
+		copied.journal = &journal{
+			entries: append([]journalEntry(nil), tree.journal.entries...),
+		}
+	}
+
+	return copied, nil
+}
+
+// copyHasher returns a hasher like that of the tree for a copy of it.
+func (tree *Tree) copyHasher() (hash.Hash, error) {
+	if tree.hasherName != "" {
+		newHasher, err := LookupHasher(tree.hasherName)
+		if err != nil {
+			return nil, err
+		}
+		return newHasher(), nil
+	}
+	if cloner, ok := tree.hasher.(hash.Cloner); ok {
+		return cloner.Clone()
+	}
+	return nil, ErrUncopyableHasher
+}
+
+// Equal reports whether other has the same depth, the same default nodes,
+// hence the same way of hashing as far as it shows, and the same root. How
+// the hasher was given, by name or as a hash.Hash, does not matter.
+func (tree *Tree) Equal(other *Tree) bool {
+	if tree.depth != other.depth {
+		return false
+	}
+	for d, defaultNode := range tree.defaultNodes {
+		if !bytes.Equal(defaultNode, other.defaultNodes[d]) {
+			return false
+		}
+	}
+	return tree.Root().Equal(other.Root())
+}
+
 func (tree *Tree) hash(b []byte) ([]byte, error) {
-	tree.hasher.Reset()
-	if _, err := tree.hasher.Write(b); err != nil {
+	return tree.hashTo(nil, b)
+}
+
+// hashTo is hash appending the digest to dst.
+func (tree *Tree) hashTo(dst, b []byte) ([]byte, error) {
+	hasher := tree.getHasher()
+	defer tree.putHasher(hasher)
+
+	hasher.Reset()
+	if _, err := hasher.Write(b); err != nil {
 		return nil, err
 	}
-	return tree.hasher.Sum(nil), nil
+	return hasher.Sum(dst), nil
+}
+
+// hashLeaf returns the node of a leaf value.
+func (tree *Tree) hashLeaf(leaf []byte) ([]byte, error) {
+	return tree.hashLeafTo(nil, leaf)
+}
+
+// hashLeafTo is hashLeaf appending the node to dst.
+func (tree *Tree) hashLeafTo(dst, leaf []byte) ([]byte, error) {
+	if tree.ssz {
+		if uint64(len(leaf)) != tree.hashSize {
+			return nil, ErrInvalidChunkSize
+		}
+		return append(dst, leaf...), nil
+	}
+	return tree.hashTo(dst, leaf)
+}
+
+// pairHash returns the node of height h, counted from 1 right above the
+// leaves, over the pair of its children.
+func (tree *Tree) pairHash(h uint64, b1, b2 []byte) ([]byte, error) {
+	return tree.pairHashTo(nil, h, b1, b2)
+}
+
+// pairHashTo is pairHash appending the digest to dst.
+func (tree *Tree) pairHashTo(dst []byte, h uint64, b1, b2 []byte) ([]byte, error) {
+	hasher := tree.getHasher()
+	defer tree.putHasher(hasher)
+
+	return tree.pairHashWith(hasher, dst, h, b1, b2)
 }
 
-func (tree *Tree) pairHash(b1, b2 []byte) ([]byte, error) {
-	tree.hasher.Reset()
-	if _, err := tree.hasher.Write(b1); err != nil {
+// pairHashWith is pairHashTo hashing with hasher.
+func (tree *Tree) pairHashWith(hasher hash.Hash, dst []byte, h uint64, b1, b2 []byte) ([]byte, error) {
+	if tree.sortedPairs && bytes.Compare(b1, b2) > 0 {
+		b1, b2 = b2, b1
+	}
+
+	hasher.Reset()
+	if tree.levelTweak {
+		if _, err := hasher.Write([]byte{byte(h + tree.heightOffset)}); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := hasher.Write(b1); err != nil {
 		return nil, err
 	}
-	if _, err := tree.hasher.Write(b2); err != nil {
+	if _, err := hasher.Write(b2); err != nil {
 		return nil, err
 	}
-	return tree.hasher.Sum(nil), nil
+	return hasher.Sum(dst), nil
 }
 
 func (tree *Tree) buildDefaultNodes() error {
+	// filled in by withDefaultNodes
+	if tree.defaultNodes[0] != nil {
+		return nil
+	}
+
 	node, err := tree.hash(make([]byte, tree.hashSize, tree.hashSize))
 	if err != nil {
 		return err
 	}
+	// a hasher whose output does not match its Size would otherwise only
+	// surface as proofs of the wrong size failing to verify
+	if uint64(len(node)) != tree.hashSize {
+		return ErrInvalidHashSize
+	}
+	if tree.ssz {
+		node = make([]byte, tree.hashSize)
+	}
+	if !tree.levelTweak && tree.useDefaultNodeTable(node) {
+		return nil
+	}
 	tree.defaultNodes[tree.depth] = node
 
 	for d := tree.depth; d > 0; d-- {
-		node, err := tree.pairHash(tree.defaultNodes[d], tree.defaultNodes[d])
+		node, err := tree.pairHash(tree.depth-d+1, tree.defaultNodes[d], tree.defaultNodes[d])
 		if err != nil {
 			return err
 		}
@@ -101,58 +342,375 @@ func (tree *Tree) buildDefaultNodes() error {
 	return nil
 }
 
-func (tree *Tree) build(leaves map[uint64][]byte) error {
-	for index, leaf := range leaves {
-		node, err := tree.hash(leaf)
-		if err != nil {
-			return err
-		}
-		tree.levels[tree.depth][index] = node
-	}
-
+// buildInternalNodes computes the internal nodes from the leaf level, each
+// level into an arena of its own.
+func (tree *Tree) buildInternalNodes() error {
 	for d := tree.depth; d > 0; d-- {
 		level := tree.levels[d]
+		arena := tree.newNodeArena(level.len()/2 + 1)
 
-		for index, node := range level {
+		for index, node := range level.all() {
 			if index%2 == 0 {
-				siblingNode, ok := level[index+1]
+				siblingNode, ok := level.get(index + 1)
 				if !ok {
 					siblingNode = tree.defaultNodes[d]
 				}
-				parentNode, err := tree.pairHash(node, siblingNode)
+				parentNode, err := tree.pairHashTo(arena.alloc(), tree.depth-d+1, node, siblingNode)
 				if err != nil {
 					return err
 				}
-				tree.levels[d-1][index/2] = parentNode
+				if err := tree.putNode(d-1, index/2, parentNode); err != nil {
+					return err
+				}
 
 			} else {
-				if _, ok := level[index-1]; ok {
+				if level.has(index - 1) {
 					continue
 				}
-				parentNode, err := tree.pairHash(tree.defaultNodes[d], node)
+				parentNode, err := tree.pairHashTo(arena.alloc(), tree.depth-d+1, tree.defaultNodes[d], node)
 				if err != nil {
 					return err
 				}
-				tree.levels[d-1][index/2] = parentNode
+				if err := tree.putNode(d-1, index/2, parentNode); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Rebuild discards the internal nodes and recomputes them from the leaf
+// level, as a way to recover from the corruption reported by Audit.
+func (tree *Tree) Rebuild() error {
+	start := time.Now()
+
+	for d := uint64(0); d < tree.depth; d++ {
+		for index := range tree.levels[d].all() {
+			if err := tree.deleteNode(d, index); err != nil {
+				return err
 			}
 		}
 	}
+	if err := tree.buildInternalNodes(); err != nil {
+		return err
+	}
+
+	if tree.logger != nil {
+		tree.logger.Info("rebuilt internal nodes",
+			slog.Int("leaves", tree.levels[tree.depth].len()),
+			slog.Duration("elapsed", time.Since(start)),
+		)
+	}
 
 	return nil
 }
 
-func (tree *Tree) Root() []byte {
-	if root, ok := tree.levels[0][0]; ok {
-		return root
+func (tree *Tree) Root() Root {
+	root, ok := tree.levels[0].get(0)
+	if !ok {
+		root = tree.defaultNodes[0]
 	}
-	return tree.defaultNodes[0]
+	// the default nodes may be shared with other trees
+	return append(Root(nil), root...)
 }
 
-func (tree *Tree) CreateMembershipProof(index uint64) ([]byte, error) {
+// Leaves returns a copy of the non-default leaf nodes. Leaf values are not
+// retained, so these are their hashes.
+func (tree *Tree) Leaves() map[uint64][]byte {
+	leaves := make(map[uint64][]byte, tree.levels[tree.depth].len())
+	for index, node := range tree.levels[tree.depth].all() {
+		leaves[index] = append([]byte(nil), node...)
+	}
+	return leaves
+}
+
+func (tree *Tree) HasLeaf(index uint64) bool {
+	if index > tree.indexMax {
+		return false
+	}
+	return tree.levels[tree.depth].has(index)
+}
+
+func (tree *Tree) Node(depth, index uint64) ([]byte, error) {
+	if depth > tree.depth {
+		return nil, ErrTooLargeTreeDepth
+	}
+	if index > tree.indexMax>>(tree.depth-depth) {
+		return nil, ErrTooLargeNodeIndex
+	}
+	node, _ := tree.levels[depth].get(index)
+	return node, nil
+}
+
+func (tree *Tree) Update(index uint64, leaf []byte) error {
+	return tree.update(index, leaf, nil)
+}
+
+// update writes leaf at index, salted with salt, or a fresh one if salt is
+// nil, when the tree salts its leaves.
+func (tree *Tree) update(index uint64, leaf, salt []byte) error {
+	if tree.frozen {
+		return ErrReadOnlyTree
+	}
+	if index > tree.indexMax {
+		return ErrTooLargeLeafIndex
+	}
+
+	node, err := tree.saltedLeafNode(nil, index, leaf, salt)
+	if err != nil {
+		return err
+	}
+	return tree.updateNode(index, leaf, node)
+}
+
+// updateNode writes leaf at index given its node, already computed.
+func (tree *Tree) updateNode(index uint64, leaf, node []byte) error {
+	if err := tree.setLeafNode(index, node); err != nil {
+		return err
+	}
+	delete(tree.expiries, index)
+	delete(tree.metadata, index)
+
+	if tree.journal != nil {
+		tree.journal.record(journalOpUpdate, index, tree.ingest(leaf), tree.salts[index])
+	}
+
+	return nil
+}
+
+// writeLeafNode sets the leaf node at index to node, or removes it if node
+// is nil, for writes made by node rather than by leaf, such as those of Sync
+// and ApplyPatch. The salt of the leaf is set to salt, or forgotten if salt
+// is nil, and the write is recorded to the journal with the node itself.
+func (tree *Tree) writeLeafNode(index uint64, node, salt []byte) error {
+	if index > tree.indexMax {
+		return ErrTooLargeLeafIndex
+	}
+	if salt != nil && tree.salts == nil {
+		return ErrSaltedLeavesDisabled
+	}
+	if err := tree.setLeafNode(index, node); err != nil {
+		return err
+	}
+	if salt != nil {
+		if err := tree.putSalt(index, salt); err != nil {
+			return err
+		}
+	} else if err := tree.deleteSalt(index); err != nil {
+		return err
+	}
+	delete(tree.expiries, index)
+	delete(tree.metadata, index)
+
+	if tree.journal != nil {
+		tree.journal.record(journalOpNode, index, node, salt)
+	}
+
+	return nil
+}
+
+func (tree *Tree) Delete(index uint64) error {
+	if tree.frozen {
+		return ErrReadOnlyTree
+	}
+	if index > tree.indexMax {
+		return ErrTooLargeLeafIndex
+	}
+
+	if err := tree.setLeafNode(index, nil); err != nil {
+		return err
+	}
+	if err := tree.deleteSalt(index); err != nil {
+		return err
+	}
+	delete(tree.expiries, index)
+	delete(tree.metadata, index)
+
+	if tree.journal != nil {
+		tree.journal.record(journalOpDelete, index, nil, nil)
+	}
+
+	return nil
+}
+
+// ingest returns a copy of b, a caller-owned slice the tree is about to
+// retain, unless copying was turned off with WithoutInputCopy.
+func (tree *Tree) ingest(b []byte) []byte {
+	if tree.noInputCopy || b == nil {
+		return b
+	}
+	return append([]byte(nil), b...)
+}
+
+// pathNode is a node of the path of a leaf being written, nil if the node
+// is removed.
+type pathNode struct {
+	depth uint64
+	index uint64
+	node  []byte
+}
+
+// setLeafNode sets the leaf node at index, or removes it if node is nil, and
+// recomputes its path. The path is computed first and written to the store,
+// and only then to the levels: if a store write fails, the nodes already
+// written are restored and the tree is left as it was.
+func (tree *Tree) setLeafNode(index uint64, node []byte) error {
+	if tree.frozen {
+		return ErrReadOnlyTree
+	}
+	if node != nil && uint64(len(node)) != tree.hashSize {
+		return ErrInvalidNodeSize
+	}
+
+	path := make([]pathNode, 1, tree.depth+1)
+	path[0] = pathNode{tree.depth, index, node}
+
+	for d := tree.depth; d > 0; d-- {
+		siblingNode, siblingOK := tree.levels[d].get(index ^ 1)
+
+		if node != nil || siblingOK {
+			if node == nil {
+				node = tree.defaultNodes[d]
+			}
+			if !siblingOK {
+				siblingNode = tree.defaultNodes[d]
+			}
+			leftNode, rightNode := node, siblingNode
+			if index%2 == 1 {
+				leftNode, rightNode = rightNode, leftNode
+			}
+
+			parentNode, err := tree.pairHash(tree.depth-d+1, leftNode, rightNode)
+			if err != nil {
+				return err
+			}
+			node = parentNode
+		}
+		index /= 2
+
+		path = append(path, pathNode{d - 1, index, node})
+	}
+
+	for i, pnode := range path {
+		if err := tree.storeNode(pnode.depth, pnode.index, pnode.node); err != nil {
+			tree.restoreNodes(path[:i])
+			return err
+		}
+	}
+
+	for _, pnode := range path {
+		if pnode.node == nil {
+			tree.levels[pnode.depth].remove(pnode.index)
+		} else {
+			tree.levels[pnode.depth].put(pnode.index, pnode.node)
+		}
+	}
+
+	if tree.proofCache != nil {
+		tree.proofCache.invalidate(path[0].index, tree.depth)
+	}
+
+	return nil
+}
+
+// restoreNodes writes back to the store the nodes of the levels at the
+// positions of path, undoing the writes of a path that failed. The store is
+// failing, so the errors are only logged.
+func (tree *Tree) restoreNodes(path []pathNode) {
+	for _, pnode := range path {
+		node, ok := tree.levels[pnode.depth].get(pnode.index)
+		if !ok {
+			node = nil
+		}
+		tree.storeNode(pnode.depth, pnode.index, node)
+	}
+}
+
+func (tree *Tree) putNode(depth, index uint64, node []byte) error {
+	if uint64(len(node)) != tree.hashSize {
+		return ErrInvalidNodeSize
+	}
+	if err := tree.storeNode(depth, index, node); err != nil {
+		return err
+	}
+	tree.levels[depth].put(index, node)
+	return nil
+}
+
+func (tree *Tree) deleteNode(depth, index uint64) error {
+	if err := tree.storeNode(depth, index, nil); err != nil {
+		return err
+	}
+	tree.levels[depth].remove(index)
+	return nil
+}
+
+// storeNode writes the node at depth and index to the store, or deletes it
+// from the store if node is nil.
+func (tree *Tree) storeNode(depth, index uint64, node []byte) error {
+	if node != nil && tree.pipeline != nil {
+		tree.pipeline.put(depth, index, node)
+		return nil
+	}
+	if tree.store == nil {
+		return nil
+	}
+
+	op, call := "put", func() error {
+		return tree.store.Put(nodeKey(depth, index), node)
+	}
+	if node == nil {
+		op, call = "delete", func() error {
+			return tree.store.Delete(nodeKey(depth, index))
+		}
+	}
+	if err := tree.traceStore(op, depth, index, call); err != nil {
+		tree.logStoreError(op, depth, index, err)
+		return err
+	}
+	return nil
+}
+
+func (tree *Tree) logStoreError(op string, depth, index uint64, err error) {
+	if tree.logger == nil {
+		return
+	}
+	tree.logger.Error("node store "+op+" failed",
+		slog.Uint64("depth", depth),
+		slog.Uint64("index", index),
+		slog.Any("error", err),
+	)
+}
+
+func (tree *Tree) CreateMembershipProof(index uint64) (proof []byte, err error) {
+	if tree.tracer != nil {
+		span := tree.tracer.Start("merkle.proof", slog.Uint64("index", index))
+		defer func() {
+			span.End(err)
+		}()
+	}
+
 	if index > tree.indexMax {
 		return nil, ErrTooLargeLeafIndex
 	}
 
+	if tree.proofCache != nil {
+		if proof, ok := tree.proofCache.get(tree, index); ok {
+			return proof, nil
+		}
+
+		siblings := make([][]byte, tree.depth)
+		for h := range siblings {
+			siblings[h] = tree.siblingNode(index, uint64(h))
+		}
+		proof := encodeProof(siblings)
+		tree.proofCache.add(index, siblings, proof)
+
+		return proof, nil
+	}
+
 	var proofHead uint64
 
 	proofHeadBytes := make([]byte, proofHeadSize)
@@ -166,7 +724,7 @@ func (tree *Tree) CreateMembershipProof(index uint64) ([]byte, error) {
 			siblingIndex = index - 1
 		}
 
-		if siblingNode, ok := tree.levels[d][siblingIndex]; ok {
+		if siblingNode, ok := tree.levels[d].get(siblingIndex); ok {
 			if _, err := buf.Write(siblingNode); err != nil {
 				return nil, err
 			}
@@ -178,31 +736,38 @@ func (tree *Tree) CreateMembershipProof(index uint64) ([]byte, error) {
 
 	binary.BigEndian.PutUint64(proofHeadBytes, proofHead)
 
-	proof := buf.Bytes()
+	proof = buf.Bytes()
 	copy(proof[:proofHeadSize], proofHeadBytes)
 
 	return proof, nil
 }
 
 func (tree *Tree) VerifyMembershipProof(index uint64, proof []byte) (bool, error) {
+	hasher := tree.getHasher()
+	defer tree.putHasher(hasher)
+
+	return tree.verifyMembershipProof(hasher, tree.Root(), index, proof)
+}
+
+// verifyMembershipProof verifies proof against root, hashing with hasher.
+func (tree *Tree) verifyMembershipProof(hasher hash.Hash, root Root, index uint64, proof []byte) (bool, error) {
 	if index > tree.indexMax {
 		return false, ErrTooLargeLeafIndex
 	}
-	if uint64(len(proof)) > tree.hashSize*tree.depth+proofHeadSize {
-		return false, ErrTooLargeProofSize
-	}
-	if (uint64(len(proof))-proofHeadSize)%tree.hashSize != 0 {
-		return false, ErrInvalidProofSize
+	if err := tree.SanitizeProof(proof); err != nil {
+		return false, err
 	}
 
 	proofIndex := proofHeadSize
 	proofHead := binary.BigEndian.Uint64(proof[:proofIndex])
 
-	b, ok := tree.levels[tree.depth][index]
+	b, ok := tree.levels[tree.depth].get(index)
 	if !ok {
 		b = tree.defaultNodes[tree.depth]
 	}
 
+	// the nodes of the path alternate between two buffers
+	var bufs [2][]byte
 	for d := tree.depth; d > 0; d-- {
 		var siblingNode []byte
 		if proofHead&1 == 0 {
@@ -213,18 +778,95 @@ func (tree *Tree) VerifyMembershipProof(index uint64, proof []byte) (bool, error
 		}
 
 		var err error
+		dst := bufs[d%2][:0]
 		if index%2 == 0 {
-			b, err = tree.pairHash(b, siblingNode)
+			b, err = tree.pairHashWith(hasher, dst, tree.depth-d+1, b, siblingNode)
 		} else {
-			b, err = tree.pairHash(siblingNode, b)
+			b, err = tree.pairHashWith(hasher, dst, tree.depth-d+1, siblingNode, b)
 		}
 		if err != nil {
 			return false, err
 		}
+		bufs[d%2] = b
 
 		proofHead >>= 1
 		index /= 2
 	}
 
-	return bytes.Equal(b, tree.Root()), nil
+	return tree.equalRoots(b, root), nil
+}
+
+// equalRoots compares a root computed during verification with an expected
+// one, in constant time if WithConstantTimeCompare is set.
+func (tree *Tree) equalRoots(computed, expected Root) bool {
+	if tree.constantTime {
+		return computed.ConstantTimeEqual(expected)
+	}
+	return computed.Equal(expected)
+}
+
+// ProofSizeError is returned for a proof of an unexpected size. It wraps
+// ErrTooLargeProofSize, with Want the largest size allowed,
+// ErrInvalidProofSize, with Want the size the head of the proof calls for,
+// or ErrInvalidProofHead, for a head marking siblings above the root, with
+// Want the size the head calls for without them.
+type ProofSizeError struct {
+	Err      error
+	Got      uint64
+	Want     uint64
+	Depth    uint64
+	HashSize uint64
+}
+
+func (e *ProofSizeError) Error() string {
+	want := fmt.Sprintf("%d", e.Want)
+	switch e.Err {
+	case ErrTooLargeProofSize:
+		want = "at most " + want
+	case ErrInvalidProofHead:
+		want += " for siblings up to the root"
+	}
+	return fmt.Sprintf("%v: got %d bytes, want %s (depth %d, hash size %d)", e.Err, e.Got, want, e.Depth, e.HashSize)
+}
+
+func (e *ProofSizeError) Unwrap() error {
+	return e.Err
+}
+
+func (tree *Tree) proofSizeError(err error, got, want uint64) error {
+	return &ProofSizeError{
+		Err:      err,
+		Got:      got,
+		Want:     want,
+		Depth:    tree.depth,
+		HashSize: tree.hashSize,
+	}
+}
+
+// SanitizeProof checks that proof is well formed for the tree without
+// hashing anything: it must not exceed the size of a proof with every
+// sibling included, its head must not mark siblings above the root, and it
+// must carry exactly one whole sibling per bit set in its head. Every
+// method taking a proof calls it first, and servers may call it on proofs
+// received from the network before doing anything else with them. Size
+// and head errors are returned as a *ProofSizeError.
+func (tree *Tree) SanitizeProof(proof []byte) error {
+	size := uint64(len(proof))
+	if sizeMax := tree.hashSize*tree.depth + proofHeadSize; size > sizeMax {
+		return tree.proofSizeError(ErrTooLargeProofSize, size, sizeMax)
+	}
+	if size < proofHeadSize {
+		return tree.proofSizeError(ErrInvalidProofSize, size, proofHeadSize)
+	}
+
+	proofHead := binary.BigEndian.Uint64(proof[:proofHeadSize])
+	if tree.depth < DepthMax && proofHead>>tree.depth != 0 {
+		want := proofHeadSize + uint64(bits.OnesCount64(proofHead&(1<<tree.depth-1)))*tree.hashSize
+		return tree.proofSizeError(ErrInvalidProofHead, size, want)
+	}
+	if want := proofHeadSize + uint64(bits.OnesCount64(proofHead))*tree.hashSize; size != want {
+		return tree.proofSizeError(ErrInvalidProofSize, size, want)
+	}
+
+	return nil
 }
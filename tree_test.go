@@ -1,9 +1,13 @@
 package merkle
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"hash"
+	"log/slog"
+	"strings"
 	"testing"
 )
 
@@ -18,6 +22,22 @@ func newTestTree(t *testing.T) *Tree {
 	return tree
 }
 
+// testNode returns the node at (d, i) of tree, or nil.
+func testNode(tree *Tree, d, i uint64) []byte {
+	node, _ := tree.levels[d].get(i)
+	return node
+}
+
+// sizedHasher misreports the output size of the underlying hasher.
+type sizedHasher struct {
+	hash.Hash
+	size int
+}
+
+func (hasher *sizedHasher) Size() int {
+	return hasher.size
+}
+
 func TestTree(t *testing.T) {
 	type input struct {
 		hasher hash.Hash
@@ -45,6 +65,42 @@ func TestTree(t *testing.T) {
 				ErrTooLargeTreeDepth,
 			},
 		},
+		{
+			"failure: zero hash size",
+			input{
+				&sizedHasher{sha256.New(), 0},
+				3,
+				nil,
+			},
+			output{
+				"",
+				ErrInvalidHashSize,
+			},
+		},
+		{
+			"failure: too large hash size",
+			input{
+				&sizedHasher{sha256.New(), 65},
+				3,
+				nil,
+			},
+			output{
+				"",
+				ErrInvalidHashSize,
+			},
+		},
+		{
+			"failure: inconsistent hash size",
+			input{
+				&sizedHasher{sha256.New(), 16},
+				3,
+				nil,
+			},
+			output{
+				"",
+				ErrInvalidHashSize,
+			},
+		},
 		{
 			"failure: too large leaf index",
 			input{
@@ -298,7 +354,7 @@ func TestTree_VerifyMembershipProof(t *testing.T) {
 				t.Fatal(err)
 			}
 			ok, err := tree.VerifyMembershipProof(in.index, proof)
-			if err != out.err {
+			if !errors.Is(err, out.err) {
 				t.Errorf("expected: %v, actual: %v", out.err, err)
 			}
 			if err == nil {
@@ -309,3 +365,381 @@ func TestTree_VerifyMembershipProof(t *testing.T) {
 		})
 	}
 }
+
+func TestTree_Update(t *testing.T) {
+	tree, err := NewTree(sha256.New(), 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tree.Update(8, nil); err != ErrTooLargeLeafIndex {
+		t.Errorf("expected: %v, actual: %v", ErrTooLargeLeafIndex, err)
+	}
+
+	for _, index := range []uint64{0, 5, 3} {
+		if err := tree.Update(index, []byte{byte(index)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tree.Delete(5); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := newTestTree(t)
+	for _, index := range []uint64{0, 3} {
+		if err := expected.Update(index, []byte{byte(index)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if rootHex, expectedHex := hex.EncodeToString(tree.Root()), hex.EncodeToString(expected.Root()); rootHex != expectedHex {
+		t.Errorf("expected: %s, actual: %s", expectedHex, rootHex)
+	}
+	for d := range tree.levels {
+		if tree.levels[d].len() != expected.levels[d].len() {
+			t.Errorf("expected: %d, actual: %d", expected.levels[d].len(), tree.levels[d].len())
+		}
+	}
+}
+
+func TestTree_Leaves(t *testing.T) {
+	tree := newTestTree(t)
+
+	leaves := tree.Leaves()
+	if len(leaves) != 2 {
+		t.Fatalf("expected: %d, actual: %d", 2, len(leaves))
+	}
+	for _, index := range []uint64{0, 3} {
+		expected, _ := tree.hash([]byte{byte(index), byte(index), byte(index), byte(index), byte(index), byte(index), byte(index), byte(index)})
+		if hex.EncodeToString(leaves[index]) != hex.EncodeToString(expected) {
+			t.Errorf("expected: %x, actual: %x", expected, leaves[index])
+		}
+	}
+
+	root := hex.EncodeToString(tree.Root())
+	leaves[0][0] ^= 0xff
+	delete(leaves, 3)
+	if !tree.HasLeaf(3) || hex.EncodeToString(tree.Root()) != root {
+		t.Errorf("expected: leaves of the tree untouched")
+	}
+	if leaf := tree.Leaves()[0]; leaf[0] == leaves[0][0] {
+		t.Errorf("expected: %x, actual: %x", leaves[0][0]^0xff, leaf[0])
+	}
+}
+
+func TestTree_Copy(t *testing.T) {
+	tree := newTestTree(t)
+
+	copied, err := tree.Copy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !copied.Equal(tree) {
+		t.Errorf("expected: %t, actual: %t", true, false)
+	}
+	if copied.hasher == tree.hasher {
+		t.Errorf("expected: a hasher of its own")
+	}
+
+	if err := copied.Update(5, []byte{0x05}); err != nil {
+		t.Fatal(err)
+	}
+	node := testNode(copied, 3, 0)
+	node[0] ^= 0xff
+	copied.levels[3].put(0, node)
+	if tree.HasLeaf(5) || testNode(tree, 3, 0)[0] == testNode(copied, 3, 0)[0] {
+		t.Errorf("expected: tree untouched")
+	}
+	if copied.Equal(tree) {
+		t.Errorf("expected: %t, actual: %t", false, true)
+	}
+
+	named, err := NewTreeByName("sha256", 3, map[uint64][]byte{
+		0: []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		3: []byte{0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !named.Equal(tree) {
+		t.Errorf("expected: %t, actual: %t", true, false)
+	}
+	tweaked, err := NewTree(sha256.New(), 3, map[uint64][]byte{
+		0: []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		3: []byte{0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03},
+	}, WithLevelTweak())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tweaked.Equal(tree) {
+		t.Errorf("expected: %t, actual: %t", false, true)
+	}
+	copied, err = named.Copy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !copied.Equal(named) {
+		t.Errorf("expected: %t, actual: %t", true, false)
+	}
+}
+
+type failingNodeStore struct {
+	NodeStore
+	err error
+}
+
+func (store *failingNodeStore) Put(key, value []byte) error {
+	return store.err
+}
+
+// failingKeyNodeStore fails the writes of a single key.
+type failingKeyNodeStore struct {
+	NodeStore
+	key []byte
+	err error
+}
+
+func (store *failingKeyNodeStore) Put(key, value []byte) error {
+	if bytes.Equal(key, store.key) {
+		return store.err
+	}
+	return store.NodeStore.Put(key, value)
+}
+
+func TestTree_Update_failedStoreWrite(t *testing.T) {
+	fileStore, err := NewFileStore(t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &failingKeyNodeStore{NodeStore: fileStore}
+
+	tree, err := NewTree(sha256.New(), 3, map[uint64][]byte{
+		0: []byte{0x00},
+	}, WithNodeStore(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := tree.Root()
+
+	// the root is the last node of the path written
+	store.key, store.err = nodeKey(0, 0), errors.New("disk full")
+	if err := tree.Update(1, []byte{0x01}); err != store.err {
+		t.Fatalf("expected: %v, actual: %v", store.err, err)
+	}
+	if !tree.Root().Equal(root) {
+		t.Errorf("expected: %x, actual: %x", root, tree.Root())
+	}
+	if tree.HasLeaf(1) {
+		t.Errorf("expected: %t, actual: %t", false, true)
+	}
+
+	loaded, err := LoadTree(sha256.New(), 3, fileStore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Root().Equal(root) {
+		t.Errorf("expected: %x, actual: %x", root, loaded.Root())
+	}
+}
+
+func TestTree_WithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	tree, err := NewTree(sha256.New(), 3, map[uint64][]byte{
+		0: []byte{0x00},
+	}, WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "msg=\"built tree\"") {
+		t.Errorf("expected build to be logged: %s", buf.String())
+	}
+
+	storeErr := errors.New("disk full")
+	tree.store = &failingNodeStore{err: storeErr}
+
+	buf.Reset()
+	if err := tree.Update(1, []byte{0x01}); err != storeErr {
+		t.Fatalf("expected: %v, actual: %v", storeErr, err)
+	}
+	if !strings.Contains(buf.String(), "level=ERROR") || !strings.Contains(buf.String(), "error=\"disk full\"") {
+		t.Errorf("expected store error to be logged: %s", buf.String())
+	}
+}
+
+func TestTree_WithConstantTimeCompare(t *testing.T) {
+	tree, err := NewTree(sha256.New(), 3, map[uint64][]byte{
+		0: []byte{0x00},
+		3: []byte{0x03},
+	}, WithConstantTimeCompare())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proof, err := tree.CreateMembershipProof(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := tree.VerifyMembershipProof(3, proof); err != nil || !ok {
+		t.Errorf("expected: %t, actual: %t (%v)", true, ok, err)
+	}
+	if ok, err := tree.VerifyMembershipProof(2, proof); err != nil || ok {
+		t.Errorf("expected: %t, actual: %t (%v)", false, ok, err)
+	}
+}
+
+func TestTree_SanitizeProof(t *testing.T) {
+	tree := newTestTree(t)
+
+	proof, err := tree.CreateMembershipProof(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name  string
+		proof []byte
+		err   error
+	}{
+		{
+			"failure: too large proof size",
+			make([]byte, proofHeadSize+3*sha256.Size+1),
+			ErrTooLargeProofSize,
+		},
+		{
+			"failure: no head",
+			proof[:proofHeadSize-1],
+			ErrInvalidProofSize,
+		},
+		{
+			"failure: sibling above root",
+			[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x08},
+			ErrInvalidProofHead,
+		},
+		{
+			"failure: partial sibling",
+			proof[:len(proof)-1],
+			ErrInvalidProofSize,
+		},
+		{
+			"failure: extra sibling",
+			append(append([]byte(nil), proof...), make([]byte, sha256.Size)...),
+			ErrInvalidProofSize,
+		},
+		{
+			"success",
+			proof,
+			nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tree.SanitizeProof(tc.proof); !errors.Is(err, tc.err) {
+				t.Errorf("expected: %v, actual: %v", tc.err, err)
+			}
+		})
+	}
+
+	// the head marks a sibling above the root and the one at height 0,
+	// which is carried
+	headProof := append([]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x09}, make([]byte, sha256.Size)...)
+	var sizeErr *ProofSizeError
+	if err := tree.SanitizeProof(headProof); !errors.As(err, &sizeErr) {
+		t.Fatalf("expected: %T, actual: %v", sizeErr, err)
+	}
+	expected := ProofSizeError{ErrInvalidProofHead, proofHeadSize + sha256.Size, proofHeadSize + sha256.Size, 3, sha256.Size}
+	if *sizeErr != expected {
+		t.Errorf("expected: %+v, actual: %+v", expected, *sizeErr)
+	}
+}
+
+func TestTree_WithHasherPool(t *testing.T) {
+	tree, err := NewTree(sha256.New(), 3, map[uint64][]byte{
+		0: []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		3: []byte{0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03},
+	}, WithHasherPool(sha256.New))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := newTestTree(t)
+	if !tree.Root().Equal(expected.Root()) {
+		t.Errorf("expected: %x, actual: %x", expected.Root(), tree.Root())
+	}
+}
+
+func TestTree_WithLevelTweak(t *testing.T) {
+	leaves := map[uint64][]byte{
+		0: []byte{0x00},
+		3: []byte{0x03},
+	}
+	tree, err := NewTree(sha256.New(), 2, leaves, WithLevelTweak())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := func(b ...[]byte) []byte {
+		d := sha256.Sum256(bytes.Join(b, nil))
+		return d[:]
+	}
+	defaultNode := h(make([]byte, sha256.Size))
+	expected := h(
+		[]byte{0x02},
+		h([]byte{0x01}, h([]byte{0x00}), defaultNode),
+		h([]byte{0x01}, defaultNode, h([]byte{0x03})),
+	)
+	if !tree.Root().Equal(expected) {
+		t.Errorf("expected: %x, actual: %x", expected, tree.Root())
+	}
+
+	// writes and proofs hash pairs the same way as building does
+	untweaked, err := NewTree(sha256.New(), 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for index, leaf := range leaves {
+		if err := untweaked.Update(index, leaf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if untweaked.Root().Equal(tree.Root()) {
+		t.Errorf("expected roots to differ: %x", tree.Root())
+	}
+	if err := tree.Update(1, []byte{0x01}); err != nil {
+		t.Fatal(err)
+	}
+	if mismatches, err := tree.Audit(); err != nil || len(mismatches) != 0 {
+		t.Errorf("expected: no mismatches, actual: %v (%v)", mismatches, err)
+	}
+	proof, err := tree.CreateMembershipProof(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := tree.VerifyAgainstRoots(1, []byte{0x01}, proof, [][]byte{tree.Root()}); err != nil || !ok {
+		t.Errorf("expected: %t, actual: %t (%v)", true, ok, err)
+	}
+}
+
+func TestProofSizeError(t *testing.T) {
+	tree := newTestTree(t)
+
+	proof, err := tree.CreateMembershipProof(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = tree.SanitizeProof(proof[:len(proof)-1])
+
+	var sizeErr *ProofSizeError
+	if !errors.As(err, &sizeErr) {
+		t.Fatalf("expected: %T, actual: %T", sizeErr, err)
+	}
+	if sizeErr.Got != uint64(len(proof)-1) || sizeErr.Want != uint64(len(proof)) || sizeErr.Depth != 3 || sizeErr.HashSize != sha256.Size {
+		t.Errorf("expected: %d/%d/%d/%d, actual: %d/%d/%d/%d", len(proof)-1, len(proof), 3, sha256.Size, sizeErr.Got, sizeErr.Want, sizeErr.Depth, sizeErr.HashSize)
+	}
+	if msg := "invalid proof size: got 39 bytes, want 40 (depth 3, hash size 32)"; err.Error() != msg {
+		t.Errorf("expected: %s, actual: %s", msg, err.Error())
+	}
+}
@@ -1,5 +1,9 @@
 package merkle
 
+import (
+	"sort"
+)
+
 func maxIndex(leaves map[uint64][]byte) uint64 {
 	max := uint64(0)
 	for i, _ := range leaves {
@@ -9,3 +13,29 @@ func maxIndex(leaves map[uint64][]byte) uint64 {
 	}
 	return max
 }
+
+func sortedIndices[V any](leaves map[uint64]V) []uint64 {
+	indices := make([]uint64, 0, len(leaves))
+	for i, _ := range leaves {
+		indices = append(indices, i)
+	}
+	sort.Slice(indices, func(i, j int) bool {
+		return indices[i] < indices[j]
+	})
+	return indices
+}
+
+func uniqueSorted(indices []uint64) []uint64 {
+	sorted := append([]uint64(nil), indices...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	unique := sorted[:0]
+	for i, index := range sorted {
+		if i == 0 || index != sorted[i-1] {
+			unique = append(unique, index)
+		}
+	}
+	return unique
+}
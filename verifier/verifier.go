@@ -0,0 +1,156 @@
+// Package verifier verifies membership proofs of sparse Merkle trees
+// without any of the tree itself. It depends on nothing beyond the standard
+// library and keeps no state but the default nodes, for light clients such
+// as gomobile or TinyGo builds that must not pull in node stores.
+package verifier
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"hash"
+)
+
+const (
+	DepthMax    uint64 = 64
+	HashSizeMax uint64 = 64
+
+	proofHeadSize uint64 = 8
+)
+
+var (
+	ErrTooLargeTreeDepth = errors.New("too large tree depth")
+	ErrTooLargeLeafIndex = errors.New("too large leaf index")
+	ErrInvalidProofSize  = errors.New("invalid proof size")
+	ErrInvalidHashSize   = errors.New("invalid hash size")
+)
+
+type Option func(*Verifier)
+
+// WithLevelTweak verifies proofs of trees built with the option of the same
+// name, which hash every internal node as H(h || left || right).
+func WithLevelTweak() Option {
+	return func(v *Verifier) {
+		v.levelTweak = true
+	}
+}
+
+// Verifier verifies the proofs of trees of one hasher and depth. Like the
+// hasher, it must not be used by several goroutines at once.
+type Verifier struct {
+	hasher       hash.Hash
+	hashSize     uint64
+	depth        uint64
+	levelTweak   bool
+	defaultNodes [][]byte
+}
+
+func New(hasher hash.Hash, depth uint64, opts ...Option) (*Verifier, error) {
+	if depth > DepthMax {
+		return nil, ErrTooLargeTreeDepth
+	}
+	if size := hasher.Size(); size <= 0 || uint64(size) > HashSizeMax {
+		return nil, ErrInvalidHashSize
+	}
+
+	v := &Verifier{
+		hasher:       hasher,
+		hashSize:     uint64(hasher.Size()),
+		depth:        depth,
+		defaultNodes: make([][]byte, depth+1),
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	v.defaultNodes[depth] = v.hash(make([]byte, v.hashSize))
+	for d := depth; d > 0; d-- {
+		v.defaultNodes[d-1] = v.pairHash(depth-d+1, v.defaultNodes[d], v.defaultNodes[d])
+	}
+
+	return v, nil
+}
+
+// DecodeProof returns the siblings of a proof from the leaf level up, with
+// nil in place of default nodes.
+func (v *Verifier) DecodeProof(proof []byte) ([][]byte, error) {
+	if uint64(len(proof)) < proofHeadSize {
+		return nil, ErrInvalidProofSize
+	}
+	proofHead := binary.BigEndian.Uint64(proof[:proofHeadSize])
+	proofIndex := proofHeadSize
+
+	siblings := make([][]byte, v.depth)
+	for h := range siblings {
+		if proofHead&1 == 1 {
+			if proofIndex+v.hashSize > uint64(len(proof)) {
+				return nil, ErrInvalidProofSize
+			}
+			siblings[h] = proof[proofIndex : proofIndex+v.hashSize]
+			proofIndex += v.hashSize
+		}
+		proofHead >>= 1
+	}
+	if proofHead != 0 || proofIndex != uint64(len(proof)) {
+		return nil, ErrInvalidProofSize
+	}
+
+	return siblings, nil
+}
+
+// ComputeRoot returns the root a proof of leaf at index leads to. A nil
+// leaf stands for an empty one.
+func (v *Verifier) ComputeRoot(index uint64, leaf, proof []byte) ([]byte, error) {
+	if v.depth < DepthMax && index>>v.depth != 0 {
+		return nil, ErrTooLargeLeafIndex
+	}
+
+	siblings, err := v.DecodeProof(proof)
+	if err != nil {
+		return nil, err
+	}
+
+	node := v.defaultNodes[v.depth]
+	if leaf != nil {
+		node = v.hash(leaf)
+	}
+	for h, siblingNode := range siblings {
+		if siblingNode == nil {
+			siblingNode = v.defaultNodes[v.depth-uint64(h)]
+		}
+		if index%2 == 0 {
+			node = v.pairHash(uint64(h)+1, node, siblingNode)
+		} else {
+			node = v.pairHash(uint64(h)+1, siblingNode, node)
+		}
+		index /= 2
+	}
+
+	return node, nil
+}
+
+// Verify checks that leaf is at index in the tree of root, according to
+// proof. The roots are compared in constant time.
+func (v *Verifier) Verify(root []byte, index uint64, leaf, proof []byte) (bool, error) {
+	computed, err := v.ComputeRoot(index, leaf, proof)
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(computed, root) == 1, nil
+}
+
+func (v *Verifier) hash(b []byte) []byte {
+	v.hasher.Reset()
+	v.hasher.Write(b)
+	return v.hasher.Sum(nil)
+}
+
+func (v *Verifier) pairHash(h uint64, b1, b2 []byte) []byte {
+	v.hasher.Reset()
+	if v.levelTweak {
+		v.hasher.Write([]byte{byte(h)})
+	}
+	v.hasher.Write(b1)
+	v.hasher.Write(b2)
+	return v.hasher.Sum(nil)
+}
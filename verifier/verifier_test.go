@@ -0,0 +1,105 @@
+package verifier_test
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	merkle "github.com/m0t0k1ch1/sparse-merkle-tree"
+	"github.com/m0t0k1ch1/sparse-merkle-tree/verifier"
+)
+
+func TestVerifier_Verify(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		treeOpts     []merkle.Option
+		verifierOpts []verifier.Option
+	}{
+		{"plain", nil, nil},
+		{"level tweak", []merkle.Option{merkle.WithLevelTweak()}, []verifier.Option{verifier.WithLevelTweak()}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tree, err := merkle.NewTree(sha256.New(), 4, map[uint64][]byte{
+				0:  []byte{0x00},
+				5:  []byte{0x05},
+				15: []byte{0x0f},
+			}, tc.treeOpts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			v, err := verifier.New(sha256.New(), 4, tc.verifierOpts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			type input struct {
+				index uint64
+				leaf  []byte
+			}
+			type output struct {
+				ok  bool
+				err error
+			}
+			testCases := []struct {
+				name string
+				in   input
+				out  output
+			}{
+				{
+					"failure: too large leaf index",
+					input{16, []byte{0x05}},
+					output{false, verifier.ErrTooLargeLeafIndex},
+				},
+				{
+					"success: member",
+					input{5, []byte{0x05}},
+					output{true, nil},
+				},
+				{
+					"success: wrong leaf",
+					input{5, []byte{0x06}},
+					output{false, nil},
+				},
+				{
+					"success: empty leaf",
+					input{6, nil},
+					output{true, nil},
+				},
+			}
+
+			for _, tc := range testCases {
+				t.Run(tc.name, func(t *testing.T) {
+					proof, err := tree.CreateMembershipProof(tc.in.index % 16)
+					if err != nil {
+						t.Fatal(err)
+					}
+					ok, err := v.Verify(tree.Root(), tc.in.index, tc.in.leaf, proof)
+					if err != tc.out.err {
+						t.Errorf("expected: %v, actual: %v", tc.out.err, err)
+					}
+					if ok != tc.out.ok {
+						t.Errorf("expected: %t, actual: %t", tc.out.ok, ok)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestVerifier_DecodeProof(t *testing.T) {
+	v, err := verifier.New(sha256.New(), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, proof := range [][]byte{
+		nil,
+		// a sibling above the root
+		{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10},
+		// a sibling missing
+		{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01},
+	} {
+		if _, err := v.DecodeProof(proof); err != verifier.ErrInvalidProofSize {
+			t.Errorf("expected: %v, actual: %v", verifier.ErrInvalidProofSize, err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package merkle
+
+import (
+	"errors"
+)
+
+// VerifyFailure tells why a membership proof does not verify.
+type VerifyFailure int
+
+const (
+	VerifyFailureNone VerifyFailure = iota
+	// VerifyFailureLeafIndex is for a leaf index beyond the tree.
+	VerifyFailureLeafIndex
+	// VerifyFailureProofSize is for a proof whose size does not match its
+	// head or the tree.
+	VerifyFailureProofSize
+	// VerifyFailureProofHead is for a proof whose head marks siblings above
+	// the root.
+	VerifyFailureProofHead
+	// VerifyFailureRootMismatch is for a well formed proof that does not
+	// lead to the root of the tree.
+	VerifyFailureRootMismatch
+)
+
+func (failure VerifyFailure) String() string {
+	switch failure {
+	case VerifyFailureNone:
+		return "none"
+	case VerifyFailureLeafIndex:
+		return "too large leaf index"
+	case VerifyFailureProofSize:
+		return "invalid proof size"
+	case VerifyFailureProofHead:
+		return "invalid proof head"
+	case VerifyFailureRootMismatch:
+		return "root mismatch"
+	default:
+		return "unknown"
+	}
+}
+
+// VerifyMembershipProofDetailed is VerifyMembershipProof reporting why the
+// proof does not verify, VerifyFailureNone meaning that it does. The error
+// is left for failures of the hasher.
+func (tree *Tree) VerifyMembershipProofDetailed(index uint64, proof []byte) (VerifyFailure, error) {
+	if index > tree.indexMax {
+		return VerifyFailureLeafIndex, nil
+	}
+	if err := tree.SanitizeProof(proof); err != nil {
+		if errors.Is(err, ErrInvalidProofHead) {
+			return VerifyFailureProofHead, nil
+		}
+		return VerifyFailureProofSize, nil
+	}
+
+	ok, err := tree.VerifyMembershipProof(index, proof)
+	if err != nil {
+		return VerifyFailureNone, err
+	}
+	if !ok {
+		return VerifyFailureRootMismatch, nil
+	}
+	return VerifyFailureNone, nil
+}
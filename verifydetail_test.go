@@ -0,0 +1,64 @@
+package merkle
+
+import (
+	"testing"
+)
+
+func TestTree_VerifyMembershipProofDetailed(t *testing.T) {
+	tree := newTestTree(t)
+
+	proof, err := tree.CreateMembershipProof(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name    string
+		index   uint64
+		proof   []byte
+		failure VerifyFailure
+	}{
+		{
+			"failure: too large leaf index",
+			8,
+			proof,
+			VerifyFailureLeafIndex,
+		},
+		{
+			"failure: invalid proof size",
+			3,
+			proof[:len(proof)-1],
+			VerifyFailureProofSize,
+		},
+		{
+			"failure: invalid proof head",
+			3,
+			[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x08},
+			VerifyFailureProofHead,
+		},
+		{
+			"failure: root mismatch",
+			2,
+			proof,
+			VerifyFailureRootMismatch,
+		},
+		{
+			"success",
+			3,
+			proof,
+			VerifyFailureNone,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			failure, err := tree.VerifyMembershipProofDetailed(tc.index, tc.proof)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if failure != tc.failure {
+				t.Errorf("expected: %v, actual: %v", tc.failure, failure)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package merkle
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"math/big"
+)
+
+// RSA-FDH-VRF-SHA256 (RFC 9381, section 4)
+const (
+	vrfSuite byte = 0x01
+)
+
+var (
+	ErrInvalidVRFProof = errors.New("invalid vrf proof")
+	ErrInvalidVRFKey   = errors.New("invalid vrf key")
+)
+
+type VRFPrivateKey struct {
+	key *rsa.PrivateKey
+}
+
+func NewVRFPrivateKey(key *rsa.PrivateKey) *VRFPrivateKey {
+	return &VRFPrivateKey{
+		key: key,
+	}
+}
+
+func (sk *VRFPrivateKey) Public() *VRFPublicKey {
+	return NewVRFPublicKey(&sk.key.PublicKey)
+}
+
+// Prove returns the proof pi of alpha. The private exponentiation of
+// math/big is not constant time, so it is blinded with a fresh random r as
+// (m * r^e)^d * r^-1, run over the primes of the key when it is precomputed,
+// and checked against the public key before pi is returned. It fails only
+// if no randomness can be read or the key is inconsistent.
+func (sk *VRFPrivateKey) Prove(alpha []byte) ([]byte, error) {
+	pk := sk.Public()
+	n, e := sk.key.N, big.NewInt(int64(sk.key.E))
+
+	m := new(big.Int).SetBytes(pk.encodeMessage(alpha))
+
+	var r, rInv *big.Int
+	for rInv == nil {
+		var err error
+		if r, err = rand.Int(rand.Reader, n); err != nil {
+			return nil, err
+		}
+		if r.Sign() == 0 {
+			continue
+		}
+		rInv = new(big.Int).ModInverse(r, n)
+	}
+
+	c := new(big.Int).Exp(r, e, n)
+	c.Mul(c, m).Mod(c, n)
+
+	s := sk.decrypt(c)
+	s.Mul(s, rInv).Mod(s, n)
+
+	// a fault in the private exponentiation must not leak a factor of n
+	if new(big.Int).Exp(s, e, n).Cmp(m) != 0 {
+		return nil, ErrInvalidVRFKey
+	}
+
+	return i2osp(s, pk.size()), nil
+}
+
+// decrypt returns c^d mod n, using the CRT values of a key with two primes
+// if they are precomputed.
+func (sk *VRFPrivateKey) decrypt(c *big.Int) *big.Int {
+	key := sk.key
+	if len(key.Primes) != 2 || key.Precomputed.Dp == nil {
+		return new(big.Int).Exp(c, key.D, key.N)
+	}
+
+	p, q := key.Primes[0], key.Primes[1]
+	m1 := new(big.Int).Exp(c, key.Precomputed.Dp, p)
+	m2 := new(big.Int).Exp(c, key.Precomputed.Dq, q)
+
+	// m2 + q * (qInv * (m1 - m2) mod p)
+	h := m1.Sub(m1, m2)
+	h.Mul(h, key.Precomputed.Qinv).Mod(h, p)
+	h.Mul(h, q)
+	return h.Add(h, m2)
+}
+
+type VRFPublicKey struct {
+	key *rsa.PublicKey
+}
+
+func NewVRFPublicKey(key *rsa.PublicKey) *VRFPublicKey {
+	return &VRFPublicKey{
+		key: key,
+	}
+}
+
+func (pk *VRFPublicKey) Verify(alpha, pi []byte) ([]byte, error) {
+	k := pk.size()
+	if len(pi) != k {
+		return nil, ErrInvalidVRFProof
+	}
+
+	s := new(big.Int).SetBytes(pi)
+	if s.Cmp(pk.key.N) >= 0 {
+		return nil, ErrInvalidVRFProof
+	}
+	m := new(big.Int).Exp(s, big.NewInt(int64(pk.key.E)), pk.key.N)
+	if m.BitLen() > (k-1)*8 {
+		return nil, ErrInvalidVRFProof
+	}
+
+	if subtle.ConstantTimeCompare(i2osp(m, k-1), pk.encodeMessage(alpha)) != 1 {
+		return nil, ErrInvalidVRFProof
+	}
+
+	return VRFProofToHash(pi), nil
+}
+
+func (pk *VRFPublicKey) size() int {
+	return (pk.key.N.BitLen() + 7) / 8
+}
+
+func (pk *VRFPublicKey) encodeMessage(alpha []byte) []byte {
+	k := pk.size()
+
+	seed := []byte{vrfSuite, 0x01}
+	seed = binary.BigEndian.AppendUint32(seed, uint32(k))
+	seed = append(seed, i2osp(pk.key.N, k)...)
+	seed = append(seed, alpha...)
+
+	return mgf1(seed, k-1)
+}
+
+func VRFProofToHash(pi []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{vrfSuite, 0x02})
+	h.Write(pi)
+	return h.Sum(nil)
+}
+
+func VRFIndex(beta []byte, depth uint64) uint64 {
+	b := make([]byte, 8)
+	copy(b, beta)
+	return binary.BigEndian.Uint64(b) >> (DepthMax - depth)
+}
+
+func (tree *Tree) CreateVRFMembershipProof(sk *VRFPrivateKey, id []byte) (uint64, []byte, []byte, error) {
+	pi, err := sk.Prove(id)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	index := VRFIndex(VRFProofToHash(pi), tree.depth)
+
+	proof, err := tree.CreateMembershipProof(index)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	return index, pi, proof, nil
+}
+
+func (tree *Tree) VerifyVRFMembershipProof(pk *VRFPublicKey, id, pi, proof []byte) (bool, error) {
+	beta, err := pk.Verify(id, pi)
+	if err != nil {
+		return false, err
+	}
+
+	return tree.VerifyMembershipProof(VRFIndex(beta, tree.depth), proof)
+}
+
+func mgf1(seed []byte, length int) []byte {
+	out := make([]byte, 0, length+sha256.Size)
+	for counter := uint32(0); len(out) < length; counter++ {
+		h := sha256.New()
+		h.Write(seed)
+		h.Write(binary.BigEndian.AppendUint32(nil, counter))
+		out = h.Sum(out)
+	}
+	return out[:length]
+}
+
+func i2osp(x *big.Int, length int) []byte {
+	return x.FillBytes(make([]byte, length))
+}
@@ -0,0 +1,153 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"math/big"
+	"testing"
+)
+
+func newTestVRFPrivateKey(t *testing.T) *VRFPrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewVRFPrivateKey(key)
+}
+
+func TestVRF(t *testing.T) {
+	sk := newTestVRFPrivateKey(t)
+	pk := sk.Public()
+
+	pi, err := sk.Prove([]byte("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type input struct {
+		alpha []byte
+		pi    []byte
+	}
+	type output struct {
+		err error
+	}
+	testCases := []struct {
+		name string
+		in   input
+		out  output
+	}{
+		{
+			"failure: invalid proof size",
+			input{
+				[]byte("alice"),
+				pi[1:],
+			},
+			output{
+				ErrInvalidVRFProof,
+			},
+		},
+		{
+			"failure: another input",
+			input{
+				[]byte("bob"),
+				pi,
+			},
+			output{
+				ErrInvalidVRFProof,
+			},
+		},
+		{
+			"success",
+			input{
+				[]byte("alice"),
+				pi,
+			},
+			output{
+				nil,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			in, out := tc.in, tc.out
+
+			beta, err := pk.Verify(in.alpha, in.pi)
+			if err != out.err {
+				t.Errorf("expected: %v, actual: %v", out.err, err)
+			}
+			if err == nil {
+				if string(beta) != string(VRFProofToHash(pi)) {
+					t.Errorf("expected: %x, actual: %x", VRFProofToHash(pi), beta)
+				}
+			}
+		})
+	}
+}
+
+func TestVRFPrivateKey_Prove(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alpha := []byte("alice")
+
+	// the unblinded RSA-FDH-VRF proof
+	pk := NewVRFPublicKey(&key.PublicKey)
+	m := new(big.Int).SetBytes(pk.encodeMessage(alpha))
+	expected := i2osp(new(big.Int).Exp(m, key.D, key.N), pk.size())
+
+	withoutCRT := *key
+	withoutCRT.Precomputed = rsa.PrecomputedValues{}
+	inconsistent := *key
+	inconsistent.D = new(big.Int).Add(key.D, big.NewInt(1))
+	inconsistent.Precomputed = rsa.PrecomputedValues{}
+
+	testCases := []struct {
+		name string
+		key  *rsa.PrivateKey
+		err  error
+	}{
+		{"failure: inconsistent key", &inconsistent, ErrInvalidVRFKey},
+		{"success: crt", key, nil},
+		{"success: without crt", &withoutCRT, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pi, err := NewVRFPrivateKey(tc.key).Prove(alpha)
+			if err != tc.err {
+				t.Fatalf("expected: %v, actual: %v", tc.err, err)
+			}
+			if err == nil && !bytes.Equal(pi, expected) {
+				t.Errorf("expected: %x, actual: %x", expected, pi)
+			}
+		})
+	}
+}
+
+func TestTree_VerifyVRFMembershipProof(t *testing.T) {
+	sk := newTestVRFPrivateKey(t)
+	tree := newTestTree(t)
+
+	index, pi, proof, err := tree.CreateVRFMembershipProof(sk, []byte("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index > tree.indexMax {
+		t.Errorf("expected: <= %d, actual: %d", tree.indexMax, index)
+	}
+
+	ok, err := tree.VerifyVRFMembershipProof(sk.Public(), []byte("alice"), pi, proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Errorf("expected: %t, actual: %t", true, ok)
+	}
+
+	if _, err := tree.VerifyVRFMembershipProof(sk.Public(), []byte("bob"), pi, proof); err != ErrInvalidVRFProof {
+		t.Errorf("expected: %v, actual: %v", ErrInvalidVRFProof, err)
+	}
+}
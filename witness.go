@@ -0,0 +1,144 @@
+package merkle
+
+import (
+	"errors"
+	"hash"
+)
+
+var (
+	ErrNotInWitness          = errors.New("not in witness")
+	ErrWitnessRootMismatch   = errors.New("witness root mismatch")
+	ErrUnexpectedWitnessNode = errors.New("unexpected witness node")
+)
+
+type WitnessNode struct {
+	Depth uint64
+	Index uint64
+	Node  []byte
+}
+
+// Witness holds the nodes needed to read and write the leaves at Indices
+// without the rest of the tree: the nodes of the occupied ones among them
+// and the non-default siblings on their paths that cannot be computed from
+// other paths. Nodes left out are default.
+type Witness struct {
+	Indices []uint64
+	Nodes   []WitnessNode
+}
+
+func (tree *Tree) Witness(indices []uint64) (*Witness, error) {
+	indices = uniqueSorted(indices)
+	for _, index := range indices {
+		if index > tree.indexMax {
+			return nil, ErrTooLargeLeafIndex
+		}
+	}
+
+	witness := &Witness{
+		Indices: indices,
+	}
+	for _, index := range indices {
+		if node, ok := tree.levels[tree.depth].get(index); ok {
+			witness.Nodes = append(witness.Nodes, WitnessNode{tree.depth, index, node})
+		}
+	}
+	for _, pos := range tree.batchSiblingPositions(indices) {
+		d := tree.depth - pos.height
+		if node, ok := tree.levels[d].get(pos.index); ok {
+			witness.Nodes = append(witness.Nodes, WitnessNode{d, pos.index, node})
+		}
+	}
+
+	return witness, nil
+}
+
+// PartialTree is a tree that only knows the nodes of a witness, and can
+// read and write the leaves covered by it and compute the resulting root as
+// the full tree would.
+type PartialTree struct {
+	tree    *Tree
+	indices map[uint64]struct{}
+}
+
+// NewPartialTree builds a partial tree from witness, which must yield
+// oldRoot, so that the leaves read from it can be trusted as far as oldRoot
+// is. Only the leaves at the indices of the witness and the siblings on
+// their paths are accepted, so that the root is computed from them alone
+// and no node can stand in for the leaves below it.
+func NewPartialTree(hasher hash.Hash, depth uint64, witness *Witness, oldRoot Root, opts ...Option) (*PartialTree, error) {
+	tree, err := NewTree(hasher, depth, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	indices := uniqueSorted(witness.Indices)
+	ptree := &PartialTree{
+		tree:    tree,
+		indices: make(map[uint64]struct{}, len(indices)),
+	}
+	positions := make(map[nodePosition]struct{}, len(indices))
+	for _, index := range indices {
+		if index > tree.indexMax {
+			return nil, ErrTooLargeLeafIndex
+		}
+		ptree.indices[index] = struct{}{}
+		positions[nodePosition{0, index}] = struct{}{}
+	}
+	for _, pos := range tree.batchSiblingPositions(indices) {
+		positions[pos] = struct{}{}
+	}
+
+	for _, wnode := range witness.Nodes {
+		if wnode.Depth > tree.depth {
+			return nil, ErrTooLargeTreeDepth
+		}
+		if _, ok := positions[nodePosition{tree.depth - wnode.Depth, wnode.Index}]; !ok {
+			return nil, ErrUnexpectedWitnessNode
+		}
+		if uint64(len(wnode.Node)) != tree.hashSize {
+			return nil, ErrInvalidHashSize
+		}
+		if err := tree.putNode(wnode.Depth, wnode.Index, tree.ingest(wnode.Node)); err != nil {
+			return nil, err
+		}
+	}
+
+	// absent siblings are default, and the nodes on the paths of the witness
+	// are all left out of it, so they are computed bottom-up as for a full
+	// tree
+	if err := tree.buildInternalNodes(); err != nil {
+		return nil, err
+	}
+	if !tree.equalRoots(tree.Root(), oldRoot) {
+		return nil, ErrWitnessRootMismatch
+	}
+
+	return ptree, nil
+}
+
+// Get returns the node of the leaf at index, or nil if it is empty.
+func (ptree *PartialTree) Get(index uint64) ([]byte, error) {
+	if _, ok := ptree.indices[index]; !ok {
+		return nil, ErrNotInWitness
+	}
+	node, _ := ptree.tree.levels[ptree.tree.depth].get(index)
+	return node, nil
+}
+
+func (ptree *PartialTree) Update(index uint64, leaf []byte) error {
+	if _, ok := ptree.indices[index]; !ok {
+		return ErrNotInWitness
+	}
+	return ptree.tree.Update(index, leaf)
+}
+
+func (ptree *PartialTree) Delete(index uint64) error {
+	if _, ok := ptree.indices[index]; !ok {
+		return ErrNotInWitness
+	}
+	return ptree.tree.Delete(index)
+}
+
+func (ptree *PartialTree) Root() Root {
+	return ptree.tree.Root()
+}
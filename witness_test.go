@@ -0,0 +1,103 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestTree_Witness(t *testing.T) {
+	tree, err := NewTree(sha256.New(), 4, map[uint64][]byte{
+		0:  []byte{0x00},
+		3:  []byte{0x03},
+		6:  []byte{0x06},
+		9:  []byte{0x09},
+		15: []byte{0x0f},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tree.Witness([]uint64{16}); err != ErrTooLargeLeafIndex {
+		t.Errorf("expected: %v, actual: %v", ErrTooLargeLeafIndex, err)
+	}
+
+	indices := []uint64{9, 2, 3}
+	witness, err := tree.Witness(indices)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewPartialTree(sha256.New(), 4, witness, tree.defaultNodes[0]); err != ErrWitnessRootMismatch {
+		t.Errorf("expected: %v, actual: %v", ErrWitnessRootMismatch, err)
+	}
+
+	// nodes standing in for the leaves below them
+	for _, forged := range []*Witness{
+		{
+			Indices: []uint64{3},
+			Nodes:   []WitnessNode{{1, 0, testNode(tree, 1, 0)}, {1, 1, testNode(tree, 1, 1)}},
+		},
+		{
+			Indices: []uint64{3},
+			Nodes:   []WitnessNode{{0, 0, tree.Root()}},
+		},
+	} {
+		if _, err := NewPartialTree(sha256.New(), 4, forged, tree.Root()); err != ErrUnexpectedWitnessNode {
+			t.Errorf("expected: %v, actual: %v", ErrUnexpectedWitnessNode, err)
+		}
+	}
+
+	ptree, err := NewPartialTree(sha256.New(), 4, witness, tree.Root())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if node, err := ptree.Get(3); err != nil || !bytes.Equal(node, testNode(tree, 4, 3)) {
+		t.Errorf("expected: %x, actual: %x (%v)", testNode(tree, 4, 3), node, err)
+	}
+	if node, err := ptree.Get(2); err != nil || node != nil {
+		t.Errorf("expected: %x, actual: %x (%v)", []byte(nil), node, err)
+	}
+	if _, err := ptree.Get(6); err != ErrNotInWitness {
+		t.Errorf("expected: %v, actual: %v", ErrNotInWitness, err)
+	}
+
+	if err := ptree.Update(6, []byte{0x07}); err != ErrNotInWitness {
+		t.Errorf("expected: %v, actual: %v", ErrNotInWitness, err)
+	}
+	if err := ptree.Delete(15); err != ErrNotInWitness {
+		t.Errorf("expected: %v, actual: %v", ErrNotInWitness, err)
+	}
+
+	for _, write := range []struct {
+		index uint64
+		leaf  []byte
+	}{
+		{2, []byte{0x02}},
+		{9, []byte{0x0a}},
+		{3, []byte{0x04}},
+	} {
+		if err := ptree.Update(write.index, write.leaf); err != nil {
+			t.Fatal(err)
+		}
+		if err := tree.Update(write.index, write.leaf); err != nil {
+			t.Fatal(err)
+		}
+		if !ptree.Root().Equal(tree.Root()) {
+			t.Errorf("expected: %x, actual: %x", tree.Root(), ptree.Root())
+		}
+	}
+
+	for _, index := range []uint64{3, 9} {
+		if err := ptree.Delete(index); err != nil {
+			t.Fatal(err)
+		}
+		if err := tree.Delete(index); err != nil {
+			t.Fatal(err)
+		}
+		if !ptree.Root().Equal(tree.Root()) {
+			t.Errorf("expected: %x, actual: %x", tree.Root(), ptree.Root())
+		}
+	}
+}